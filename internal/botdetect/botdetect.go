@@ -0,0 +1,257 @@
+// Package botdetect classifies clicks made by bots, crawlers, link
+// unfurlers, security scanners and browser prefetchers.
+package botdetect
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/usecase"
+)
+
+const (
+	ReasonUserAgent   = "user-agent"
+	ReasonEmptyUA     = "empty-user-agent"
+	ReasonHead        = "head-request"
+	ReasonPrefetch    = "prefetch"
+	ReasonHeaders     = "missing-browser-headers"
+	ReasonBurst       = "burst"
+	maxTrackedClients = 100000
+)
+
+// DefaultPatterns match User-Agents of well-known bots. Patterns from the
+// file configured with BOT_PATTERNS_FILE are added to these.
+var DefaultPatterns = []string{
+	`bot\b`, `crawl`, `spider`, `slurp`, `facebookexternalhit`, `facebookcatalog`,
+	`slack-imgproxy`, `slackbot`, `twitterbot`, `linkedinbot`, `discordbot`, `telegrambot`,
+	`whatsapp`, `skypeuripreview`, `embedly`, `pinterest`, `redditbot`, `applebot`,
+	`bingpreview`, `googlebot`, `adsbot-google`, `mediapartners-google`, `google-read-aloud`,
+	`headlesschrome`, `phantomjs`, `python-requests`, `python-urllib`, `aiohttp`, `curl/`,
+	`wget/`, `go-http-client`, `java/`, `okhttp`, `axios/`, `node-fetch`, `libwww-perl`,
+	`scrapy`, `httpclient`, `barracuda`, `proofpoint`, `mimecast`, `urlscan`, `virustotal`,
+	`preview`, `monitor`, `uptime`,
+}
+
+// Config is loaded from BOT_* env vars, see LoadConfig.
+type Config struct {
+	PatternsFile   string
+	ReloadInterval time.Duration
+	// HeaderHeuristics flags requests without the headers every browser
+	// sends on navigation (Accept-Language, a specific Accept).
+	HeaderHeuristics bool
+	// A client making more than BurstThreshold clicks within BurstWindow
+	// is treated as a scanner for the rest of the window.
+	BurstThreshold int
+	BurstWindow    time.Duration
+}
+
+func LoadConfig() Config {
+	cfg := Config{
+		PatternsFile:     os.Getenv("BOT_PATTERNS_FILE"),
+		ReloadInterval:   time.Minute,
+		HeaderHeuristics: true,
+		BurstThreshold:   30,
+		BurstWindow:      time.Minute,
+	}
+	if d, err := time.ParseDuration(os.Getenv("BOT_PATTERNS_RELOAD_INTERVAL")); err == nil && d > 0 {
+		cfg.ReloadInterval = d
+	}
+	if v, err := strconv.ParseBool(os.Getenv("BOT_HEADER_HEURISTICS")); err == nil {
+		cfg.HeaderHeuristics = v
+	}
+	if n, err := strconv.Atoi(os.Getenv("BOT_BURST_THRESHOLD")); err == nil {
+		cfg.BurstThreshold = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("BOT_BURST_WINDOW")); err == nil && d > 0 {
+		cfg.BurstWindow = d
+	}
+	return cfg
+}
+
+// Classifier implements usecase.BotClassifier. It is safe for concurrent
+// use.
+type Classifier struct {
+	cfg Config
+
+	mu       sync.RWMutex
+	patterns *regexp.Regexp
+	modTime  time.Time
+	checked  time.Time
+
+	burstMu     sync.Mutex
+	windowStart time.Time
+	counts      map[string]int
+}
+
+var _ usecase.BotClassifier = (*Classifier)(nil)
+
+// New builds a classifier and loads the pattern file, if any.
+func New(cfg Config) (*Classifier, error) {
+	c := &Classifier{cfg: cfg, counts: map[string]int{}}
+	if err := c.reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewBotClassifierFromEnv builds the classifier configured by BOT_* env
+// vars.
+func NewBotClassifierFromEnv() usecase.BotClassifier {
+	c, err := New(LoadConfig())
+	if err != nil {
+		log.Fatalf("Failed to load bot patterns: %v", err)
+	}
+	return c
+}
+
+// Classify implements usecase.BotClassifier.
+func (c *Classifier) Classify(click domain.ClickContext) usecase.BotVerdict {
+	c.maybeReload()
+
+	ua := strings.TrimSpace(click.UserAgent)
+	switch {
+	case strings.EqualFold(click.Method, "HEAD"):
+		return bot(ReasonHead)
+	case isPrefetch(click.Purpose):
+		return bot(ReasonPrefetch)
+	case ua == "":
+		return bot(ReasonEmptyUA)
+	}
+
+	c.mu.RLock()
+	matched := c.patterns.MatchString(strings.ToLower(ua))
+	c.mu.RUnlock()
+	if matched {
+		return bot(ReasonUserAgent)
+	}
+
+	if c.cfg.HeaderHeuristics && click.AcceptLanguage == "" && (click.Accept == "" || click.Accept == "*/*") {
+		return bot(ReasonHeaders)
+	}
+	if c.isBurst(click.IP, click.At) {
+		return bot(ReasonBurst)
+	}
+	return usecase.BotVerdict{}
+}
+
+func bot(reason string) usecase.BotVerdict {
+	return usecase.BotVerdict{Bot: true, Reason: reason}
+}
+
+// isPrefetch recognises Sec-Purpose/Purpose/X-Purpose/X-Moz values sent by
+// browsers that load a page speculatively.
+func isPrefetch(purpose string) bool {
+	p := strings.ToLower(purpose)
+	return strings.Contains(p, "prefetch") || strings.Contains(p, "prerender") || strings.Contains(p, "preview")
+}
+
+// isBurst counts clicks per client in fixed windows. Tracking stops
+// adding clients once maxTrackedClients is reached within a window.
+func (c *Classifier) isBurst(ip string, at time.Time) bool {
+	if c.cfg.BurstThreshold <= 0 || ip == "" {
+		return false
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+	c.burstMu.Lock()
+	defer c.burstMu.Unlock()
+	if at.Sub(c.windowStart) >= c.cfg.BurstWindow {
+		c.windowStart = at
+		clear(c.counts)
+	}
+	n, ok := c.counts[ip]
+	if !ok && len(c.counts) >= maxTrackedClients {
+		return false
+	}
+	n++
+	c.counts[ip] = n
+	return n > c.cfg.BurstThreshold
+}
+
+// maybeReload re-reads the pattern file when it changed, checking at most
+// once per ReloadInterval.
+func (c *Classifier) maybeReload() {
+	if c.cfg.PatternsFile == "" {
+		return
+	}
+	c.mu.RLock()
+	due := time.Since(c.checked) >= c.cfg.ReloadInterval
+	c.mu.RUnlock()
+	if !due {
+		return
+	}
+	if err := c.reload(); err != nil {
+		log.Printf("Failed to reload bot patterns, keeping previous list: %v", err)
+	}
+}
+
+func (c *Classifier) reload() error {
+	c.mu.Lock()
+	c.checked = time.Now()
+	c.mu.Unlock()
+
+	patterns := DefaultPatterns
+	var modTime time.Time
+	if c.cfg.PatternsFile != "" {
+		info, err := os.Stat(c.cfg.PatternsFile)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err == nil {
+			modTime = info.ModTime()
+			c.mu.RLock()
+			unchanged := c.patterns != nil && modTime.Equal(c.modTime)
+			c.mu.RUnlock()
+			if unchanged {
+				return nil
+			}
+			extra, err := readPatterns(c.cfg.PatternsFile)
+			if err != nil {
+				return err
+			}
+			patterns = append(append([]string{}, DefaultPatterns...), extra...)
+		}
+	}
+
+	re, err := regexp.Compile("(?i)" + strings.Join(patterns, "|"))
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.patterns = re
+	c.modTime = modTime
+	c.mu.Unlock()
+	return nil
+}
+
+// readPatterns parses one regex per line; blank lines and lines starting
+// with # are ignored. Each pattern is validated on its own so errors point
+// at the offending line.
+func readPatterns(path string) ([]string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var patterns []string
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for line := 1; scanner.Scan(); line++ {
+		p := strings.TrimSpace(scanner.Text())
+		if p == "" || strings.HasPrefix(p, "#") {
+			continue
+		}
+		if _, err := regexp.Compile(p); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		patterns = append(patterns, "(?:"+p+")")
+	}
+	return patterns, scanner.Err()
+}
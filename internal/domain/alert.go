@@ -0,0 +1,99 @@
+package domain
+
+import "time"
+
+// Alert rule kinds.
+const (
+	// AlertKindThreshold fires once clicks reach Threshold in total.
+	AlertKindThreshold = "threshold"
+	// AlertKindSpike fires when the clicks of the current hour exceed
+	// Factor times the hourly average of the WindowHours before it.
+	AlertKindSpike = "spike"
+)
+
+// Alert delivery channels.
+const (
+	AlertChannelWebhook = "webhook"
+	AlertChannelEmail   = "email"
+)
+
+// Alert event delivery statuses.
+const (
+	AlertDeliveryPending = "pending"
+	AlertDeliverySent    = "sent"
+	AlertDeliveryFailed  = "failed"
+)
+
+var (
+	AlertKinds    = []string{AlertKindThreshold, AlertKindSpike}
+	AlertChannels = []string{AlertChannelWebhook, AlertChannelEmail}
+)
+
+func IsValidAlertKind(kind string) bool {
+	return kind == AlertKindThreshold || kind == AlertKindSpike
+}
+
+func IsValidAlertChannel(channel string) bool {
+	return channel == AlertChannelWebhook || channel == AlertChannelEmail
+}
+
+// AlertRule watches the clicks of one link, or of the whole account when
+// LinkID is nil.
+type AlertRule struct {
+	ID          int64
+	UserID      int64
+	LinkID      *int64
+	ShortCode   string // not persisted; filled from the link
+	Name        string
+	Kind        string
+	Threshold   int64
+	Factor      float64
+	WindowHours int
+	// MinClicks keeps spike rules quiet on links with almost no traffic.
+	MinClicks   int64
+	IncludeBots bool
+	Channel     string
+	// Target is a webhook URL or an email address, depending on Channel.
+	Target          string
+	Cooldown        time.Duration
+	Enabled         bool
+	LastTriggeredAt *time.Time
+	DeletedAt       *time.Time
+	CreatedAt       time.Time
+	UpdatedAt       *time.Time
+}
+
+// AlertEvent is one firing of a rule and the state of its delivery.
+type AlertEvent struct {
+	ID        int64
+	RuleID    int64
+	RuleName  string // not persisted; filled from the rule
+	UserID    int64
+	LinkID    *int64
+	ShortCode string // not persisted; filled from the link
+	Kind      string
+	// DedupKey identifies what fired, e.g. the threshold or the hour of a
+	// spike; a rule fires at most once per key.
+	DedupKey string
+	// Value is the clicks observed: the total for threshold rules, the
+	// current hour's for spike rules.
+	Value int64
+	// Baseline is what Value was compared to: the threshold, or the
+	// trailing hourly average.
+	Baseline      float64
+	Status        string
+	Attempts      int
+	Error         string
+	TriggeredAt   time.Time
+	NextAttemptAt *time.Time
+	DeliveredAt   *time.Time
+}
+
+// AlertHistoryQuery selects a user's alert events, newest first.
+type AlertHistoryQuery struct {
+	UserID int64
+	RuleID *int64
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+}
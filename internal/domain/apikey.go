@@ -1,7 +1,61 @@
 package domain
 
+import "time"
+
+// API key scope resources. A scope is "<resource>:read" or
+// "<resource>:write"; "<resource>:*" grants both and "*" everything.
+const (
+	ScopeLinks     = "links"
+	ScopeCampaigns = "campaigns"
+	ScopeStats     = "stats"
+	ScopeShares    = "shares"
+	ScopeAlerts    = "alerts"
+	ScopeKeys      = "keys"
+	ScopeAdmin     = "admin"
+)
+
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAll   = "*"
+)
+
+// Scopes lists every scope that exists. Statistics are read-only.
+var Scopes = []string{
+	"links:read", "links:write",
+	"campaigns:read", "campaigns:write",
+	"stats:read",
+	"shares:read", "shares:write",
+	"alerts:read", "alerts:write",
+	"keys:read", "keys:write",
+	"admin:read", "admin:write",
+}
+
+// ApiKey is an issued API key. Only the digest of the key is stored;
+// Prefix is its first few characters, kept to tell keys apart. Scopes
+// limit what the key can do, within what its user's role allows.
 type ApiKey struct {
-	ID     int64
-	UserID int64
-	Key    string
+	ID      int64
+	UserID  int64
+	KeyHash string
+	Prefix  string
+	Scopes  []string
+	// ExpiresAt is nil for keys that do not expire. Rotation sets it on
+	// the old key to the end of the grace period.
+	ExpiresAt *time.Time
+	// LastUsedAt and LastUsedIP are written in batches, so they can lag
+	// behind by a flush interval.
+	LastUsedAt *time.Time
+	LastUsedIP string
+	// ReplacedBy is the key that replaced this one on rotation, nil if it
+	// was not rotated.
+	ReplacedBy *int64
+	CreatedAt  time.Time
+}
+
+// APIKeyUsage is the latest use of a key seen by an instance.
+type APIKeyUsage struct {
+	KeyID int64
+	At    time.Time
+	IP    string
 }
@@ -0,0 +1,78 @@
+package domain
+
+import "time"
+
+// Audit actions.
+const (
+	AuditUserCreate      = "user.create"
+	AuditUserDelete      = "user.delete"
+	AuditUserPlanUpdate  = "user.plan_update"
+	AuditAPIKeyCreate    = "apikey.create"
+	AuditAPIKeyRevoke    = "apikey.revoke"
+	AuditAPIKeyRotate    = "apikey.rotate"
+	AuditBlocklistAdd    = "blocklist.add"
+	AuditBlocklistRemove = "blocklist.remove"
+	AuditLinkCreate      = "link.create"
+	AuditLinkUpdate      = "link.update"
+	AuditLinkDelete      = "link.delete"
+	AuditLinkFlag        = "link.flag"
+	AuditLinkUnflag      = "link.unflag"
+)
+
+// Audit target types.
+const (
+	AuditTargetUser      = "user"
+	AuditTargetLink      = "link"
+	AuditTargetBlocklist = "blocklist"
+)
+
+// AuditActor is who performed an action. Background jobs act without a
+// user, so every field may be empty.
+type AuditActor struct {
+	UserID    *int64
+	APIKeyID  *int64
+	IP        string
+	RequestID string
+}
+
+// AuditEvent is one entry of the audit trail. Before and After hold the
+// fields of the target that changed; Before is empty on create and After
+// on delete. Hash covers PrevHash and every other field except ID, so
+// editing, removing or reordering entries breaks the chain.
+type AuditEvent struct {
+	ID         int64
+	Actor      AuditActor
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     map[string]any
+	After      map[string]any
+	CreatedAt  time.Time
+	PrevHash   string
+	Hash       string
+}
+
+// AuditQuery filters the audit trail. From is inclusive and To exclusive;
+// BeforeID pages backwards through List results.
+type AuditQuery struct {
+	ActorUserID *int64
+	Action      string
+	TargetType  string
+	TargetID    string
+	From        *time.Time
+	To          *time.Time
+	BeforeID    int64
+	Limit       int
+}
+
+// AuditVerification is the result of checking the hash chain. HeadHash is
+// the hash of the newest event; recording it elsewhere also makes removal
+// of the newest events detectable.
+type AuditVerification struct {
+	Valid    bool
+	Checked  int64
+	HeadHash string
+	// BrokenAt is the first event whose hash or link does not match.
+	BrokenAt *int64
+	Reason   string
+}
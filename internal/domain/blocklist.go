@@ -0,0 +1,14 @@
+package domain
+
+const (
+	BlocklistDomain = "domain"
+	BlocklistPrefix = "prefix"
+	BlocklistRegex  = "regex"
+)
+
+// Blocklist is the set of locally maintained malicious destinations.
+type Blocklist struct {
+	Domains  []string `json:"domains"`
+	Prefixes []string `json:"prefixes"`
+	Regexes  []string `json:"regexes"`
+}
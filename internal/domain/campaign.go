@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+type Campaign struct {
+	ID        int64
+	UserID    int64
+	Name      string
+	StartsAt  *time.Time
+	EndsAt    *time.Time
+	DeletedAt *time.Time
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+}
+
+// CampaignStats aggregates clicks across all links of a campaign.
+type CampaignStats struct {
+	CampaignID     int64
+	From           *time.Time
+	To             *time.Time
+	IncludeBots    bool
+	Links          int
+	Clicks         int64
+	UniqueVisitors int64
+	LastClickedAt  *time.Time
+	TopReferrers   []ReferrerCount
+	TimeSeries     []TimeBucket
+}
+
+type ReferrerCount struct {
+	Referrer string
+	Clicks   int64
+}
+
+type TimeBucket struct {
+	Start  time.Time
+	Clicks int64
+	// UniqueVisitors is an HLL estimate, nil where it is not available
+	// (hourly buckets).
+	UniqueVisitors *int64
+}
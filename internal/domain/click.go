@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+// ClickContext is the raw request data captured when a short link is
+// resolved. It never leaves the process as is; see ClickEvent.
+type ClickContext struct {
+	Referrer       string
+	UserAgent      string
+	IP             string
+	AcceptLanguage string
+	Source         string
+	DoNotTrack     bool
+	At             time.Time
+	// Method, Accept and Purpose (Sec-Purpose, Purpose, X-Purpose or X-Moz)
+	// feed bot detection only.
+	Method  string
+	Accept  string
+	Purpose string
+}
+
+// ClickEvent is one recorded click, stripped of raw identifiers.
+type ClickEvent struct {
+	ID              int64
+	LinkID          int64
+	UserID          int64
+	ClickedAt       time.Time
+	Referrer        string
+	ReferrerDomain  string
+	Browser         string
+	BrowserVersion  string
+	OS              string
+	Device          string
+	Languages       string
+	PrimaryLanguage string
+	Country         string
+	IPHash          string
+	Source          string
+	OptOut          bool
+	IsBot           bool
+	BotReason       string
+	// ShortCode of the link, carried for live streams; not persisted.
+	ShortCode string
+	// VisitorHash is a daily-rotating visitor fingerprint used only to
+	// update unique visitor sketches; it is not persisted. Zero when the
+	// visitor opted out or is a bot.
+	VisitorHash uint64
+}
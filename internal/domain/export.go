@@ -0,0 +1,88 @@
+package domain
+
+import "time"
+
+const (
+	ExportFormatCSV     = "csv"
+	ExportFormatNDJSON  = "ndjson"
+	ExportFormatParquet = "parquet"
+
+	// ExportDatasetEvents exports raw click events, ExportDatasetHourly the
+	// hourly click rollups.
+	ExportDatasetEvents = "events"
+	ExportDatasetHourly = "hourly"
+)
+
+const (
+	ExportJobPending = "pending"
+	ExportJobRunning = "running"
+	ExportJobDone    = "done"
+	ExportJobFailed  = "failed"
+)
+
+var ExportFormats = []string{ExportFormatCSV, ExportFormatNDJSON, ExportFormatParquet}
+
+var ExportDatasets = []string{ExportDatasetEvents, ExportDatasetHourly}
+
+func IsValidExportFormat(format string) bool {
+	for _, f := range ExportFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+func IsValidExportDataset(dataset string) bool {
+	for _, d := range ExportDatasets {
+		if d == dataset {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportQuery selects the rows of an export. A nil UserID exports every
+// account (admin only); a nil LinkID every link in scope. From is
+// inclusive and To exclusive.
+type ExportQuery struct {
+	UserID  *int64
+	LinkID  *int64
+	Dataset string
+	Format  string
+	From    *time.Time
+	To      *time.Time
+}
+
+// ExportedClick is a click event with the short code of its link.
+type ExportedClick struct {
+	ClickEvent
+	ShortCode string
+}
+
+// HourlyClicks is one row of the hourly click rollups.
+type HourlyClicks struct {
+	LinkID    int64
+	ShortCode string
+	UserID    int64
+	Bucket    time.Time
+	Clicks    int64
+	BotClicks int64
+}
+
+// ExportJob is an export written to storage in the background. Token is
+// the secret handle used to poll and download it.
+type ExportJob struct {
+	ID          int64
+	Token       string
+	RequestedBy int64
+	Query       ExportQuery
+	Status      string
+	Rows        int64
+	SizeBytes   int64
+	Error       string
+	CreatedAt   time.Time
+	StartedAt   *time.Time
+	FinishedAt  *time.Time
+	ExpiresAt   *time.Time
+}
@@ -4,13 +4,56 @@ import (
 	"time"
 )
 
+const (
+	LinkHealthUnknown  = "unknown"
+	LinkHealthHealthy  = "healthy"
+	LinkHealthDegraded = "degraded"
+	LinkHealthBroken   = "broken"
+)
+
 type Link struct {
-	ID            int64
-	UserID        int64
-	ShortCode     string
-	LongURL       string
-	ClickCount    int64
-	LastClickedAt *time.Time
-	DeletedAt     *time.Time
-	CreatedAt     time.Time
+	ID                  int64
+	UserID              int64
+	CampaignID          *int64
+	ShortCode           string
+	LongURL             string
+	ClickCount          int64 // human clicks only
+	BotClickCount       int64
+	LastClickedAt       *time.Time
+	HealthStatus        string
+	HealthStatusCode    int
+	HealthLatencyMs     int64
+	HealthRedirectChain []string
+	HealthError         string // why the last check failed, if it did
+	HealthCheckedAt     *time.Time
+	HealthChangedAt     *time.Time // when HealthStatus last changed
+	FlaggedAt           *time.Time
+	FlagReason          string
+	DeletedAt           *time.Time
+	CreatedAt           time.Time
+}
+
+// LinkHealthCheck is the outcome of probing a link's destination.
+type LinkHealthCheck struct {
+	LinkID        int64
+	Status        string
+	StatusCode    int
+	Latency       time.Duration
+	RedirectChain []string
+	Error         string
+	CheckedAt     time.Time
+}
+
+// LinkFilter narrows down link listings. Zero values mean "no filter".
+type LinkFilter struct {
+	HealthStatus string
+	CampaignID   *int64
+}
+
+func IsValidLinkHealth(status string) bool {
+	switch status {
+	case LinkHealthUnknown, LinkHealthHealthy, LinkHealthDegraded, LinkHealthBroken:
+		return true
+	}
+	return false
 }
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// LiveClick is a recorded click as pushed to live streams. It carries only
+// what a dashboard shows, never identifiers of the visitor.
+type LiveClick struct {
+	LinkID    int64
+	UserID    int64
+	ShortCode string
+	ClickedAt time.Time
+	Country   string
+	Device    string
+	// Referrer is the referrer domain, empty for direct traffic.
+	Referrer string
+	IsBot    bool
+}
+
+func (e *ClickEvent) LiveClick() LiveClick {
+	return LiveClick{
+		LinkID:    e.LinkID,
+		UserID:    e.UserID,
+		ShortCode: e.ShortCode,
+		ClickedAt: e.ClickedAt,
+		Country:   e.Country,
+		Device:    e.Device,
+		Referrer:  e.ReferrerDomain,
+		IsBot:     e.IsBot,
+	}
+}
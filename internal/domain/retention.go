@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// RollupRepair summarizes the rebuild of one UTC day of rollups from the
+// raw click events.
+type RollupRepair struct {
+	Day           time.Time
+	Links         int
+	HourlyRows    int64
+	DimensionRows int64
+	// ClickDelta and BotClickDelta are what the rebuild added to (or, when
+	// negative, removed from) the links' counters.
+	ClickDelta    int64
+	BotClickDelta int64
+}
+
+// CounterDrift is a link whose counters differ from the sum of its hourly
+// rollups.
+type CounterDrift struct {
+	LinkID          int64
+	ShortCode       string
+	ClickCount      int64
+	BotClickCount   int64
+	RollupClicks    int64
+	RollupBotClicks int64
+}
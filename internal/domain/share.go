@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+// StatsShare publishes the click statistics of one link under an
+// unguessable token, for people without an account. Only the breakdown
+// Dimensions the owner picked are exposed.
+type StatsShare struct {
+	ID         int64
+	LinkID     int64
+	UserID     int64
+	ShortCode  string // not persisted; filled from the link
+	Token      string
+	Dimensions []string
+	ExpiresAt  *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// Active reports whether the share may still be viewed at now.
+func (s *StatsShare) Active(now time.Time) bool {
+	return s.RevokedAt == nil && (s.ExpiresAt == nil || now.Before(*s.ExpiresAt))
+}
+
+// PublicStats is what a share exposes: human clicks of the link over a
+// range and the breakdowns of the shared dimensions.
+type PublicStats struct {
+	Share      *StatsShare
+	Series     *ClickSeries
+	Breakdowns []*Breakdown
+}
@@ -0,0 +1,137 @@
+package domain
+
+import "time"
+
+const (
+	StatsIntervalHour  = "hour"
+	StatsIntervalDay   = "day"
+	StatsIntervalWeek  = "week"
+	StatsIntervalMonth = "month"
+)
+
+func IsValidStatsInterval(interval string) bool {
+	switch interval {
+	case StatsIntervalHour, StatsIntervalDay, StatsIntervalWeek, StatsIntervalMonth:
+		return true
+	}
+	return false
+}
+
+// ClickSeriesQuery selects clicks of one user, optionally of one link, in
+// [From, To) bucketed by Interval in Location.
+type ClickSeriesQuery struct {
+	UserID   int64
+	LinkID   *int64
+	From     time.Time
+	To       time.Time
+	Interval string
+	Location *time.Location
+	// IncludeBots adds bot clicks to the counts.
+	IncludeBots bool
+}
+
+// ClickSeries is a gap-free click time series; empty buckets have zero
+// clicks.
+type ClickSeries struct {
+	ShortCode   string
+	Interval    string
+	Timezone    string
+	IncludeBots bool
+	From        time.Time
+	To          time.Time
+	Total       int64
+	// UniqueVisitors is nil for hourly series; see TimeBucket.
+	UniqueVisitors *int64
+	Buckets        []TimeBucket
+}
+
+// DailySketch is the encoded HLL visitor sketch of one link on one UTC day.
+type DailySketch struct {
+	LinkID int64
+	Day    time.Time
+	Sketch []byte
+}
+
+// Breakdown dimensions.
+const (
+	DimensionReferrer = "referrer"
+	DimensionBrowser  = "browser"
+	DimensionOS       = "os"
+	DimensionDevice   = "device"
+	DimensionCountry  = "country"
+	DimensionLanguage = "language"
+)
+
+// Placeholder values for clicks without a value for a dimension.
+const (
+	DimensionValueDirect  = "(direct)"
+	DimensionValueUnknown = "(unknown)"
+	DimensionValueOther   = "(other)"
+)
+
+var StatsDimensions = []string{
+	DimensionReferrer, DimensionBrowser, DimensionOS, DimensionDevice, DimensionCountry, DimensionLanguage,
+}
+
+func IsValidStatsDimension(dimension string) bool {
+	for _, d := range StatsDimensions {
+		if d == dimension {
+			return true
+		}
+	}
+	return false
+}
+
+// DimensionValues returns the value of every breakdown dimension for a
+// click, with placeholders for missing ones.
+func (e *ClickEvent) DimensionValues() map[string]string {
+	values := map[string]string{
+		DimensionReferrer: e.ReferrerDomain,
+		DimensionBrowser:  e.Browser,
+		DimensionOS:       e.OS,
+		DimensionDevice:   e.Device,
+		DimensionCountry:  e.Country,
+		DimensionLanguage: e.PrimaryLanguage,
+	}
+	for d, v := range values {
+		if v != "" {
+			continue
+		}
+		if d == DimensionReferrer && !e.OptOut {
+			values[d] = DimensionValueDirect
+		} else {
+			values[d] = DimensionValueUnknown
+		}
+	}
+	return values
+}
+
+// BreakdownQuery selects the top Limit values of Dimension for one user,
+// optionally of one link, over the UTC days from From to To.
+type BreakdownQuery struct {
+	UserID    int64
+	LinkID    *int64
+	Dimension string
+	From      time.Time
+	To        time.Time
+	Limit     int
+	// IncludeBots adds bot clicks to the counts.
+	IncludeBots bool
+}
+
+type DimensionCount struct {
+	Value  string
+	Clicks int64
+}
+
+// Breakdown is a top-N breakdown. Items beyond the top N are summed into a
+// trailing DimensionValueOther item.
+type Breakdown struct {
+	ShortCode   string
+	Dimension   string
+	IncludeBots bool
+	From        time.Time
+	To          time.Time
+	Total       int64
+	Items       []DimensionCount
+}
@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+const (
+	AttentionBroken  = "broken"
+	AttentionFlagged = "flagged"
+)
+
+// AccountSummaryQuery selects what goes into an account summary. The click
+// windows start at TodayFrom, WeekFrom and MonthFrom and end now.
+type AccountSummaryQuery struct {
+	UserID         int64
+	TodayFrom      time.Time
+	WeekFrom       time.Time
+	MonthFrom      time.Time
+	AttentionSince time.Time
+	TopLinks       int
+	AttentionLinks int
+}
+
+// AccountSummary is the overview of one account for a dashboard. Clicks
+// are human clicks only.
+type AccountSummary struct {
+	Plan          string
+	PlanExpiresAt *time.Time
+	// LinkLimit and LinksRemaining are nil when the account has no link
+	// limit.
+	LinkLimit      *int
+	LinksRemaining *int
+	TotalLinks     int
+	// ActiveLinks are links that still redirect, i.e. are not flagged.
+	ActiveLinks      int
+	ClicksToday      int64
+	ClicksLast7Days  int64
+	ClicksLast30Days int64
+	TopLinks         []*Link
+	AttentionLinks   []AttentionLink
+	GeneratedAt      time.Time
+}
+
+// AttentionLink is a link that recently stopped working: its destination
+// became broken or it was flagged by screening.
+type AttentionLink struct {
+	Link   *Link
+	Reason string
+	Since  time.Time
+}
@@ -11,4 +11,8 @@ type User struct {
 	Plan          string
 	Role          string
 	PlanExpiresAt *time.Time
+	// APIKeyID and APIKeyScopes describe the key the current request
+	// authenticated with; they are only set by API key lookups.
+	APIKeyID     int64
+	APIKeyScopes []string
 }
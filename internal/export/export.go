@@ -0,0 +1,330 @@
+// Package export encodes click data and audit events as CSV, NDJSON or
+// Parquet one row at a time, so exports of any size stream with bounded
+// memory.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"url-shortener/internal/domain"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+const (
+	// parquetRowGroupSize bounds how many rows a Parquet writer holds in
+	// memory before writing a row group.
+	parquetRowGroupSize = 100_000
+	parquetBatchSize    = 1024
+)
+
+// EventRow is the exported form of a click event.
+type EventRow struct {
+	ID              int64     `json:"id" parquet:"id"`
+	LinkID          int64     `json:"link_id" parquet:"link_id"`
+	ShortCode       string    `json:"short_code" parquet:"short_code,dict"`
+	UserID          int64     `json:"user_id" parquet:"user_id"`
+	ClickedAt       time.Time `json:"clicked_at" parquet:"clicked_at,timestamp(microsecond)"`
+	Referrer        string    `json:"referrer" parquet:"referrer"`
+	ReferrerDomain  string    `json:"referrer_domain" parquet:"referrer_domain,dict"`
+	Browser         string    `json:"browser" parquet:"browser,dict"`
+	BrowserVersion  string    `json:"browser_version" parquet:"browser_version,dict"`
+	OS              string    `json:"os" parquet:"os,dict"`
+	Device          string    `json:"device" parquet:"device,dict"`
+	Country         string    `json:"country" parquet:"country,dict"`
+	Languages       string    `json:"languages" parquet:"languages"`
+	PrimaryLanguage string    `json:"primary_language" parquet:"primary_language,dict"`
+	Source          string    `json:"source" parquet:"source,dict"`
+	IPHash          string    `json:"ip_hash" parquet:"ip_hash"`
+	OptOut          bool      `json:"opt_out" parquet:"opt_out"`
+	IsBot           bool      `json:"is_bot" parquet:"is_bot"`
+	BotReason       string    `json:"bot_reason" parquet:"bot_reason,dict"`
+}
+
+func NewEventRow(c *domain.ExportedClick) EventRow {
+	return EventRow{
+		ID:              c.ID,
+		LinkID:          c.LinkID,
+		ShortCode:       c.ShortCode,
+		UserID:          c.UserID,
+		ClickedAt:       c.ClickedAt.UTC(),
+		Referrer:        c.Referrer,
+		ReferrerDomain:  c.ReferrerDomain,
+		Browser:         c.Browser,
+		BrowserVersion:  c.BrowserVersion,
+		OS:              c.OS,
+		Device:          c.Device,
+		Country:         c.Country,
+		Languages:       c.Languages,
+		PrimaryLanguage: c.PrimaryLanguage,
+		Source:          c.Source,
+		IPHash:          c.IPHash,
+		OptOut:          c.OptOut,
+		IsBot:           c.IsBot,
+		BotReason:       c.BotReason,
+	}
+}
+
+func (EventRow) csvHeader() []string {
+	return []string{
+		"id", "link_id", "short_code", "user_id", "clicked_at", "referrer", "referrer_domain",
+		"browser", "browser_version", "os", "device", "country", "languages", "primary_language",
+		"source", "ip_hash", "opt_out", "is_bot", "bot_reason",
+	}
+}
+
+func (r EventRow) csvRecord() []string {
+	return []string{
+		itoa(r.ID), itoa(r.LinkID), r.ShortCode, itoa(r.UserID), r.ClickedAt.Format(time.RFC3339Nano),
+		r.Referrer, r.ReferrerDomain, r.Browser, r.BrowserVersion, r.OS, r.Device, r.Country,
+		r.Languages, r.PrimaryLanguage, r.Source, r.IPHash,
+		strconv.FormatBool(r.OptOut), strconv.FormatBool(r.IsBot), r.BotReason,
+	}
+}
+
+// HourlyRow is the exported form of an hourly click rollup.
+type HourlyRow struct {
+	LinkID    int64     `json:"link_id" parquet:"link_id"`
+	ShortCode string    `json:"short_code" parquet:"short_code,dict"`
+	UserID    int64     `json:"user_id" parquet:"user_id"`
+	Bucket    time.Time `json:"bucket" parquet:"bucket,timestamp(microsecond)"`
+	Clicks    int64     `json:"clicks" parquet:"clicks"`
+	BotClicks int64     `json:"bot_clicks" parquet:"bot_clicks"`
+}
+
+func NewHourlyRow(h *domain.HourlyClicks) HourlyRow {
+	return HourlyRow{
+		LinkID:    h.LinkID,
+		ShortCode: h.ShortCode,
+		UserID:    h.UserID,
+		Bucket:    h.Bucket.UTC(),
+		Clicks:    h.Clicks,
+		BotClicks: h.BotClicks,
+	}
+}
+
+func (HourlyRow) csvHeader() []string {
+	return []string{"link_id", "short_code", "user_id", "bucket", "clicks", "bot_clicks"}
+}
+
+func (r HourlyRow) csvRecord() []string {
+	return []string{
+		itoa(r.LinkID), r.ShortCode, itoa(r.UserID), r.Bucket.Format(time.RFC3339),
+		itoa(r.Clicks), itoa(r.BotClicks),
+	}
+}
+
+// AuditRow is the exported form of an audit event. Before and After are
+// JSON objects encoded as strings; Hash and PrevHash let the chain be
+// verified offline.
+type AuditRow struct {
+	ID            int64     `json:"id" parquet:"id"`
+	CreatedAt     time.Time `json:"created_at" parquet:"created_at,timestamp(microsecond)"`
+	ActorUserID   *int64    `json:"actor_user_id" parquet:"actor_user_id,optional"`
+	ActorAPIKeyID *int64    `json:"actor_api_key_id" parquet:"actor_api_key_id,optional"`
+	Action        string    `json:"action" parquet:"action,dict"`
+	TargetType    string    `json:"target_type" parquet:"target_type,dict"`
+	TargetID      string    `json:"target_id" parquet:"target_id"`
+	Before        string    `json:"before" parquet:"before"`
+	After         string    `json:"after" parquet:"after"`
+	IP            string    `json:"ip" parquet:"ip"`
+	RequestID     string    `json:"request_id" parquet:"request_id"`
+	PrevHash      string    `json:"prev_hash" parquet:"prev_hash"`
+	Hash          string    `json:"hash" parquet:"hash"`
+}
+
+func NewAuditRow(e *domain.AuditEvent) AuditRow {
+	return AuditRow{
+		ID:            e.ID,
+		CreatedAt:     e.CreatedAt.UTC(),
+		ActorUserID:   e.Actor.UserID,
+		ActorAPIKeyID: e.Actor.APIKeyID,
+		Action:        e.Action,
+		TargetType:    e.TargetType,
+		TargetID:      e.TargetID,
+		Before:        jsonObject(e.Before),
+		After:         jsonObject(e.After),
+		IP:            e.Actor.IP,
+		RequestID:     e.Actor.RequestID,
+		PrevHash:      e.PrevHash,
+		Hash:          e.Hash,
+	}
+}
+
+func (AuditRow) csvHeader() []string {
+	return []string{
+		"id", "created_at", "actor_user_id", "actor_api_key_id", "action", "target_type", "target_id",
+		"before", "after", "ip", "request_id", "prev_hash", "hash",
+	}
+}
+
+func (r AuditRow) csvRecord() []string {
+	return []string{
+		itoa(r.ID), r.CreatedAt.Format(time.RFC3339Nano), optionalItoa(r.ActorUserID), optionalItoa(r.ActorAPIKeyID),
+		r.Action, r.TargetType, r.TargetID, r.Before, r.After, r.IP, r.RequestID, r.PrevHash, r.Hash,
+	}
+}
+
+// Row is implemented by the exported row types.
+type Row interface {
+	EventRow | HourlyRow | AuditRow
+	csvHeader() []string
+	csvRecord() []string
+}
+
+// Writer encodes rows to an underlying io.Writer. Close must be called to
+// flush buffered rows and write any trailer; it does not close the
+// underlying writer.
+type Writer[T Row] interface {
+	Write(row T) error
+	Close() error
+}
+
+// NewWriter returns a Writer for one of the domain.ExportFormat* formats.
+func NewWriter[T Row](format string, w io.Writer) (Writer[T], error) {
+	switch format {
+	case domain.ExportFormatCSV:
+		return &csvWriter[T]{w: csv.NewWriter(w)}, nil
+	case domain.ExportFormatNDJSON:
+		buf := bufio.NewWriter(w)
+		return &ndjsonWriter[T]{buf: buf, enc: json.NewEncoder(buf)}, nil
+	case domain.ExportFormatParquet:
+		return &parquetWriter[T]{
+			w: parquet.NewGenericWriter[T](w,
+				parquet.MaxRowsPerRowGroup(parquetRowGroupSize),
+				parquet.Compression(&parquet.Zstd),
+			),
+			batch: make([]T, 0, parquetBatchSize),
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
+
+// ContentType returns the MIME type of format.
+func ContentType(format string) string {
+	switch format {
+	case domain.ExportFormatCSV:
+		return "text/csv; charset=utf-8"
+	case domain.ExportFormatNDJSON:
+		return "application/x-ndjson"
+	case domain.ExportFormatParquet:
+		return "application/vnd.apache.parquet"
+	}
+	return "application/octet-stream"
+}
+
+type csvWriter[T Row] struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func (c *csvWriter[T]) Write(row T) error {
+	if !c.wroteHeader {
+		if err := c.writeHeader(); err != nil {
+			return err
+		}
+	}
+	record := row.csvRecord()
+	for i, cell := range record {
+		record[i] = escapeFormula(cell)
+	}
+	return c.w.Write(record)
+}
+
+// escapeFormula prefixes cells that spreadsheets would run as a formula
+// with a quote. Referrers, sources and the like come from visitors, so a
+// crafted one could otherwise execute in whoever opens the export.
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+func (c *csvWriter[T]) writeHeader() error {
+	var zero T
+	c.wroteHeader = true
+	return c.w.Write(zero.csvHeader())
+}
+
+// Close writes the header of an empty export and flushes.
+func (c *csvWriter[T]) Close() error {
+	if !c.wroteHeader {
+		if err := c.writeHeader(); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonWriter[T Row] struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter[T]) Write(row T) error {
+	return n.enc.Encode(row)
+}
+
+func (n *ndjsonWriter[T]) Close() error {
+	return n.buf.Flush()
+}
+
+type parquetWriter[T Row] struct {
+	w     *parquet.GenericWriter[T]
+	batch []T
+}
+
+func (p *parquetWriter[T]) Write(row T) error {
+	p.batch = append(p.batch, row)
+	if len(p.batch) < cap(p.batch) {
+		return nil
+	}
+	return p.flushBatch()
+}
+
+func (p *parquetWriter[T]) flushBatch() error {
+	if len(p.batch) == 0 {
+		return nil
+	}
+	_, err := p.w.Write(p.batch)
+	p.batch = p.batch[:0]
+	return err
+}
+
+func (p *parquetWriter[T]) Close() error {
+	if err := p.flushBatch(); err != nil {
+		return err
+	}
+	return p.w.Close()
+}
+
+func itoa(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
+
+func optionalItoa(n *int64) string {
+	if n == nil {
+		return ""
+	}
+	return itoa(*n)
+}
+
+// jsonObject encodes m, or returns "" for an empty map.
+func jsonObject(m map[string]any) string {
+	if len(m) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
@@ -0,0 +1,56 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+	"url-shortener/internal/domain"
+)
+
+func TestCSVWriterEscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter[EventRow](domain.ExportFormatCSV, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	referrers := []string{
+		"=HYPERLINK(\"http://evil.example\")",
+		"+1+1",
+		"-1+1",
+		"@SUM(A1)",
+		"\t=1",
+		"\r=1",
+		"https://example.com/",
+		"",
+	}
+	for i, ref := range referrers {
+		row := EventRow{ID: int64(i + 1), ClickedAt: time.Unix(0, 0).UTC(), Referrer: ref}
+		if err := w.Write(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"'=HYPERLINK(\"http://evil.example\")",
+		"'+1+1",
+		"'-1+1",
+		"'@SUM(A1)",
+		"'\t=1",
+		"'\r=1",
+		"https://example.com/",
+		"",
+	}
+	for i, w := range want {
+		if got := records[i+1][5]; got != w {
+			t.Errorf("referrer %q exported as %q, want %q", referrers[i], got, w)
+		}
+	}
+}
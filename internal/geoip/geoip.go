@@ -0,0 +1,66 @@
+// Package geoip resolves client IPs to countries using a local
+// MaxMind-format database (GeoLite2-Country, GeoIP2-City, DB-IP, ...).
+package geoip
+
+import (
+	"log"
+	"net/netip"
+	"os"
+	"url-shortener/internal/usecase"
+
+	"github.com/oschwald/maxminddb-golang/v2"
+)
+
+type Reader struct {
+	db *maxminddb.Reader
+}
+
+type countryRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	// Anycast and satellite ranges often only carry a registered country.
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+}
+
+// Open memory-maps the database at path.
+func Open(path string) (*Reader, error) {
+	db, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{db: db}, nil
+}
+
+// Country implements usecase.CountryResolver. It returns the ISO 3166-1
+// alpha-2 code, or "" when the address is not in the database.
+func (r *Reader) Country(addr netip.Addr) string {
+	var rec countryRecord
+	if err := r.db.Lookup(addr.Unmap()).Decode(&rec); err != nil {
+		return ""
+	}
+	if rec.Country.ISOCode != "" {
+		return rec.Country.ISOCode
+	}
+	return rec.RegisteredCountry.ISOCode
+}
+
+func (r *Reader) Close() error {
+	return r.db.Close()
+}
+
+// NewCountryResolverFromEnv opens the database named by GEOIP_DB_PATH.
+// Without it clicks are recorded with no country.
+func NewCountryResolverFromEnv() usecase.CountryResolver {
+	path := os.Getenv("GEOIP_DB_PATH")
+	if path == "" {
+		return nil
+	}
+	r, err := Open(path)
+	if err != nil {
+		log.Fatalf("Failed to open GeoIP database: %v", err)
+	}
+	return r
+}
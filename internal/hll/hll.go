@@ -0,0 +1,167 @@
+// Package hll implements HyperLogLog cardinality sketches. Sketches of the
+// same precision merge losslessly, so per-day sketches can answer "how many
+// distinct visitors" for any range of days without keeping identifiers.
+package hll
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/bits"
+)
+
+// DefaultPrecision gives 4096 registers, a standard error of about 1.6%.
+const DefaultPrecision = 12
+
+const (
+	minPrecision = 4
+	maxPrecision = 16
+
+	formatSparse = 1
+	formatDense  = 2
+)
+
+var (
+	ErrInvalidPrecision  = errors.New("hll: precision out of range")
+	ErrPrecisionMismatch = errors.New("hll: cannot merge sketches of different precision")
+	ErrCorrupt           = errors.New("hll: corrupt sketch")
+)
+
+type Sketch struct {
+	p    uint8
+	regs []uint8
+}
+
+func New(precision uint8) (*Sketch, error) {
+	if precision < minPrecision || precision > maxPrecision {
+		return nil, ErrInvalidPrecision
+	}
+	return &Sketch{p: precision, regs: make([]uint8, 1<<precision)}, nil
+}
+
+// NewDefault returns an empty sketch with DefaultPrecision.
+func NewDefault() *Sketch {
+	s, _ := New(DefaultPrecision)
+	return s
+}
+
+func (s *Sketch) Precision() uint8 {
+	return s.p
+}
+
+// Add records a 64-bit hash. Callers must pass well-mixed hashes (e.g. from
+// SHA-256 or HMAC), not raw identifiers.
+func (s *Sketch) Add(hash uint64) {
+	idx := hash >> (64 - s.p)
+	// The guard bit bounds rho when the remaining bits are all zero.
+	w := hash<<s.p | 1<<(s.p-1)
+	rho := uint8(bits.LeadingZeros64(w)) + 1
+	if rho > s.regs[idx] {
+		s.regs[idx] = rho
+	}
+}
+
+// Merge folds other into s, as if every hash added to other had been added
+// to s.
+func (s *Sketch) Merge(other *Sketch) error {
+	if other.p != s.p {
+		return ErrPrecisionMismatch
+	}
+	for i, r := range other.regs {
+		if r > s.regs[i] {
+			s.regs[i] = r
+		}
+	}
+	return nil
+}
+
+// Estimate returns the approximate number of distinct hashes added.
+func (s *Sketch) Estimate() uint64 {
+	m := float64(len(s.regs))
+	var sum float64
+	zeros := 0
+	for _, r := range s.regs {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	if zeros == len(s.regs) {
+		return 0
+	}
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / sum
+	// Linear counting is more accurate while many registers are empty.
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+// MarshalBinary encodes the sketch. Sketches with few set registers, the
+// common case for a single link and day, use a compact sparse form.
+func (s *Sketch) MarshalBinary() ([]byte, error) {
+	set := 0
+	for _, r := range s.regs {
+		if r != 0 {
+			set++
+		}
+	}
+	if set*3 < len(s.regs) {
+		b := make([]byte, 2, 2+set*3)
+		b[0], b[1] = formatSparse, s.p
+		for i, r := range s.regs {
+			if r != 0 {
+				b = binary.BigEndian.AppendUint16(b, uint16(i))
+				b = append(b, r)
+			}
+		}
+		return b, nil
+	}
+	b := make([]byte, 2+len(s.regs))
+	b[0], b[1] = formatDense, s.p
+	copy(b[2:], s.regs)
+	return b, nil
+}
+
+func (s *Sketch) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return ErrCorrupt
+	}
+	fresh, err := New(data[1])
+	if err != nil {
+		return ErrCorrupt
+	}
+	body := data[2:]
+	switch data[0] {
+	case formatSparse:
+		if len(body)%3 != 0 {
+			return ErrCorrupt
+		}
+		for i := 0; i < len(body); i += 3 {
+			idx := int(binary.BigEndian.Uint16(body[i:]))
+			if idx >= len(fresh.regs) {
+				return ErrCorrupt
+			}
+			fresh.regs[idx] = body[i+2]
+		}
+	case formatDense:
+		if len(body) != len(fresh.regs) {
+			return ErrCorrupt
+		}
+		copy(fresh.regs, body)
+	default:
+		return ErrCorrupt
+	}
+	*s = *fresh
+	return nil
+}
+
+// Decode is a convenience wrapper around UnmarshalBinary.
+func Decode(data []byte) (*Sketch, error) {
+	s := &Sketch{}
+	if err := s.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
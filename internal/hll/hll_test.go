@@ -0,0 +1,143 @@
+package hll
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+)
+
+// splitmix64 turns a counter into well-mixed hashes, standing in for the
+// HMACs real callers add.
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	return x ^ x>>31
+}
+
+func sketchOf(t *testing.T, from, to uint64) *Sketch {
+	t.Helper()
+	s := NewDefault()
+	for i := from; i < to; i++ {
+		s.Add(splitmix64(i))
+	}
+	return s
+}
+
+func TestNew(t *testing.T) {
+	for _, p := range []uint8{0, minPrecision - 1, maxPrecision + 1} {
+		if _, err := New(p); !errors.Is(err, ErrInvalidPrecision) {
+			t.Errorf("New(%d) error = %v, want %v", p, err, ErrInvalidPrecision)
+		}
+	}
+	s, err := New(minPrecision)
+	if err != nil || s.Precision() != minPrecision {
+		t.Fatalf("New(%d) = %v, %v", minPrecision, s, err)
+	}
+	if got := NewDefault().Estimate(); got != 0 {
+		t.Errorf("empty sketch estimate = %d, want 0", got)
+	}
+}
+
+func TestEstimateErrorBound(t *testing.T) {
+	// The standard error at DefaultPrecision is 1.04/sqrt(4096) ≈ 1.6%;
+	// allow three of them.
+	const maxRelErr = 3 * 1.04 / 64
+	for _, n := range []uint64{1, 10, 100, 1000, 10_000, 100_000, 1_000_000} {
+		s := sketchOf(t, 0, n)
+		got := float64(s.Estimate())
+		if relErr := math.Abs(got-float64(n)) / float64(n); relErr > maxRelErr {
+			t.Errorf("n = %d: estimate %.0f off by %.2f%%", n, got, relErr*100)
+		}
+	}
+}
+
+func TestEstimateIgnoresDuplicates(t *testing.T) {
+	s := sketchOf(t, 0, 1000)
+	before := s.Estimate()
+	for i := uint64(0); i < 1000; i++ {
+		s.Add(splitmix64(i))
+	}
+	if after := s.Estimate(); after != before {
+		t.Errorf("estimate changed from %d to %d after re-adding the same hashes", before, after)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	// Overlapping halves merge into exactly the sketch of their union.
+	a := sketchOf(t, 0, 6000)
+	b := sketchOf(t, 4000, 10_000)
+	union := sketchOf(t, 0, 10_000)
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a.regs, union.regs) {
+		t.Error("merged registers differ from the sketch of the union")
+	}
+
+	other, _ := New(DefaultPrecision - 1)
+	if err := a.Merge(other); !errors.Is(err, ErrPrecisionMismatch) {
+		t.Errorf("merge of different precisions error = %v, want %v", err, ErrPrecisionMismatch)
+	}
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		n      uint64
+		format byte
+	}{
+		{name: "empty", n: 0, format: formatSparse},
+		{name: "sparse", n: 100, format: formatSparse},
+		{name: "dense", n: 100_000, format: formatDense},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := sketchOf(t, 0, tt.n)
+			data, err := s.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data[0] != tt.format {
+				t.Errorf("format = %d, want %d", data[0], tt.format)
+			}
+			decoded, err := Decode(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decoded.Precision() != s.Precision() || !bytes.Equal(decoded.regs, s.regs) {
+				t.Error("decoded sketch differs from the original")
+			}
+			again, _ := decoded.MarshalBinary()
+			if !bytes.Equal(again, data) {
+				t.Error("re-encoding the decoded sketch changed its bytes")
+			}
+		})
+	}
+}
+
+func TestDecodeCorrupt(t *testing.T) {
+	dense := make([]byte, 2+1<<DefaultPrecision)
+	dense[0], dense[1] = formatDense, DefaultPrecision
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "nil", data: nil},
+		{name: "one byte", data: []byte{formatSparse}},
+		{name: "unknown format", data: []byte{9, DefaultPrecision}},
+		{name: "bad precision", data: []byte{formatSparse, maxPrecision + 1}},
+		{name: "partial sparse entry", data: []byte{formatSparse, DefaultPrecision, 0, 1}},
+		{name: "sparse index out of range", data: []byte{formatSparse, DefaultPrecision, 0xff, 0xff, 1}},
+		{name: "short dense", data: dense[:len(dense)-1]},
+		{name: "long dense", data: append(dense, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(tt.data); !errors.Is(err, ErrCorrupt) {
+				t.Errorf("Decode error = %v, want %v", err, ErrCorrupt)
+			}
+		})
+	}
+}
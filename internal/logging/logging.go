@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewFromEnv builds the process logger: JSON lines on stderr, or a
+// readable console format with LOG_FORMAT=console. LOG_LEVEL is one of
+// debug, info (default), warn or error. Sensitive fields are redacted.
+func NewFromEnv() *zap.Logger {
+	level := zapcore.InfoLevel
+	if v := strings.TrimSpace(os.Getenv("LOG_LEVEL")); v != "" {
+		if l, err := zapcore.ParseLevel(v); err == nil {
+			level = l
+		}
+	}
+
+	encoderCfg := zap.NewProductionEncoderConfig()
+	encoderCfg.TimeKey = "time"
+	encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+	var encoder zapcore.Encoder
+	if strings.EqualFold(os.Getenv("LOG_FORMAT"), "console") {
+		encoderCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(encoderCfg)
+	} else {
+		encoder = zapcore.NewJSONEncoder(encoderCfg)
+	}
+
+	core := zapcore.NewCore(encoder, zapcore.Lock(os.Stderr), level)
+	return zap.New(redactCore{core}, zap.AddCaller(), zap.AddStacktrace(zapcore.DPanicLevel))
+}
+
+// Install makes logger the zap global and sends the standard library's
+// log output through it, so packages still using log.Printf also write
+// structured lines.
+func Install(logger *zap.Logger) func() {
+	undoGlobals := zap.ReplaceGlobals(logger)
+	undoStdLog := zap.RedirectStdLog(logger)
+	return func() {
+		undoStdLog()
+		undoGlobals()
+	}
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID, or "" outside a request.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are field names, lowercased without "_" and "-", whose
+// values never reach the log.
+var sensitiveKeys = map[string]bool{
+	"apikey":        true,
+	"xapikey":       true,
+	"key":           true,
+	"password":      true,
+	"passwd":        true,
+	"secret":        true,
+	"token":         true,
+	"authorization": true,
+	"cookie":        true,
+	"setcookie":     true,
+	"dsn":           true,
+	"databaseurl":   true,
+}
+
+// sensitiveSuffixes catch names like smtp_password or webhook_secret.
+var sensitiveSuffixes = []string{"password", "secret", "token"}
+
+func isSensitive(key string) bool {
+	k := strings.ToLower(strings.NewReplacer("_", "", "-", "", ".", "").Replace(key))
+	if sensitiveKeys[k] {
+		return true
+	}
+	for _, suffix := range sensitiveSuffixes {
+		if strings.HasSuffix(k, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactCore replaces the values of sensitive fields, including those
+// attached with Logger.With, before they are encoded.
+type redactCore struct {
+	zapcore.Core
+}
+
+func (c redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return redactCore{c.Core.With(redactFields(fields))}
+}
+
+func (c redactCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, redactFields(fields))
+}
+
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fields {
+		if !isSensitive(f.Key) {
+			continue
+		}
+		if out == nil {
+			// Copy on first hit; the caller owns fields.
+			out = append([]zapcore.Field(nil), fields...)
+		}
+		out[i] = zap.String(f.Key, redacted)
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
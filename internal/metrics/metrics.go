@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"url-shortener/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/uptrace/bun"
+)
+
+// Redirect outcomes. There is deliberately no "expired" outcome: links
+// have no expiry, and deleted links are indistinguishable from unknown
+// short codes, so both count as not_found.
+const (
+	RedirectFound    = "found"
+	RedirectNotFound = "not_found"
+	// RedirectBlocked covers flagged links and destinations that loop or
+	// are no longer allowed.
+	RedirectBlocked = "blocked"
+	RedirectError   = "error"
+)
+
+// Link creation outcomes.
+const (
+	LinkCreated       = "created"
+	LinkLimitExceeded = "limit_exceeded"
+	LinkDuplicate     = "duplicate"
+	LinkInvalid       = "invalid"
+	// LinkCollision means no free short code was found within the retries.
+	LinkCollision = "collision"
+	LinkError     = "error"
+)
+
+// latencyBuckets are finer than prometheus.DefBuckets at the low end,
+// where redirects are expected to land.
+var latencyBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics owns a private registry, so only what is listed here is exposed.
+type Metrics struct {
+	cfg           Config
+	server        *http.Server
+	registry      *prometheus.Registry
+	requests      *prometheus.CounterVec
+	duration      *prometheus.HistogramVec
+	redirects     *prometheus.CounterVec
+	linkCreations *prometheus.CounterVec
+}
+
+func NewMetrics(db *bun.DB, recorder *usecase.ClickRecorder, shortener *usecase.ShortenerService) *Metrics {
+	return NewMetricsWithConfig(db, recorder, shortener, LoadConfig())
+}
+
+func NewMetricsWithConfig(db *bun.DB, recorder *usecase.ClickRecorder, shortener *usecase.ShortenerService, cfg Config) *Metrics {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	if recorder == nil {
+		panic("ClickRecorder cannot be nil")
+	}
+	if shortener == nil {
+		panic("ShortenerService cannot be nil")
+	}
+	m := &Metrics{
+		cfg:      cfg,
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by method, route and status.",
+			Buckets: latencyBuckets,
+		}, []string{"method", "route", "status"}),
+		redirects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "redirects_total",
+			Help: "Short link redirects by outcome.",
+		}, []string{"outcome"}),
+		linkCreations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "link_creations_total",
+			Help: "Link creation requests by outcome.",
+		}, []string{"outcome"}),
+	}
+	// Pre-create the outcome series so rates work before the first event.
+	for _, o := range []string{RedirectFound, RedirectNotFound, RedirectBlocked, RedirectError} {
+		m.redirects.WithLabelValues(o)
+	}
+	for _, o := range []string{LinkCreated, LinkLimitExceeded, LinkDuplicate, LinkInvalid, LinkCollision, LinkError} {
+		m.linkCreations.WithLabelValues(o)
+	}
+
+	stats := func(f func(usecase.ClickRecorderStats) float64) func() float64 {
+		return func() float64 { return f(recorder.Stats()) }
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(db.DB, "postgres"),
+		m.requests,
+		m.duration,
+		m.redirects,
+		m.linkCreations,
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "short_code_collisions_total",
+			Help: "Generated short codes that were already taken.",
+		}, func() float64 { return float64(shortener.Collisions()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "click_queue_length",
+			Help: "Click events waiting to be written.",
+		}, stats(func(s usecase.ClickRecorderStats) float64 { return float64(s.QueueLength) })),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "click_queue_capacity",
+			Help: "Size of the click event queue.",
+		}, stats(func(s usecase.ClickRecorderStats) float64 { return float64(s.QueueCapacity) })),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "click_events_enqueued_total",
+			Help: "Click events accepted into the queue.",
+		}, stats(func(s usecase.ClickRecorderStats) float64 { return float64(s.Enqueued) })),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "click_events_dropped_total",
+			Help: "Click events dropped because the queue was full.",
+		}, stats(func(s usecase.ClickRecorderStats) float64 { return float64(s.Dropped) })),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "click_events_recorded_total",
+			Help: "Click events written to the database.",
+		}, stats(func(s usecase.ClickRecorderStats) float64 { return float64(s.Recorded) })),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "click_events_failed_total",
+			Help: "Click events lost to failed batch writes.",
+		}, stats(func(s usecase.ClickRecorderStats) float64 { return float64(s.Failed) })),
+	)
+	return m
+}
+
+// Middleware records the count and latency of every request. Requests
+// that match no route share the "unmatched" label so scanners cannot blow
+// up the number of series.
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(ctx.Writer.Status())
+		m.requests.WithLabelValues(ctx.Request.Method, route, status).Inc()
+		m.duration.WithLabelValues(ctx.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+func (m *Metrics) ObserveRedirect(outcome string) {
+	m.redirects.WithLabelValues(outcome).Inc()
+}
+
+func (m *Metrics) ObserveLinkCreation(outcome string) {
+	m.linkCreations.WithLabelValues(outcome).Inc()
+}
+
+// Handler serves the registry in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
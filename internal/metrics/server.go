@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Config comes from METRICS_ADDR and METRICS_TOKEN. Metrics are served on
+// their own listener at Addr ("off" disables it) and, when Token is set,
+// also at /metrics on the main port behind "Authorization: Bearer <Token>".
+type Config struct {
+	Addr  string
+	Token string
+}
+
+func LoadConfig() Config {
+	addr := strings.TrimSpace(os.Getenv("METRICS_ADDR"))
+	if addr == "" {
+		addr = ":9090"
+	} else if strings.EqualFold(addr, "off") {
+		addr = ""
+	}
+	return Config{Addr: addr, Token: os.Getenv("METRICS_TOKEN")}
+}
+
+// RegisterRoutes exposes /metrics on the main router if a token is
+// configured; without one the endpoint would be public, so it is left out.
+func (m *Metrics) RegisterRoutes(r *gin.Engine) {
+	if m.cfg.Token == "" {
+		return
+	}
+	r.GET("/metrics", requireToken(m.cfg.Token), gin.WrapH(m.Handler()))
+}
+
+func requireToken(token string) gin.HandlerFunc {
+	want := []byte("Bearer " + token)
+	return func(ctx *gin.Context) {
+		got := []byte(ctx.GetHeader("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid metrics token"})
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// Start serves /metrics on the separate listener, if configured. It fails
+// fast when the address cannot be bound.
+func (m *Metrics) Start() error {
+	if m.cfg.Addr == "" {
+		return nil
+	}
+	ln, err := net.Listen("tcp", m.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+	m.server = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		log.Println("Metrics server starting on", m.cfg.Addr)
+		if err := m.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Metrics server failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+func (m *Metrics) Stop(ctx context.Context) error {
+	if m.server == nil {
+		return nil
+	}
+	return m.server.Shutdown(ctx)
+}
@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/usecase"
+)
+
+// SMTPNotifier mails events through an SMTP relay, normally one on the
+// local host. Credentials are optional; PLAIN auth is only used when a
+// username is set.
+type SMTPNotifier struct {
+	addr     string
+	from     string
+	username string
+	password string
+}
+
+var _ usecase.AlertNotifier = (*SMTPNotifier)(nil)
+
+func NewSMTPNotifier(addr, from, username, password string) *SMTPNotifier {
+	return &SMTPNotifier{addr: addr, from: from, username: username, password: password}
+}
+
+// Notify implements usecase.AlertNotifier. net/smtp does not take a
+// context, so the deadline is applied to the connection instead.
+func (n *SMTPNotifier) Notify(ctx context.Context, rule *domain.AlertRule, event *domain.AlertEvent) error {
+	msg := n.message(rule, event)
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	host, _, _ := net.SplitHostPort(n.addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if n.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", n.username, n.password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(n.from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(rule.Target); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (n *SMTPNotifier) message(rule *domain.AlertRule, event *domain.AlertEvent) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue(n.from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue(rule.Target))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", headerValue(subject(rule, event))))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+
+	fmt.Fprintf(&b, "%s\r\n\r\n", subject(rule, event))
+	fmt.Fprintf(&b, "Rule:      %s (#%d, %s)\r\n", rule.Name, rule.ID, rule.Kind)
+	if rule.ShortCode != "" {
+		fmt.Fprintf(&b, "Link:      /%s\r\n", rule.ShortCode)
+	}
+	fmt.Fprintf(&b, "Clicks:    %d\r\n", event.Value)
+	fmt.Fprintf(&b, "Baseline:  %.1f\r\n", event.Baseline)
+	fmt.Fprintf(&b, "Triggered: %s\r\n", event.TriggeredAt.UTC().Format(time.RFC3339))
+	return b.Bytes()
+}
+
+// headerValue strips line breaks so values cannot inject headers.
+func headerValue(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/usecase"
+)
+
+// NewAlertNotifierFromEnv builds the webhook and email notifiers. Webhook
+// bodies are signed when ALERT_WEBHOOK_SECRET is set; mail goes through
+// the SMTP relay at SMTP_ADDR (default localhost:25).
+func NewAlertNotifierFromEnv() usecase.AlertNotifier {
+	addr := strings.TrimSpace(os.Getenv("SMTP_ADDR"))
+	if addr == "" {
+		addr = "localhost:25"
+	}
+	from := strings.TrimSpace(os.Getenv("SMTP_FROM"))
+	if from == "" {
+		from = "alerts@localhost"
+	}
+	return Dispatcher{
+		domain.AlertChannelWebhook: NewWebhookNotifier(os.Getenv("ALERT_WEBHOOK_SECRET"), nil),
+		domain.AlertChannelEmail:   NewSMTPNotifier(addr, from, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD")),
+	}
+}
+
+// Dispatcher routes every event to the notifier of its rule's channel.
+type Dispatcher map[string]usecase.AlertNotifier
+
+var _ usecase.AlertNotifier = Dispatcher(nil)
+
+// Notify implements usecase.AlertNotifier.
+func (d Dispatcher) Notify(ctx context.Context, rule *domain.AlertRule, event *domain.AlertEvent) error {
+	n, ok := d[rule.Channel]
+	if !ok {
+		return fmt.Errorf("no notifier for channel %q", rule.Channel)
+	}
+	return n.Notify(ctx, rule, event)
+}
+
+// subject is the one-line summary used as mail subject and webhook text.
+func subject(rule *domain.AlertRule, event *domain.AlertEvent) string {
+	scope := "your account"
+	if rule.ShortCode != "" {
+		scope = "/" + rule.ShortCode
+	}
+	switch event.Kind {
+	case domain.AlertKindThreshold:
+		return fmt.Sprintf("%s: %s reached %d clicks", rule.Name, scope, event.Value)
+	case domain.AlertKindSpike:
+		return fmt.Sprintf("%s: %s got %d clicks this hour (%.1f per hour before)", rule.Name, scope, event.Value, event.Baseline)
+	}
+	return fmt.Sprintf("%s: alert for %s", rule.Name, scope)
+}
@@ -0,0 +1,102 @@
+package repo
+
+import (
+	"context"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repo/model"
+	"url-shortener/internal/usecase"
+
+	"github.com/uptrace/bun"
+)
+
+type AccountSummaryPGRepository struct {
+	db *bun.DB
+}
+
+func NewAccountSummaryPGRepository(db *bun.DB) usecase.AccountSummaryRepository {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	return &AccountSummaryPGRepository{db: db}
+}
+
+type attentionLinkRow struct {
+	model.LinkBunModel `bun:",extend"`
+	Reason             string    `bun:"reason"`
+	Since              time.Time `bun:"since"`
+}
+
+// Summary implements usecase.AccountSummaryRepository. It runs four small
+// queries: link counts, click totals from the hourly rollups, top links by
+// their counters and recently broken or flagged links.
+func (r *AccountSummaryPGRepository) Summary(ctx context.Context, query domain.AccountSummaryQuery) (*domain.AccountSummary, error) {
+	summary := &domain.AccountSummary{}
+	err := r.db.NewSelect().
+		Model((*model.LinkBunModel)(nil)).
+		ColumnExpr("COUNT(*)").
+		ColumnExpr("COUNT(*) FILTER (WHERE flagged_at IS NULL)").
+		Where("user_id = ?", query.UserID).
+		Scan(ctx, &summary.TotalLinks, &summary.ActiveLinks)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.NewSelect().
+		Model((*model.ClickRollupBunModel)(nil)).
+		ColumnExpr("COALESCE(SUM(clicks) FILTER (WHERE bucket >= ?), 0)", query.TodayFrom).
+		ColumnExpr("COALESCE(SUM(clicks) FILTER (WHERE bucket >= ?), 0)", query.WeekFrom).
+		ColumnExpr("COALESCE(SUM(clicks), 0)").
+		Where("user_id = ?", query.UserID).
+		Where("bucket >= ?", query.MonthFrom).
+		Scan(ctx, &summary.ClicksToday, &summary.ClicksLast7Days, &summary.ClicksLast30Days)
+	if err != nil {
+		return nil, err
+	}
+
+	var top []model.LinkBunModel
+	err = r.db.NewSelect().
+		Model(&top).
+		Where("user_id = ?", query.UserID).
+		Where("click_count > 0").
+		Order("click_count DESC", "id").
+		Limit(query.TopLinks).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summary.TopLinks = make([]*domain.Link, 0, len(top))
+	for i := range top {
+		summary.TopLinks = append(summary.TopLinks, top[i].ToDomain())
+	}
+
+	var attention []attentionLinkRow
+	err = r.db.NewSelect().
+		Model(&attention).
+		ColumnExpr("link_bun_model.*").
+		ColumnExpr("CASE WHEN flagged_at >= ? THEN ? ELSE ? END AS reason",
+			query.AttentionSince, domain.AttentionFlagged, domain.AttentionBroken).
+		ColumnExpr("CASE WHEN flagged_at >= ? THEN flagged_at ELSE health_changed_at END AS since",
+			query.AttentionSince).
+		Where("user_id = ?", query.UserID).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				Where("flagged_at >= ?", query.AttentionSince).
+				WhereOr("health_status = ? AND health_changed_at >= ?", domain.LinkHealthBroken, query.AttentionSince)
+		}).
+		OrderExpr("since DESC, id").
+		Limit(query.AttentionLinks).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summary.AttentionLinks = make([]domain.AttentionLink, 0, len(attention))
+	for i := range attention {
+		summary.AttentionLinks = append(summary.AttentionLinks, domain.AttentionLink{
+			Link:   attention[i].LinkBunModel.ToDomain(),
+			Reason: attention[i].Reason,
+			Since:  attention[i].Since,
+		})
+	}
+	return summary, nil
+}
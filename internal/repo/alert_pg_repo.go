@@ -0,0 +1,306 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repo/model"
+	"url-shortener/internal/usecase"
+
+	"github.com/uptrace/bun"
+)
+
+type AlertPGRepository struct {
+	db *bun.DB
+}
+
+func NewAlertPGRepository(db *bun.DB) usecase.AlertRepository {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	return &AlertPGRepository{db: db}
+}
+
+type alertRuleWithLink struct {
+	model.AlertRuleBunModel `bun:",extend"`
+	ShortCode               sql.NullString `bun:"short_code"`
+}
+
+func (r alertRuleWithLink) toDomain() *domain.AlertRule {
+	rule := r.AlertRuleBunModel.ToDomain()
+	rule.ShortCode = r.ShortCode.String
+	return rule
+}
+
+// selectRules selects rules with the short codes of their links.
+func (r *AlertPGRepository) selectRules(rows *[]alertRuleWithLink) *bun.SelectQuery {
+	return r.db.NewSelect().
+		Model(rows).
+		ColumnExpr("alert_rule_bun_model.*").
+		ColumnExpr("l.short_code").
+		Join("LEFT JOIN links AS l ON l.id = alert_rule_bun_model.link_id")
+}
+
+// CreateRule implements usecase.AlertRepository.
+func (r *AlertPGRepository) CreateRule(ctx context.Context, rule *domain.AlertRule) error {
+	ruleModel := model.ToAlertRuleBunModel(rule)
+	_, err := r.db.NewInsert().
+		Model(ruleModel).
+		ExcludeColumn("id").
+		Returning("id, created_at").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	rule.ID = ruleModel.ID
+	rule.CreatedAt = ruleModel.CreatedAt
+	return nil
+}
+
+// FindRule implements usecase.AlertRepository.
+func (r *AlertPGRepository) FindRule(ctx context.Context, userID, ruleID int64) (*domain.AlertRule, error) {
+	var rows []alertRuleWithLink
+	err := r.selectRules(&rows).
+		Where("alert_rule_bun_model.id = ?", ruleID).
+		Where("alert_rule_bun_model.user_id = ?", userID).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return rows[0].toDomain(), nil
+}
+
+// ListRules implements usecase.AlertRepository.
+func (r *AlertPGRepository) ListRules(ctx context.Context, userID int64) ([]*domain.AlertRule, error) {
+	var rows []alertRuleWithLink
+	err := r.selectRules(&rows).
+		Where("alert_rule_bun_model.user_id = ?", userID).
+		Order("alert_rule_bun_model.id").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]*domain.AlertRule, 0, len(rows))
+	for _, row := range rows {
+		rules = append(rules, row.toDomain())
+	}
+	return rules, nil
+}
+
+// CountRules implements usecase.AlertRepository.
+func (r *AlertPGRepository) CountRules(ctx context.Context, userID int64) (int, error) {
+	return r.db.NewSelect().
+		Model((*model.AlertRuleBunModel)(nil)).
+		Where("user_id = ?", userID).
+		Count(ctx)
+}
+
+// UpdateRule implements usecase.AlertRepository.
+func (r *AlertPGRepository) UpdateRule(ctx context.Context, rule *domain.AlertRule) (bool, error) {
+	ruleModel := model.ToAlertRuleBunModel(rule)
+	res, err := r.db.NewUpdate().
+		Model((*model.AlertRuleBunModel)(nil)).
+		Set("link_id = ?", ruleModel.LinkID).
+		Set("name = ?", ruleModel.Name).
+		Set("kind = ?", ruleModel.Kind).
+		Set("threshold = ?", ruleModel.Threshold).
+		Set("factor = ?", ruleModel.Factor).
+		Set("window_hours = ?", ruleModel.WindowHours).
+		Set("min_clicks = ?", ruleModel.MinClicks).
+		Set("include_bots = ?", ruleModel.IncludeBots).
+		Set("channel = ?", ruleModel.Channel).
+		Set("target = ?", ruleModel.Target).
+		Set("cooldown_seconds = ?", ruleModel.CooldownSeconds).
+		Set("enabled = ?", ruleModel.Enabled).
+		Set("last_triggered_at = NULL").
+		Set("updated_at = NOW()").
+		Where("id = ?", rule.ID).
+		Where("user_id = ?", rule.UserID).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// DeleteRule implements usecase.AlertRepository. Rules are soft deleted so
+// their history stays readable.
+func (r *AlertPGRepository) DeleteRule(ctx context.Context, userID, ruleID int64) (bool, error) {
+	res, err := r.db.NewDelete().
+		Model((*model.AlertRuleBunModel)(nil)).
+		Where("id = ?", ruleID).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ListEnabledRules implements usecase.AlertRepository.
+func (r *AlertPGRepository) ListEnabledRules(ctx context.Context, afterID int64, limit int) ([]*domain.AlertRule, error) {
+	var rows []alertRuleWithLink
+	err := r.selectRules(&rows).
+		Where("alert_rule_bun_model.enabled").
+		Where("alert_rule_bun_model.id > ?", afterID).
+		Where("alert_rule_bun_model.link_id IS NULL OR l.deleted_at IS NULL").
+		Order("alert_rule_bun_model.id").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]*domain.AlertRule, 0, len(rows))
+	for _, row := range rows {
+		rules = append(rules, row.toDomain())
+	}
+	return rules, nil
+}
+
+// MarkTriggered implements usecase.AlertRepository.
+func (r *AlertPGRepository) MarkTriggered(ctx context.Context, ruleID int64, at time.Time) error {
+	_, err := r.db.NewUpdate().
+		Model((*model.AlertRuleBunModel)(nil)).
+		Set("last_triggered_at = ?", at).
+		Where("id = ?", ruleID).
+		Exec(ctx)
+	return err
+}
+
+// SumClicks implements usecase.AlertRepository.
+func (r *AlertPGRepository) SumClicks(ctx context.Context, userID int64, linkID *int64, includeBots bool, from, to time.Time) (int64, error) {
+	q := r.db.NewSelect().
+		Model((*model.ClickRollupBunModel)(nil)).
+		ColumnExpr("COALESCE(SUM(?), 0)", clicksExpr(includeBots)).
+		Where("user_id = ?", userID)
+	if linkID != nil {
+		q = q.Where("link_id = ?", *linkID)
+	}
+	if !from.IsZero() {
+		q = q.Where("bucket >= ?", from)
+	}
+	if !to.IsZero() {
+		q = q.Where("bucket < ?", to)
+	}
+	var sum int64
+	err := q.Scan(ctx, &sum)
+	return sum, err
+}
+
+// CreateEvent implements usecase.AlertRepository.
+func (r *AlertPGRepository) CreateEvent(ctx context.Context, event *domain.AlertEvent) (bool, error) {
+	eventModel := model.ToAlertEventBunModel(event)
+	res, err := r.db.NewInsert().
+		Model(eventModel).
+		ExcludeColumn("id").
+		On("CONFLICT (rule_id, dedup_key) DO NOTHING").
+		Returning("id").
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	event.ID = eventModel.ID
+	return true, nil
+}
+
+// ClaimEvent implements usecase.AlertRepository.
+func (r *AlertPGRepository) ClaimEvent(ctx context.Context, eventID int64, attempts int, nextAttemptAt time.Time) (bool, error) {
+	res, err := r.db.NewUpdate().
+		Model((*model.AlertEventBunModel)(nil)).
+		Set("attempts = attempts + 1").
+		Set("next_attempt_at = ?", nextAttemptAt).
+		Where("id = ?", eventID).
+		Where("attempts = ?", attempts).
+		Where("status <> ?", domain.AlertDeliverySent).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// FinishEvent implements usecase.AlertRepository.
+func (r *AlertPGRepository) FinishEvent(ctx context.Context, event *domain.AlertEvent) error {
+	_, err := r.db.NewUpdate().
+		Model((*model.AlertEventBunModel)(nil)).
+		Set("status = ?", event.Status).
+		Set("error = NULLIF(?, '')", event.Error).
+		Set("next_attempt_at = ?", event.NextAttemptAt).
+		Set("delivered_at = ?", event.DeliveredAt).
+		Where("id = ?", event.ID).
+		Exec(ctx)
+	return err
+}
+
+// ListRetryableEvents implements usecase.AlertRepository.
+func (r *AlertPGRepository) ListRetryableEvents(ctx context.Context, now time.Time, maxAttempts, limit int) ([]*domain.AlertEvent, error) {
+	eventModels := []*model.AlertEventBunModel{}
+	err := r.db.NewSelect().
+		Model(&eventModels).
+		Where("status <> ?", domain.AlertDeliverySent).
+		Where("attempts < ?", maxAttempts).
+		Where("next_attempt_at <= ?", now).
+		Order("next_attempt_at").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]*domain.AlertEvent, 0, len(eventModels))
+	for _, m := range eventModels {
+		events = append(events, m.ToDomain())
+	}
+	return events, nil
+}
+
+type alertEventWithRule struct {
+	model.AlertEventBunModel `bun:",extend"`
+	RuleName                 string         `bun:"rule_name"`
+	ShortCode                sql.NullString `bun:"short_code"`
+}
+
+// History implements usecase.AlertRepository. Events of deleted rules are
+// included.
+func (r *AlertPGRepository) History(ctx context.Context, query domain.AlertHistoryQuery) ([]*domain.AlertEvent, error) {
+	var rows []alertEventWithRule
+	q := r.db.NewSelect().
+		Model(&rows).
+		ColumnExpr("alert_event_bun_model.*").
+		ColumnExpr("ar.name AS rule_name").
+		ColumnExpr("l.short_code").
+		Join("JOIN alert_rules AS ar ON ar.id = alert_event_bun_model.rule_id").
+		Join("LEFT JOIN links AS l ON l.id = alert_event_bun_model.link_id").
+		Where("alert_event_bun_model.user_id = ?", query.UserID).
+		Order("alert_event_bun_model.triggered_at DESC", "alert_event_bun_model.id DESC").
+		Limit(query.Limit)
+	if query.RuleID != nil {
+		q = q.Where("alert_event_bun_model.rule_id = ?", *query.RuleID)
+	}
+	if query.From != nil {
+		q = q.Where("alert_event_bun_model.triggered_at >= ?", *query.From)
+	}
+	if query.To != nil {
+		q = q.Where("alert_event_bun_model.triggered_at < ?", *query.To)
+	}
+	if err := q.Scan(ctx); err != nil {
+		return nil, err
+	}
+	events := make([]*domain.AlertEvent, 0, len(rows))
+	for _, row := range rows {
+		event := row.AlertEventBunModel.ToDomain()
+		event.RuleName = row.RuleName
+		event.ShortCode = row.ShortCode.String
+		events = append(events, event)
+	}
+	return events, nil
+}
@@ -0,0 +1,114 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repo/model"
+	"url-shortener/internal/usecase"
+
+	"github.com/uptrace/bun"
+)
+
+// auditLockKey is the advisory lock serializing appends to the audit
+// trail, so every event links to the one stored right before it.
+const auditLockKey = 0x61756469 // "audi"
+
+type AuditPGRepository struct {
+	db *bun.DB
+}
+
+func NewAuditPGRepository(db *bun.DB) usecase.AuditRepository {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	return &AuditPGRepository{db: db}
+}
+
+// Append implements usecase.AuditRepository.
+func (r *AuditPGRepository) Append(ctx context.Context, event *domain.AuditEvent, hash func(prevHash string) string) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(?)", auditLockKey); err != nil {
+			return err
+		}
+		var prevHash string
+		err := tx.NewSelect().
+			Model((*model.AuditEventBunModel)(nil)).
+			Column("hash").
+			Order("id DESC").
+			Limit(1).
+			Scan(ctx, &prevHash)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		event.PrevHash = prevHash
+		event.Hash = hash(prevHash)
+
+		eventModel := model.ToAuditEventBunModel(event)
+		_, err = tx.NewInsert().
+			Model(eventModel).
+			ExcludeColumn("id").
+			Returning("id").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		event.ID = eventModel.ID
+		return nil
+	})
+}
+
+// List implements usecase.AuditRepository.
+func (r *AuditPGRepository) List(ctx context.Context, query domain.AuditQuery) ([]*domain.AuditEvent, error) {
+	eventModels := []*model.AuditEventBunModel{}
+	q := r.db.NewSelect().
+		Model(&eventModels).
+		Order("id DESC").
+		Limit(query.Limit)
+	q = auditFilter(q, query)
+	if query.BeforeID > 0 {
+		q = q.Where("id < ?", query.BeforeID)
+	}
+	if err := q.Scan(ctx); err != nil {
+		return nil, err
+	}
+	events := make([]*domain.AuditEvent, 0, len(eventModels))
+	for _, m := range eventModels {
+		events = append(events, m.ToDomain())
+	}
+	return events, nil
+}
+
+// Stream implements usecase.AuditRepository.
+func (r *AuditPGRepository) Stream(ctx context.Context, query domain.AuditQuery, fn func(*domain.AuditEvent) error) error {
+	q := r.db.NewSelect().
+		Model((*model.AuditEventBunModel)(nil)).
+		Order("id")
+	q = auditFilter(q, query)
+	return streamRows(ctx, r.db, q, func(row *model.AuditEventBunModel) error {
+		return fn(row.ToDomain())
+	})
+}
+
+func auditFilter(q *bun.SelectQuery, query domain.AuditQuery) *bun.SelectQuery {
+	if query.ActorUserID != nil {
+		q = q.Where("actor_user_id = ?", *query.ActorUserID)
+	}
+	if query.Action != "" {
+		q = q.Where("action = ?", query.Action)
+	}
+	if query.TargetType != "" {
+		q = q.Where("target_type = ?", query.TargetType)
+	}
+	if query.TargetID != "" {
+		q = q.Where("target_id = ?", query.TargetID)
+	}
+	if query.From != nil {
+		q = q.Where("created_at >= ?", query.From.UTC())
+	}
+	if query.To != nil {
+		q = q.Where("created_at < ?", query.To.UTC())
+	}
+	return q
+}
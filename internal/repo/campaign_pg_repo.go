@@ -0,0 +1,315 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/hll"
+	"url-shortener/internal/repo/model"
+	"url-shortener/internal/usecase"
+
+	"github.com/uptrace/bun"
+)
+
+const campaignTopReferrers = 10
+
+type CampaignPGRepository struct {
+	db *bun.DB
+}
+
+func NewCampaignPGRepository(db *bun.DB) usecase.CampaignRepository {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	return &CampaignPGRepository{db: db}
+}
+
+// Create implements usecase.CampaignRepository.
+func (r *CampaignPGRepository) Create(ctx context.Context, campaign *domain.Campaign) error {
+	campaignModel := model.ToCampaignBunModel(campaign)
+	_, err := r.db.NewInsert().Model(campaignModel).ExcludeColumn("id").Returning("*").Exec(ctx)
+	if err != nil {
+		return err
+	}
+	*campaign = *campaignModel.ToDomain()
+	return nil
+}
+
+// FindByID implements usecase.CampaignRepository.
+func (r *CampaignPGRepository) FindByID(ctx context.Context, userID, campaignID int64) (*domain.Campaign, error) {
+	campaignModel := new(model.CampaignBunModel)
+	err := r.db.NewSelect().
+		Model(campaignModel).
+		Where("id = ?", campaignID).
+		Where("user_id = ?", userID).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return campaignModel.ToDomain(), nil
+}
+
+// ListByUser implements usecase.CampaignRepository.
+func (r *CampaignPGRepository) ListByUser(ctx context.Context, userID int64) ([]*domain.Campaign, error) {
+	campaignModels := []*model.CampaignBunModel{}
+	err := r.db.NewSelect().
+		Model(&campaignModels).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	campaigns := make([]*domain.Campaign, 0, len(campaignModels))
+	for _, cm := range campaignModels {
+		campaigns = append(campaigns, cm.ToDomain())
+	}
+	return campaigns, nil
+}
+
+// Update implements usecase.CampaignRepository.
+func (r *CampaignPGRepository) Update(ctx context.Context, campaign *domain.Campaign) error {
+	now := time.Now()
+	campaign.UpdatedAt = &now
+	_, err := r.db.NewUpdate().
+		Model(model.ToCampaignBunModel(campaign)).
+		Column("name", "starts_at", "ends_at", "updated_at").
+		Where("id = ?", campaign.ID).
+		Where("user_id = ?", campaign.UserID).
+		Exec(ctx)
+	return err
+}
+
+// SoftDelete implements usecase.CampaignRepository. Links keep existing but
+// are detached from the campaign.
+func (r *CampaignPGRepository) SoftDelete(ctx context.Context, userID, campaignID int64) ([]*domain.Link, error) {
+	var detached []*domain.Link
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var err error
+		detached, err = setLinksCampaign(ctx, tx, nil, func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("campaign_id = ?", campaignID).Where("user_id = ?", userID)
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tx.NewDelete().
+			Model((*model.CampaignBunModel)(nil)).
+			Where("id = ?", campaignID).
+			Where("user_id = ?", userID).
+			Exec(ctx)
+		return err
+	})
+	return detached, err
+}
+
+// AssignLinks implements usecase.CampaignRepository.
+func (r *CampaignPGRepository) AssignLinks(ctx context.Context, userID, campaignID int64, shortCodes []string) ([]*domain.Link, error) {
+	if len(shortCodes) == 0 {
+		return nil, nil
+	}
+	var assigned []*domain.Link
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var err error
+		assigned, err = setLinksCampaign(ctx, tx, &campaignID, func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("user_id = ?", userID).Where("short_code IN (?)", bun.In(shortCodes))
+		})
+		return err
+	})
+	return assigned, err
+}
+
+// UnassignLink implements usecase.CampaignRepository.
+func (r *CampaignPGRepository) UnassignLink(ctx context.Context, userID, campaignID int64, shortCode string) (*domain.Link, error) {
+	var unassigned []*domain.Link
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var err error
+		unassigned, err = setLinksCampaign(ctx, tx, nil, func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("user_id = ?", userID).Where("campaign_id = ?", campaignID).Where("short_code = ?", shortCode)
+		})
+		return err
+	})
+	if err != nil || len(unassigned) == 0 {
+		return nil, err
+	}
+	return unassigned[0], nil
+}
+
+// setLinksCampaign locks the links selected by where, sets their campaign
+// and returns them as they were before.
+func setLinksCampaign(ctx context.Context, tx bun.Tx, campaignID *int64, where func(*bun.SelectQuery) *bun.SelectQuery) ([]*domain.Link, error) {
+	var linkModels []*model.LinkBunModel
+	err := tx.NewSelect().
+		Model(&linkModels).
+		Apply(where).
+		For("UPDATE").
+		Scan(ctx)
+	if err != nil || len(linkModels) == 0 {
+		return nil, err
+	}
+	ids := make([]int64, len(linkModels))
+	links := make([]*domain.Link, len(linkModels))
+	for i, lm := range linkModels {
+		ids[i] = lm.ID
+		links[i] = lm.ToDomain()
+	}
+	_, err = tx.NewUpdate().
+		Model((*model.LinkBunModel)(nil)).
+		Set("campaign_id = ?", campaignID).
+		Where("id IN (?)", bun.In(ids)).
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// Stats implements usecase.CampaignRepository. Without a range, clicks come
+// from the lifetime link counters; with one they are summed from the hourly
+// rollups, so ranges stay accurate after raw events expire. Referrers come
+// from the daily dimension rollups of the days the range touches, and the
+// last click in a range from the raw events. Bot clicks are left out
+// unless includeBots is set.
+func (r *CampaignPGRepository) Stats(ctx context.Context, campaignID int64, from, to *time.Time, includeBots bool) (*domain.CampaignStats, error) {
+	stats := &domain.CampaignStats{CampaignID: campaignID, From: from, To: to, IncludeBots: includeBots}
+	clickCount := bun.Safe("click_count")
+	if includeBots {
+		clickCount = "click_count + bot_click_count"
+	}
+	err := r.db.NewSelect().
+		Model((*model.LinkBunModel)(nil)).
+		ColumnExpr("COUNT(*)").
+		ColumnExpr("COALESCE(SUM(?), 0)", clickCount).
+		ColumnExpr("MAX(last_clicked_at)").
+		Where("campaign_id = ?", campaignID).
+		Scan(ctx, &stats.Links, &stats.Clicks, &stats.LastClickedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	campaignLinks := r.db.NewSelect().
+		Model((*model.LinkBunModel)(nil)).
+		Column("id").
+		Where("campaign_id = ?", campaignID)
+	// Rollup buckets are whole UTC hours, so both ends of the range are
+	// truncated to the hour: clicks in the hour containing from count, and
+	// those in the hour containing to do not.
+	hourly := func() *bun.SelectQuery {
+		q := r.db.NewSelect().
+			Model((*model.ClickRollupBunModel)(nil)).
+			Where("link_id IN (?)", campaignLinks)
+		if from != nil {
+			q = q.Where("bucket >= ?", from.UTC().Truncate(time.Hour))
+		}
+		if to != nil {
+			q = q.Where("bucket < ?", to.UTC().Truncate(time.Hour))
+		}
+		return q
+	}
+
+	if from != nil || to != nil {
+		err := hourly().
+			ColumnExpr("COALESCE(SUM(?), 0)", clicksExpr(includeBots)).
+			Scan(ctx, &stats.Clicks)
+		if err != nil {
+			return nil, err
+		}
+		// The link counters only know the latest click overall; the last
+		// one inside the range needs the raw events.
+		stats.LastClickedAt = nil
+		last := r.db.NewSelect().
+			Model((*model.ClickEventBunModel)(nil)).
+			ColumnExpr("MAX(clicked_at)").
+			Where("link_id IN (?)", campaignLinks)
+		if !includeBots {
+			last = last.Where("NOT is_bot")
+		}
+		if from != nil {
+			last = last.Where("clicked_at >= ?", *from)
+		}
+		if to != nil {
+			last = last.Where("clicked_at < ?", *to)
+		}
+		if err := last.Scan(ctx, &stats.LastClickedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	referrers := r.db.NewSelect().
+		Model((*model.ClickDimensionRollupBunModel)(nil)).
+		ColumnExpr("value AS referrer").
+		ColumnExpr("SUM(?) AS clicks", clicksExpr(includeBots)).
+		Where("link_id IN (?)", campaignLinks).
+		Where("dimension = ?", domain.DimensionReferrer)
+	if from != nil {
+		referrers = referrers.Where("day >= ?", from.UTC().Format(time.DateOnly))
+	}
+	if to != nil {
+		referrers = referrers.Where("day <= ?", to.Add(-time.Nanosecond).UTC().Format(time.DateOnly))
+	}
+	err = referrers.
+		GroupExpr("1").
+		Having("SUM(?) > 0", clicksExpr(includeBots)).
+		OrderExpr("clicks DESC").
+		Limit(campaignTopReferrers).
+		Scan(ctx, &stats.TopReferrers)
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.UniqueVisitors, err = r.uniqueVisitors(ctx, campaignID, from, to); err != nil {
+		return nil, err
+	}
+
+	err = hourly().
+		ColumnExpr("date_trunc('day', bucket, 'UTC') AS start").
+		ColumnExpr("SUM(?) AS clicks", clicksExpr(includeBots)).
+		GroupExpr("1").
+		Having("SUM(?) > 0", clicksExpr(includeBots)).
+		OrderExpr("1").
+		Scan(ctx, &stats.TimeSeries)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// uniqueVisitors merges the daily visitor sketches of the campaign's links
+// over the UTC days touched by the range.
+func (r *CampaignPGRepository) uniqueVisitors(ctx context.Context, campaignID int64, from, to *time.Time) (int64, error) {
+	q := r.db.NewSelect().
+		Model((*model.ClickVisitorSketchBunModel)(nil)).
+		Column("sketch").
+		Where("link_id IN (?)", r.db.NewSelect().
+			Model((*model.LinkBunModel)(nil)).
+			Column("id").
+			Where("campaign_id = ?", campaignID)).
+		Where("sketch IS NOT NULL")
+	if from != nil {
+		q = q.Where("day >= ?", from.UTC().Format(time.DateOnly))
+	}
+	if to != nil {
+		q = q.Where("day <= ?", to.Add(-time.Nanosecond).UTC().Format(time.DateOnly))
+	}
+	var encoded [][]byte
+	if err := q.Scan(ctx, &encoded); err != nil {
+		return 0, err
+	}
+
+	total := hll.NewDefault()
+	for _, b := range encoded {
+		sketch, err := hll.Decode(b)
+		if err != nil {
+			return 0, err
+		}
+		if err := total.Merge(sketch); err != nil {
+			return 0, err
+		}
+	}
+	return int64(total.Estimate()), nil
+}
@@ -0,0 +1,105 @@
+package repo
+
+import (
+	"context"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repo/model"
+	"url-shortener/internal/usecase"
+
+	"github.com/uptrace/bun"
+)
+
+type ClickExportPGRepository struct {
+	db *bun.DB
+}
+
+func NewClickExportPGRepository(db *bun.DB) usecase.ClickExportRepository {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	return &ClickExportPGRepository{db: db}
+}
+
+type exportedClickRow struct {
+	model.ClickEventBunModel `bun:",extend"`
+	ShortCode                string `bun:"short_code"`
+}
+
+type hourlyClicksRow struct {
+	model.ClickRollupBunModel `bun:",extend"`
+	ShortCode                 string `bun:"short_code"`
+}
+
+// StreamClicks implements usecase.ClickExportRepository. Rows are read
+// from a cursor and handed to fn one at a time, ordered by click time.
+func (r *ClickExportPGRepository) StreamClicks(ctx context.Context, query domain.ExportQuery, fn func(*domain.ExportedClick) error) error {
+	q := r.db.NewSelect().
+		TableExpr("click_events AS e").
+		ColumnExpr("e.*").
+		ColumnExpr("l.short_code").
+		Join("JOIN links AS l ON l.id = e.link_id").
+		OrderExpr("e.clicked_at, e.id")
+	q = exportFilter(q, "e", "clicked_at", query)
+
+	return streamRows(ctx, r.db, q, func(row *exportedClickRow) error {
+		return fn(&domain.ExportedClick{ClickEvent: *row.ToDomain(), ShortCode: row.ShortCode})
+	})
+}
+
+// StreamHourlyClicks implements usecase.ClickExportRepository.
+func (r *ClickExportPGRepository) StreamHourlyClicks(ctx context.Context, query domain.ExportQuery, fn func(*domain.HourlyClicks) error) error {
+	q := r.db.NewSelect().
+		TableExpr("click_rollups_hourly AS h").
+		ColumnExpr("h.*").
+		ColumnExpr("l.short_code").
+		Join("JOIN links AS l ON l.id = h.link_id").
+		OrderExpr("h.bucket, h.link_id")
+	q = exportFilter(q, "h", "bucket", query)
+
+	return streamRows(ctx, r.db, q, func(row *hourlyClicksRow) error {
+		return fn(&domain.HourlyClicks{
+			LinkID:    row.LinkID,
+			ShortCode: row.ShortCode,
+			UserID:    row.UserID,
+			Bucket:    row.Bucket,
+			Clicks:    row.Clicks,
+			BotClicks: row.BotClicks,
+		})
+	})
+}
+
+func exportFilter(q *bun.SelectQuery, alias, timeColumn string, query domain.ExportQuery) *bun.SelectQuery {
+	if query.UserID != nil {
+		q = q.Where("?.user_id = ?", bun.Ident(alias), *query.UserID)
+	}
+	if query.LinkID != nil {
+		q = q.Where("?.link_id = ?", bun.Ident(alias), *query.LinkID)
+	}
+	if query.From != nil {
+		q = q.Where("?.? >= ?", bun.Ident(alias), bun.Ident(timeColumn), query.From.UTC())
+	}
+	if query.To != nil {
+		q = q.Where("?.? < ?", bun.Ident(alias), bun.Ident(timeColumn), query.To.UTC())
+	}
+	return q
+}
+
+// streamRows scans the rows of q one by one, so memory use does not grow
+// with the size of the result.
+func streamRows[T any](ctx context.Context, db *bun.DB, q *bun.SelectQuery, fn func(*T) error) error {
+	rows, err := q.Rows(ctx)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		row := new(T)
+		if err := db.ScanRow(ctx, rows, row); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
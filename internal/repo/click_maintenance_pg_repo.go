@@ -0,0 +1,365 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repo/model"
+	"url-shortener/internal/usecase"
+
+	"github.com/uptrace/bun"
+)
+
+const clickPartitionPrefix = "click_events_"
+
+type ClickMaintenancePGRepository struct {
+	db *bun.DB
+}
+
+func NewClickMaintenancePGRepository(db *bun.DB) usecase.ClickMaintenanceRepository {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	return &ClickMaintenancePGRepository{db: db}
+}
+
+// clickPartitionName names the partition holding the UTC month of t, e.g.
+// click_events_2025_09.
+func clickPartitionName(t time.Time) string {
+	return clickPartitionPrefix + t.UTC().Format("2006_01")
+}
+
+// EnsurePartitions implements usecase.ClickMaintenanceRepository.
+func (r *ClickMaintenancePGRepository) EnsurePartitions(ctx context.Context, from time.Time, months int) ([]string, error) {
+	existing, err := r.partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Date(from.UTC().Year(), from.UTC().Month(), 1, 0, 0, 0, 0, time.UTC)
+	var created []string
+	for i := 0; i < months; i++ {
+		month := start.AddDate(0, i, 0)
+		name := clickPartitionName(month)
+		if _, ok := existing[name]; ok {
+			continue
+		}
+		if err := r.createPartition(ctx, name, month, month.AddDate(0, 1, 0)); err != nil {
+			return created, fmt.Errorf("create partition %s: %w", name, err)
+		}
+		created = append(created, name)
+	}
+	return created, nil
+}
+
+// createPartition creates the partition for [start, end). Postgres refuses
+// while the default partition holds rows of that range, so they are moved
+// out first and back in through the parent once the partition exists, all
+// in one transaction.
+func (r *ClickMaintenancePGRepository) createPartition(ctx context.Context, name string, start, end time.Time) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.ExecContext(ctx, "CREATE TEMP TABLE click_events_moving (LIKE click_events) ON COMMIT DROP")
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			WITH moved AS (
+				DELETE FROM click_events_default WHERE clicked_at >= ? AND clicked_at < ? RETURNING *
+			)
+			INSERT INTO click_events_moving SELECT * FROM moved`, start, end)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			"CREATE TABLE IF NOT EXISTS ? PARTITION OF click_events FOR VALUES FROM (?) TO (?)",
+			bun.Ident(name), start, end)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "INSERT INTO click_events SELECT * FROM click_events_moving")
+		return err
+	})
+}
+
+// DropPartitionsBefore implements usecase.ClickMaintenanceRepository.
+// Only monthly partitions are dropped, never the default one.
+func (r *ClickMaintenancePGRepository) DropPartitionsBefore(ctx context.Context, cutoff time.Time) ([]string, error) {
+	existing, err := r.partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var dropped []string
+	for name, month := range existing {
+		if month.AddDate(0, 1, 0).After(cutoff) {
+			continue
+		}
+		if _, err := r.db.ExecContext(ctx, "DROP TABLE IF EXISTS ?", bun.Ident(name)); err != nil {
+			return dropped, fmt.Errorf("drop partition %s: %w", name, err)
+		}
+		dropped = append(dropped, name)
+	}
+	return dropped, nil
+}
+
+// partitions returns the monthly partitions of click_events with the
+// month each one holds.
+func (r *ClickMaintenancePGRepository) partitions(ctx context.Context) (map[string]time.Time, error) {
+	var names []string
+	err := r.db.NewRaw(
+		"SELECT c.relname FROM pg_inherits AS i JOIN pg_class AS c ON c.oid = i.inhrelid WHERE i.inhparent = 'click_events'::regclass",
+	).Scan(ctx, &names)
+	if err != nil {
+		return nil, err
+	}
+	months := make(map[string]time.Time, len(names))
+	for _, name := range names {
+		month, err := time.Parse("2006_01", strings.TrimPrefix(name, clickPartitionPrefix))
+		if err != nil {
+			continue
+		}
+		months[name] = month
+	}
+	return months, nil
+}
+
+// DeleteEventsBefore implements usecase.ClickMaintenanceRepository.
+func (r *ClickMaintenancePGRepository) DeleteEventsBefore(ctx context.Context, plans []string, excludePlans bool, before time.Time, limit int) (int64, error) {
+	users := r.db.NewSelect().
+		Model((*model.UserBunModel)(nil)).
+		Column("id").
+		WhereAllWithDeleted()
+	switch {
+	case excludePlans && len(plans) > 0:
+		users = users.Where("plan NOT IN (?)", bun.In(plans))
+	case !excludePlans:
+		users = users.Where("plan IN (?)", bun.In(plans))
+	}
+	batch := r.db.NewSelect().
+		Model((*model.ClickEventBunModel)(nil)).
+		Column("id", "clicked_at").
+		Where("clicked_at < ?", before).
+		Where("user_id IN (?)", users).
+		Limit(limit)
+	res, err := r.db.NewDelete().
+		Model((*model.ClickEventBunModel)(nil)).
+		Where("(id, clicked_at) IN (?)", batch).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+type linkClickSum struct {
+	LinkID    int64 `bun:"link_id"`
+	Clicks    int64 `bun:"clicks"`
+	BotClicks int64 `bun:"bot_clicks"`
+}
+
+// RebuildRollups implements usecase.ClickMaintenanceRepository. The day's
+// hourly and dimension rollups of the affected links are replaced by ones
+// computed from the raw events, and the links' counters move by the same
+// amount, all in one transaction. Visitor sketches cannot be rebuilt as
+// visitor fingerprints are never stored, so they are left alone.
+func (r *ClickMaintenancePGRepository) RebuildRollups(ctx context.Context, day time.Time, linkID *int64, onlyMissing bool) (*domain.RollupRepair, error) {
+	start := time.Date(day.UTC().Year(), day.UTC().Month(), day.UTC().Day(), 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 1)
+	repair := &domain.RollupRepair{Day: start}
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		events := tx.NewSelect().
+			Model((*model.ClickEventBunModel)(nil)).
+			Where("clicked_at >= ?", start).
+			Where("clicked_at < ?", end)
+		rollups := tx.NewSelect().
+			Model((*model.ClickRollupBunModel)(nil)).
+			Where("bucket >= ?", start).
+			Where("bucket < ?", end)
+		if linkID != nil {
+			events = events.Where("link_id = ?", *linkID)
+			rollups = rollups.Where("link_id = ?", *linkID)
+		}
+
+		var links []int64
+		var err error
+		if onlyMissing {
+			err = tx.NewRaw("? EXCEPT ?",
+				events.Clone().ColumnExpr("link_id").Distinct(),
+				rollups.Clone().ColumnExpr("link_id").Distinct(),
+			).Scan(ctx, &links)
+		} else {
+			err = tx.NewRaw("? UNION ?",
+				events.Clone().ColumnExpr("link_id"),
+				rollups.Clone().ColumnExpr("link_id"),
+			).Scan(ctx, &links)
+		}
+		if err != nil {
+			return err
+		}
+		if len(links) == 0 {
+			return nil
+		}
+		repair.Links = len(links)
+
+		sums := func() (map[int64]linkClickSum, error) {
+			var rows []linkClickSum
+			err := tx.NewSelect().
+				Model((*model.ClickRollupBunModel)(nil)).
+				ColumnExpr("link_id").
+				ColumnExpr("SUM(clicks) AS clicks").
+				ColumnExpr("SUM(bot_clicks) AS bot_clicks").
+				Where("bucket >= ?", start).
+				Where("bucket < ?", end).
+				Where("link_id IN (?)", bun.In(links)).
+				GroupExpr("link_id").
+				Scan(ctx, &rows)
+			byLink := make(map[int64]linkClickSum, len(rows))
+			for _, row := range rows {
+				byLink[row.LinkID] = row
+			}
+			return byLink, err
+		}
+		before, err := sums()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewDelete().
+			Model((*model.ClickRollupBunModel)(nil)).
+			Where("bucket >= ?", start).
+			Where("bucket < ?", end).
+			Where("link_id IN (?)", bun.In(links)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = tx.NewDelete().
+			Model((*model.ClickDimensionRollupBunModel)(nil)).
+			Where("day = ?", start.Format(time.DateOnly)).
+			Where("link_id IN (?)", bun.In(links)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.NewRaw(`
+			INSERT INTO click_rollups_hourly (link_id, user_id, bucket, clicks, bot_clicks)
+			SELECT link_id, user_id, date_trunc('hour', clicked_at, 'UTC'),
+				COUNT(*) FILTER (WHERE NOT is_bot), COUNT(*) FILTER (WHERE is_bot)
+			FROM click_events
+			WHERE clicked_at >= ? AND clicked_at < ? AND link_id IN (?)
+			GROUP BY 1, 2, 3`,
+			start, end, bun.In(links),
+		).Exec(ctx)
+		if err != nil {
+			return err
+		}
+		repair.HourlyRows, _ = res.RowsAffected()
+
+		// Mirrors domain.ClickEvent.DimensionValues.
+		res, err = tx.NewRaw(`
+			INSERT INTO click_dimension_rollups_daily (link_id, user_id, day, dimension, value, clicks, bot_clicks)
+			SELECT e.link_id, e.user_id, ?::date, d.dimension, d.value,
+				COUNT(*) FILTER (WHERE NOT e.is_bot), COUNT(*) FILTER (WHERE e.is_bot)
+			FROM click_events e
+			CROSS JOIN LATERAL (VALUES
+				(?, COALESCE(NULLIF(e.referrer_domain, ''), CASE WHEN e.opt_out THEN ? ELSE ? END)),
+				(?, COALESCE(NULLIF(e.browser, ''), ?)),
+				(?, COALESCE(NULLIF(e.os, ''), ?)),
+				(?, COALESCE(NULLIF(e.device, ''), ?)),
+				(?, COALESCE(NULLIF(e.country, ''), ?)),
+				(?, COALESCE(NULLIF(e.primary_language, ''), ?))
+			) AS d (dimension, value)
+			WHERE e.clicked_at >= ? AND e.clicked_at < ? AND e.link_id IN (?)
+			GROUP BY 1, 2, 4, 5`,
+			start.Format(time.DateOnly),
+			domain.DimensionReferrer, domain.DimensionValueUnknown, domain.DimensionValueDirect,
+			domain.DimensionBrowser, domain.DimensionValueUnknown,
+			domain.DimensionOS, domain.DimensionValueUnknown,
+			domain.DimensionDevice, domain.DimensionValueUnknown,
+			domain.DimensionCountry, domain.DimensionValueUnknown,
+			domain.DimensionLanguage, domain.DimensionValueUnknown,
+			start, end, bun.In(links),
+		).Exec(ctx)
+		if err != nil {
+			return err
+		}
+		repair.DimensionRows, _ = res.RowsAffected()
+
+		after, err := sums()
+		if err != nil {
+			return err
+		}
+		for _, id := range links {
+			clicks := after[id].Clicks - before[id].Clicks
+			bots := after[id].BotClicks - before[id].BotClicks
+			if clicks == 0 && bots == 0 {
+				continue
+			}
+			_, err := tx.NewUpdate().
+				Model((*model.LinkBunModel)(nil)).
+				Set("click_count = click_count + ?", clicks).
+				Set("bot_click_count = bot_click_count + ?", bots).
+				Where("id = ?", id).
+				WhereAllWithDeleted().
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+			repair.ClickDelta += clicks
+			repair.BotClickDelta += bots
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return repair, nil
+}
+
+// CounterDrift implements usecase.ClickMaintenanceRepository.
+func (r *ClickMaintenancePGRepository) CounterDrift(ctx context.Context, linkID *int64, limit int) ([]domain.CounterDrift, error) {
+	q := r.db.NewSelect().
+		Model((*model.LinkBunModel)(nil)).
+		ColumnExpr("link_bun_model.id AS link_id").
+		ColumnExpr("link_bun_model.short_code").
+		ColumnExpr("link_bun_model.click_count").
+		ColumnExpr("link_bun_model.bot_click_count").
+		ColumnExpr("COALESCE(s.clicks, 0) AS rollup_clicks").
+		ColumnExpr("COALESCE(s.bot_clicks, 0) AS rollup_bot_clicks").
+		Join(`LEFT JOIN (
+			SELECT link_id, SUM(clicks) AS clicks, SUM(bot_clicks) AS bot_clicks
+			FROM click_rollups_hourly GROUP BY link_id
+		) AS s ON s.link_id = link_bun_model.id`).
+		Where("link_bun_model.click_count <> COALESCE(s.clicks, 0) OR link_bun_model.bot_click_count <> COALESCE(s.bot_clicks, 0)").
+		WhereAllWithDeleted().
+		OrderExpr("link_bun_model.id").
+		Limit(limit)
+	if linkID != nil {
+		q = q.Where("link_bun_model.id = ?", *linkID)
+	}
+	drift := []domain.CounterDrift{}
+	if err := q.Scan(ctx, &drift); err != nil {
+		return nil, err
+	}
+	return drift, nil
+}
+
+// ResetCounters implements usecase.ClickMaintenanceRepository.
+func (r *ClickMaintenancePGRepository) ResetCounters(ctx context.Context, linkIDs []int64) (int64, error) {
+	if len(linkIDs) == 0 {
+		return 0, nil
+	}
+	res, err := r.db.NewUpdate().
+		Model((*model.LinkBunModel)(nil)).
+		Set("click_count = COALESCE((SELECT SUM(clicks) FROM click_rollups_hourly AS h WHERE h.link_id = link_bun_model.id), 0)").
+		Set("bot_click_count = COALESCE((SELECT SUM(bot_clicks) FROM click_rollups_hourly AS h WHERE h.link_id = link_bun_model.id), 0)").
+		Where("id IN (?)", bun.In(linkIDs)).
+		WhereAllWithDeleted().
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,235 @@
+package repo
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/hll"
+	"url-shortener/internal/repo/model"
+
+	"github.com/uptrace/bun"
+)
+
+// clickRollups is a batch of click events aggregated into the increments
+// of the link counters and the rollup tables.
+type clickRollups struct {
+	links      []linkClicks
+	hourly     []*model.ClickRollupBunModel
+	dimensions []*model.ClickDimensionRollupBunModel
+	visitors   []visitorSketch
+}
+
+type visitorSketch struct {
+	linkID int64
+	userID int64
+	day    time.Time
+	sketch *hll.Sketch
+}
+
+type linkClicks struct {
+	linkID int64
+	count  int
+	bots   int
+	last   time.Time
+}
+
+// timeKey keys per-link rows by time: the hour for hourly rollups, the
+// day for visitor sketches.
+type timeKey struct {
+	linkID int64
+	bucket time.Time
+}
+
+type dimensionKey struct {
+	linkID    int64
+	day       time.Time
+	dimension string
+	value     string
+}
+
+// countClick adds e to the human or bot counter.
+func countClick(e *domain.ClickEvent, clicks, bots int64) (int64, int64) {
+	if e.IsBot {
+		return clicks, bots + 1
+	}
+	return clicks + 1, bots
+}
+
+func buildClickRollups(events []*domain.ClickEvent) *clickRollups {
+	links := map[int64]*linkClicks{}
+	hourly := map[timeKey]*model.ClickRollupBunModel{}
+	dimensions := map[dimensionKey]*model.ClickDimensionRollupBunModel{}
+	visitors := map[timeKey]*visitorSketch{}
+
+	for _, e := range events {
+		lc := links[e.LinkID]
+		if lc == nil {
+			lc = &linkClicks{linkID: e.LinkID}
+			links[e.LinkID] = lc
+		}
+		if e.IsBot {
+			lc.bots++
+		} else {
+			lc.count++
+		}
+		if e.ClickedAt.After(lc.last) {
+			lc.last = e.ClickedAt
+		}
+
+		at := e.ClickedAt.UTC()
+		hk := timeKey{linkID: e.LinkID, bucket: at.Truncate(time.Hour)}
+		h := hourly[hk]
+		if h == nil {
+			h = &model.ClickRollupBunModel{LinkID: e.LinkID, UserID: e.UserID, Bucket: hk.bucket}
+			hourly[hk] = h
+		}
+		h.Clicks, h.BotClicks = countClick(e, h.Clicks, h.BotClicks)
+
+		day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+		if e.VisitorHash != 0 {
+			vk := timeKey{linkID: e.LinkID, bucket: day}
+			v := visitors[vk]
+			if v == nil {
+				v = &visitorSketch{linkID: e.LinkID, userID: e.UserID, day: day, sketch: hll.NewDefault()}
+				visitors[vk] = v
+			}
+			v.sketch.Add(e.VisitorHash)
+		}
+		for dimension, value := range e.DimensionValues() {
+			dk := dimensionKey{linkID: e.LinkID, day: day, dimension: dimension, value: value}
+			d := dimensions[dk]
+			if d == nil {
+				d = &model.ClickDimensionRollupBunModel{LinkID: e.LinkID, UserID: e.UserID, Day: day, Dimension: dimension, Value: value}
+				dimensions[dk] = d
+			}
+			d.Clicks, d.BotClicks = countClick(e, d.Clicks, d.BotClicks)
+		}
+	}
+
+	// Rows are written in primary key order so concurrent batches take
+	// their locks in the same order and cannot deadlock.
+	r := &clickRollups{}
+	for _, lc := range links {
+		r.links = append(r.links, *lc)
+	}
+	slices.SortFunc(r.links, func(a, b linkClicks) int { return cmp.Compare(a.linkID, b.linkID) })
+	for _, h := range hourly {
+		r.hourly = append(r.hourly, h)
+	}
+	slices.SortFunc(r.hourly, func(a, b *model.ClickRollupBunModel) int {
+		return cmp.Or(cmp.Compare(a.LinkID, b.LinkID), a.Bucket.Compare(b.Bucket))
+	})
+	for _, d := range dimensions {
+		r.dimensions = append(r.dimensions, d)
+	}
+	slices.SortFunc(r.dimensions, func(a, b *model.ClickDimensionRollupBunModel) int {
+		return cmp.Or(
+			cmp.Compare(a.LinkID, b.LinkID),
+			cmp.Compare(a.Dimension, b.Dimension),
+			a.Day.Compare(b.Day),
+			cmp.Compare(a.Value, b.Value),
+		)
+	})
+	for _, v := range visitors {
+		r.visitors = append(r.visitors, *v)
+	}
+	slices.SortFunc(r.visitors, func(a, b visitorSketch) int {
+		return cmp.Or(cmp.Compare(a.linkID, b.linkID), a.day.Compare(b.day))
+	})
+	return r
+}
+
+func (r *clickRollups) write(ctx context.Context, tx bun.Tx) error {
+	for _, lc := range r.links {
+		_, err := tx.NewUpdate().
+			Model((*model.LinkBunModel)(nil)).
+			Set("click_count = click_count + ?", lc.count).
+			Set("bot_click_count = bot_click_count + ?", lc.bots).
+			Set("last_clicked_at = GREATEST(last_clicked_at, ?)", lc.last).
+			Where("id = ?", lc.linkID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := tx.NewInsert().
+		Model(&r.hourly).
+		On("CONFLICT (link_id, bucket) DO UPDATE").
+		Set("clicks = ?TableAlias.clicks + EXCLUDED.clicks").
+		Set("bot_clicks = ?TableAlias.bot_clicks + EXCLUDED.bot_clicks").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = tx.NewInsert().
+		Model(&r.dimensions).
+		On("CONFLICT (link_id, dimension, day, value) DO UPDATE").
+		Set("clicks = ?TableAlias.clicks + EXCLUDED.clicks").
+		Set("bot_clicks = ?TableAlias.bot_clicks + EXCLUDED.bot_clicks").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return r.writeVisitors(ctx, tx)
+}
+
+// writeVisitors merges the batch's sketches into the stored ones. Postgres
+// cannot merge sketches itself, so each row is created if missing, locked,
+// merged in Go and written back.
+func (r *clickRollups) writeVisitors(ctx context.Context, tx bun.Tx) error {
+	if len(r.visitors) == 0 {
+		return nil
+	}
+	placeholders := make([]*model.ClickVisitorSketchBunModel, 0, len(r.visitors))
+	for _, v := range r.visitors {
+		placeholders = append(placeholders, &model.ClickVisitorSketchBunModel{LinkID: v.linkID, UserID: v.userID, Day: v.day})
+	}
+	_, err := tx.NewInsert().
+		Model(&placeholders).
+		On("CONFLICT (link_id, day) DO NOTHING").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range r.visitors {
+		stored := new(model.ClickVisitorSketchBunModel)
+		err := tx.NewSelect().
+			Model(stored).
+			Where("link_id = ?", v.linkID).
+			Where("day = ?", v.day.Format(time.DateOnly)).
+			For("UPDATE").
+			Scan(ctx)
+		if err != nil {
+			return err
+		}
+		merged := v.sketch
+		if len(stored.Sketch) > 0 {
+			existing, err := hll.Decode(stored.Sketch)
+			if err != nil {
+				return fmt.Errorf("visitor sketch of link %d on %s: %w", v.linkID, v.day.Format(time.DateOnly), err)
+			}
+			if err := existing.Merge(v.sketch); err != nil {
+				return err
+			}
+			merged = existing
+		}
+		encoded, err := merged.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		_, err = tx.NewUpdate().
+			Model((*model.ClickVisitorSketchBunModel)(nil)).
+			Set("sketch = ?", encoded).
+			Where("link_id = ?", v.linkID).
+			Where("day = ?", v.day.Format(time.DateOnly)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package repo
+
+import (
+	"context"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repo/model"
+	"url-shortener/internal/usecase"
+
+	"github.com/uptrace/bun"
+)
+
+type ClickStatsPGRepository struct {
+	db *bun.DB
+}
+
+func NewClickStatsPGRepository(db *bun.DB) usecase.ClickStatsRepository {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	return &ClickStatsPGRepository{db: db}
+}
+
+// ClickSeries implements usecase.ClickStatsRepository. Hourly rollups are
+// truncated to the interval in the query's time zone, so a local day or
+// week starts at local midnight. Empty buckets are not returned.
+func (r *ClickStatsPGRepository) ClickSeries(ctx context.Context, query domain.ClickSeriesQuery) ([]domain.TimeBucket, error) {
+	tz := query.Location.String()
+	q := r.db.NewSelect().
+		Model((*model.ClickRollupBunModel)(nil)).
+		ColumnExpr("date_trunc(?, bucket AT TIME ZONE ?) AT TIME ZONE ? AS start", query.Interval, tz, tz).
+		ColumnExpr("SUM(?) AS clicks", clicksExpr(query.IncludeBots)).
+		Where("user_id = ?", query.UserID).
+		Where("bucket >= ?", query.From).
+		Where("bucket < ?", query.To).
+		GroupExpr("1").
+		OrderExpr("1")
+	if query.LinkID != nil {
+		q = q.Where("link_id = ?", *query.LinkID)
+	}
+
+	buckets := []domain.TimeBucket{}
+	if err := q.Scan(ctx, &buckets); err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+// Breakdown implements usecase.ClickStatsRepository.
+func (r *ClickStatsPGRepository) Breakdown(ctx context.Context, query domain.BreakdownQuery) ([]domain.DimensionCount, int64, error) {
+	base := func() *bun.SelectQuery {
+		q := r.db.NewSelect().
+			Model((*model.ClickDimensionRollupBunModel)(nil)).
+			Where("user_id = ?", query.UserID).
+			Where("dimension = ?", query.Dimension).
+			Where("day >= ?", query.From.Format(time.DateOnly)).
+			// To is exclusive; a range ending at midnight excludes that day.
+			Where("day <= ?", query.To.Add(-time.Nanosecond).Format(time.DateOnly))
+		if query.LinkID != nil {
+			q = q.Where("link_id = ?", *query.LinkID)
+		}
+		return q
+	}
+
+	var total int64
+	clicks := clicksExpr(query.IncludeBots)
+	if err := base().ColumnExpr("COALESCE(SUM(?), 0)", clicks).Scan(ctx, &total); err != nil {
+		return nil, 0, err
+	}
+	items := []domain.DimensionCount{}
+	err := base().
+		ColumnExpr("value").
+		ColumnExpr("SUM(?) AS clicks", clicks).
+		GroupExpr("value").
+		Having("SUM(?) > 0", clicks).
+		OrderExpr("clicks DESC, value").
+		Limit(query.Limit).
+		Scan(ctx, &items)
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// VisitorSketches implements usecase.ClickStatsRepository.
+func (r *ClickStatsPGRepository) VisitorSketches(ctx context.Context, userID int64, linkID *int64, fromDay, toDay time.Time) ([]domain.DailySketch, error) {
+	q := r.db.NewSelect().
+		Model((*model.ClickVisitorSketchBunModel)(nil)).
+		Column("link_id", "day", "sketch").
+		Where("user_id = ?", userID).
+		Where("day >= ?", fromDay.Format(time.DateOnly)).
+		Where("day <= ?", toDay.Format(time.DateOnly)).
+		Where("sketch IS NOT NULL").
+		Order("day")
+	if linkID != nil {
+		q = q.Where("link_id = ?", *linkID)
+	}
+	sketches := []domain.DailySketch{}
+	if err := q.Scan(ctx, &sketches); err != nil {
+		return nil, err
+	}
+	return sketches, nil
+}
+
+// clicksExpr sums a rollup row's clicks, with or without its bot clicks.
+func clicksExpr(includeBots bool) bun.Safe {
+	if includeBots {
+		return bun.Safe("clicks + bot_clicks")
+	}
+	return bun.Safe("clicks")
+}
@@ -0,0 +1,124 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/usecase"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+var errUnexpectedQuery = errors.New("unexpected query")
+
+// insertDB answers every INSERT ... RETURNING with the row Postgres would
+// generate; anything else fails.
+type insertDB struct {
+	id        int64
+	createdAt time.Time
+}
+
+func (d *insertDB) Connect(ctx context.Context) (driver.Conn, error) { return d, nil }
+func (d *insertDB) Driver() driver.Driver                            { return nil }
+func (d *insertDB) Prepare(query string) (driver.Stmt, error)        { return nil, errUnexpectedQuery }
+func (d *insertDB) Close() error                                     { return nil }
+func (d *insertDB) Begin() (driver.Tx, error)                        { return nil, errUnexpectedQuery }
+
+func (d *insertDB) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if !strings.HasPrefix(query, "INSERT") || !strings.HasSuffix(query, "RETURNING id, created_at") {
+		return nil, errUnexpectedQuery
+	}
+	return &insertRows{values: []driver.Value{d.id, d.createdAt}}, nil
+}
+
+type insertRows struct {
+	values []driver.Value
+	done   bool
+}
+
+func (r *insertRows) Columns() []string { return []string{"id", "created_at"} }
+func (r *insertRows) Close() error      { return nil }
+
+func (r *insertRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.values)
+	return nil
+}
+
+// recordingAuditRepo keeps appended events in memory.
+type recordingAuditRepo struct {
+	usecase.AuditRepository
+	events []*domain.AuditEvent
+}
+
+func (r *recordingAuditRepo) Append(ctx context.Context, event *domain.AuditEvent, hash func(prevHash string) string) error {
+	event.Hash = hash("")
+	r.events = append(r.events, event)
+	return nil
+}
+
+// nopBlocklist satisfies AdminService; user creation never touches it.
+type nopBlocklist struct {
+	usecase.BlocklistStore
+}
+
+func newInsertDB(t *testing.T, id int64, createdAt time.Time) *bun.DB {
+	t.Helper()
+	db := bun.NewDB(sql.OpenDB(&insertDB{id: id, createdAt: createdAt}), pgdialect.New())
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestLinkCreateReturnsGeneratedColumns(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := NewLinkPGRepository(newInsertDB(t, 42, createdAt))
+
+	link := &domain.Link{ShortCode: "abc123", LongURL: "https://example.com/", UserID: 1}
+	if err := repo.Create(context.Background(), link); err != nil {
+		t.Fatal(err)
+	}
+	if link.ID != 42 || !link.CreatedAt.Equal(createdAt) {
+		t.Errorf("link id, created_at = %d, %v; want 42, %v", link.ID, link.CreatedAt, createdAt)
+	}
+}
+
+func TestCreateUserAuditsGeneratedID(t *testing.T) {
+	db := newInsertDB(t, 7, time.Now())
+	users := NewUserPGRepository(db)
+	links := NewLinkPGRepository(db)
+	audits := &recordingAuditRepo{}
+	audit := usecase.NewAuditService(audits)
+	admin := usecase.NewAdminService(
+		users,
+		nopBlocklist{},
+		usecase.NewClickRecorder(links, usecase.NewLiveClickHub(links, nil)),
+		audit,
+		usecase.NewAPIKeyService(users, audit),
+	)
+
+	user, err := admin.CreateUser(context.Background(), "a@example.com", "free", "user", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != 7 {
+		t.Errorf("user id = %d, want 7", user.ID)
+	}
+	if len(audits.events) != 1 {
+		t.Fatalf("recorded %d audit events, want 1", len(audits.events))
+	}
+	event := audits.events[0]
+	if event.Action != domain.AuditUserCreate || event.TargetID != strconv.FormatInt(user.ID, 10) {
+		t.Errorf("audit event = %s %s, want %s 7", event.Action, event.TargetID, domain.AuditUserCreate)
+	}
+}
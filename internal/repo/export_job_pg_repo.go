@@ -0,0 +1,140 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repo/model"
+	"url-shortener/internal/usecase"
+
+	"github.com/uptrace/bun"
+)
+
+type ExportJobPGRepository struct {
+	db *bun.DB
+}
+
+func NewExportJobPGRepository(db *bun.DB) usecase.ExportJobRepository {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	return &ExportJobPGRepository{db: db}
+}
+
+// Create implements usecase.ExportJobRepository.
+func (r *ExportJobPGRepository) Create(ctx context.Context, job *domain.ExportJob) error {
+	jobModel := model.ToExportJobBunModel(job)
+	_, err := r.db.NewInsert().
+		Model(jobModel).
+		ExcludeColumn("id").
+		Returning("id, created_at").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	job.ID = jobModel.ID
+	job.CreatedAt = jobModel.CreatedAt
+	return nil
+}
+
+// FindByToken implements usecase.ExportJobRepository.
+func (r *ExportJobPGRepository) FindByToken(ctx context.Context, token string) (*domain.ExportJob, error) {
+	jobModel := new(model.ExportJobBunModel)
+	err := r.db.NewSelect().Model(jobModel).Where("token = ?", token).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return jobModel.ToDomain(), nil
+}
+
+// ListRunnable implements usecase.ExportJobRepository.
+func (r *ExportJobPGRepository) ListRunnable(ctx context.Context, staleBefore time.Time, limit int) ([]*domain.ExportJob, error) {
+	jobModels := []*model.ExportJobBunModel{}
+	err := r.db.NewSelect().
+		Model(&jobModels).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("status = ?", domain.ExportJobPending).
+				WhereOr("status = ? AND started_at < ?", domain.ExportJobRunning, staleBefore)
+		}).
+		Order("id ASC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*domain.ExportJob, 0, len(jobModels))
+	for _, m := range jobModels {
+		jobs = append(jobs, m.ToDomain())
+	}
+	return jobs, nil
+}
+
+// Claim implements usecase.ExportJobRepository. The conditional update
+// makes sure only one worker runs a job.
+func (r *ExportJobPGRepository) Claim(ctx context.Context, id int64, staleBefore time.Time) (bool, error) {
+	res, err := r.db.NewUpdate().
+		Model((*model.ExportJobBunModel)(nil)).
+		Set("status = ?", domain.ExportJobRunning).
+		Set("started_at = NOW()").
+		Where("id = ?", id).
+		WhereGroup(" AND ", func(q *bun.UpdateQuery) *bun.UpdateQuery {
+			return q.Where("status = ?", domain.ExportJobPending).
+				WhereOr("status = ? AND started_at < ?", domain.ExportJobRunning, staleBefore)
+		}).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// Finish implements usecase.ExportJobRepository.
+func (r *ExportJobPGRepository) Finish(ctx context.Context, job *domain.ExportJob) error {
+	_, err := r.db.NewUpdate().
+		Model((*model.ExportJobBunModel)(nil)).
+		Set("status = ?", job.Status).
+		Set("rows = ?", job.Rows).
+		Set("size_bytes = ?", job.SizeBytes).
+		Set("error = NULLIF(?, '')", job.Error).
+		Set("finished_at = ?", job.FinishedAt).
+		Set("expires_at = ?", job.ExpiresAt).
+		Where("id = ?", job.ID).
+		Exec(ctx)
+	return err
+}
+
+// Release implements usecase.ExportJobRepository.
+func (r *ExportJobPGRepository) Release(ctx context.Context, id int64) error {
+	_, err := r.db.NewUpdate().
+		Model((*model.ExportJobBunModel)(nil)).
+		Set("status = ?", domain.ExportJobPending).
+		Set("started_at = NULL").
+		Where("id = ?", id).
+		Where("status = ?", domain.ExportJobRunning).
+		Exec(ctx)
+	return err
+}
+
+// DeleteExpired implements usecase.ExportJobRepository.
+func (r *ExportJobPGRepository) DeleteExpired(ctx context.Context, now time.Time) ([]*domain.ExportJob, error) {
+	jobModels := []*model.ExportJobBunModel{}
+	_, err := r.db.NewDelete().
+		Model(&jobModels).
+		Where("expires_at <= ?", now).
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*domain.ExportJob, 0, len(jobModels))
+	for _, m := range jobModels {
+		jobs = append(jobs, m.ToDomain())
+	}
+	return jobs, nil
+}
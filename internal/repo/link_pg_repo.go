@@ -9,6 +9,7 @@ import (
 	"url-shortener/internal/usecase"
 
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
 )
 
 type LinkPGRepository struct {
@@ -25,8 +26,17 @@ func NewLinkPGRepository(db *bun.DB) usecase.LinkRepository {
 // Create implements usecase.LinkRepository.
 func (r *LinkPGRepository) Create(ctx context.Context, link *domain.Link) error {
 	linkModel := model.ToLinkBunModel(link)
-	_, err := r.db.NewInsert().Model(linkModel).ExcludeColumn("id").Exec(ctx)
-	return err
+	_, err := r.db.NewInsert().
+		Model(linkModel).
+		ExcludeColumn("id").
+		Returning("id, created_at").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	link.ID = linkModel.ID
+	link.CreatedAt = linkModel.CreatedAt
+	return nil
 }
 
 // FindByShortCode implements usecase.LinkRepository.
@@ -45,15 +55,20 @@ func (r *LinkPGRepository) FindByShortCode(ctx context.Context, shortCode string
 }
 
 // ListByUser implements usecase.LinkRepository.
-func (r *LinkPGRepository) ListByUser(ctx context.Context, userID int64) ([]*domain.Link, error) {
+func (r *LinkPGRepository) ListByUser(ctx context.Context, userID int64, filter domain.LinkFilter) ([]*domain.Link, error) {
 	linkModels := []*model.LinkBunModel{}
 
-	err := r.db.NewSelect().
+	q := r.db.NewSelect().
 		Model(&linkModels).
 		Where("user_id = ?", userID).
-		Where("deleted_at is NULL").
-		Order("created_at DESC").
-		Scan(ctx)
+		Where("deleted_at is NULL")
+	if filter.HealthStatus != "" {
+		q = q.Where("health_status = ?", filter.HealthStatus)
+	}
+	if filter.CampaignID != nil {
+		q = q.Where("campaign_id = ?", *filter.CampaignID)
+	}
+	err := q.Order("created_at DESC").Scan(ctx)
 
 	if err != nil {
 		return nil, err
@@ -67,28 +82,123 @@ func (r *LinkPGRepository) ListByUser(ctx context.Context, userID int64) ([]*dom
 	return links, nil
 }
 
-// SoftDeleteByShortCode implements usecase.LinkRepository.
-func (r *LinkPGRepository) SoftDeleteByShortCode(ctx context.Context, userID int64, shortCode string) error {
-	_, err := r.db.NewDelete().
+// ListActive implements usecase.LinkRepository.
+func (r *LinkPGRepository) ListActive(ctx context.Context, afterID int64, limit int) ([]*domain.Link, error) {
+	linkModels := []*model.LinkBunModel{}
+
+	err := r.db.NewSelect().
+		Model(&linkModels).
+		Where("id > ?", afterID).
+		Where("deleted_at IS NULL").
+		Order("id ASC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]*domain.Link, 0, len(linkModels))
+	for _, lm := range linkModels {
+		links = append(links, lm.ToDomain())
+	}
+	return links, nil
+}
+
+// UpdateHealth implements usecase.LinkRepository.
+func (r *LinkPGRepository) UpdateHealth(ctx context.Context, check *domain.LinkHealthCheck) error {
+	_, err := r.db.NewUpdate().
 		Model((*model.LinkBunModel)(nil)).
+		Set("health_status = ?", check.Status).
+		Set("health_status_code = ?", check.StatusCode).
+		Set("health_latency_ms = ?", check.Latency.Milliseconds()).
+		Set("health_redirect_chain = ?", pgdialect.Array(check.RedirectChain)).
+		Set("health_error = NULLIF(?, '')", check.Error).
+		Set("health_checked_at = ?", check.CheckedAt).
+		Set("health_changed_at = CASE WHEN health_status = ? THEN health_changed_at ELSE ? END", check.Status, check.CheckedAt).
+		Where("id = ?", check.LinkID).
+		Exec(ctx)
+	return err
+}
+
+// UpdateLongURL implements usecase.LinkRepository. Changing the destination
+// clears any flag and resets health, both of which described the old URL.
+func (r *LinkPGRepository) UpdateLongURL(ctx context.Context, userID int64, shortCode, longURL string) (*domain.Link, error) {
+	linkModel := new(model.LinkBunModel)
+	res, err := r.db.NewUpdate().
+		Model(linkModel).
+		Set("long_url = ?", longURL).
+		Set("flagged_at = NULL").
+		Set("flag_reason = NULL").
+		Set("health_status = ?", domain.LinkHealthUnknown).
+		Set("health_status_code = 0").
+		Set("health_latency_ms = 0").
+		Set("health_redirect_chain = NULL").
+		Set("health_error = NULL").
+		Set("health_checked_at = NULL").
+		Set("health_changed_at = NULL").
 		Where("user_id = ?", userID).
 		Where("short_code = ?", shortCode).
+		Where("deleted_at IS NULL").
+		Returning("*").
 		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, nil
+	}
+	return linkModel.ToDomain(), nil
+}
+
+// SetFlag implements usecase.LinkRepository. A nil reason clears the flag.
+func (r *LinkPGRepository) SetFlag(ctx context.Context, linkID int64, reason *string) error {
+	q := r.db.NewUpdate().
+		Model((*model.LinkBunModel)(nil)).
+		Where("id = ?", linkID)
+	if reason == nil {
+		q = q.Set("flagged_at = NULL").Set("flag_reason = NULL")
+	} else {
+		q = q.Set("flagged_at = NOW()").Set("flag_reason = ?", *reason)
+	}
+	_, err := q.Exec(ctx)
 	return err
 }
 
-// TrackClick implements usecase.LinkRepository.
-func (r *LinkPGRepository) TrackClick(ctx context.Context, shortCode string) error {
-	_, err := r.db.NewUpdate().
+// SoftDeleteByShortCode implements usecase.LinkRepository.
+func (r *LinkPGRepository) SoftDeleteByShortCode(ctx context.Context, userID int64, shortCode string) error {
+	_, err := r.db.NewDelete().
 		Model((*model.LinkBunModel)(nil)).
-		Set("click_count = click_count + 1").
-		Set("last_clicked_at = NOW()").
+		Where("user_id = ?", userID).
 		Where("short_code = ?", shortCode).
-		Where("deleted_at IS NULL").
 		Exec(ctx)
 	return err
 }
 
+// TrackClicks implements usecase.LinkRepository. Link counters, rollups
+// and the events themselves are written in one transaction so they never
+// drift apart.
+func (r *LinkPGRepository) TrackClicks(ctx context.Context, events []*domain.ClickEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	rollups := buildClickRollups(events)
+	eventModels := make([]*model.ClickEventBunModel, 0, len(events))
+	for _, e := range events {
+		eventModels = append(eventModels, model.ToClickEventBunModel(e))
+	}
+
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := rollups.write(ctx, tx); err != nil {
+			return err
+		}
+		_, err := tx.NewInsert().
+			Model(&eventModels).
+			ExcludeColumn("id").
+			Exec(ctx)
+		return err
+	})
+}
+
 func (r *LinkPGRepository) FindLinkCountByUserIDAndLongURL(ctx context.Context, userID int64, longURL string) (int, error) {
 	count, err := r.db.NewSelect().
 		Model((*model.LinkBunModel)(nil)).
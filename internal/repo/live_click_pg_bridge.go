@@ -0,0 +1,104 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/usecase"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+const (
+	liveClickChannel = "live_clicks"
+	// maxNotifyPayload stays under the 8000 byte NOTIFY payload limit.
+	maxNotifyPayload = 7900
+)
+
+// LiveClickPGBridge carries live clicks between app instances with
+// Postgres LISTEN/NOTIFY. Notifications are not stored, so instances only
+// see clicks published while they listen.
+type LiveClickPGBridge struct {
+	db *bun.DB
+}
+
+func NewLiveClickPGBridge(db *bun.DB) usecase.LiveClickBridge {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	return &LiveClickPGBridge{db: db}
+}
+
+type liveClickPayload struct {
+	LinkID    int64     `json:"l"`
+	UserID    int64     `json:"u"`
+	ShortCode string    `json:"s"`
+	ClickedAt time.Time `json:"t"`
+	Country   string    `json:"c,omitempty"`
+	Device    string    `json:"d,omitempty"`
+	Referrer  string    `json:"r,omitempty"`
+	IsBot     bool      `json:"b,omitempty"`
+}
+
+// Publish implements usecase.LiveClickBridge. Clicks are packed into as
+// few notifications as the payload limit allows, sent in one statement.
+func (b *LiveClickPGBridge) Publish(ctx context.Context, clicks []domain.LiveClick) error {
+	var payloads []string
+	batch := []byte{'['}
+	for _, c := range clicks {
+		item, err := json.Marshal(liveClickPayload(c))
+		if err != nil {
+			return err
+		}
+		if len(batch) > 1 && len(batch)+len(item)+1 > maxNotifyPayload {
+			payloads = append(payloads, string(append(batch, ']')))
+			batch = []byte{'['}
+		}
+		if len(batch) > 1 {
+			batch = append(batch, ',')
+		}
+		batch = append(batch, item...)
+	}
+	if len(batch) > 1 {
+		payloads = append(payloads, string(append(batch, ']')))
+	}
+	if len(payloads) == 0 {
+		return nil
+	}
+	_, err := b.db.NewRaw(
+		"SELECT pg_notify(?, payload) FROM unnest(?::text[]) AS payload",
+		liveClickChannel, pgdialect.Array(payloads),
+	).Exec(ctx)
+	return err
+}
+
+// Listen implements usecase.LiveClickBridge. The listener holds its own
+// connection and reconnects by itself; Listen returns when ctx is done.
+func (b *LiveClickPGBridge) Listen(ctx context.Context, fn func(domain.LiveClick)) error {
+	ln := pgdriver.NewListener(b.db)
+	if err := ln.Listen(ctx, liveClickChannel); err != nil {
+		ln.Close()
+		return err
+	}
+	notifications := ln.CreateChannel()
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	for n := range notifications {
+		var batch []liveClickPayload
+		if err := json.Unmarshal([]byte(n.Payload), &batch); err != nil {
+			log.Printf("Ignoring malformed live click notification: %v", err)
+			continue
+		}
+		for _, c := range batch {
+			fn(domain.LiveClick(c))
+		}
+	}
+	return ctx.Err()
+}
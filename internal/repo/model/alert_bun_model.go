@@ -0,0 +1,121 @@
+package model
+
+import (
+	"time"
+	"url-shortener/internal/domain"
+
+	"github.com/jinzhu/copier"
+	"github.com/uptrace/bun"
+)
+
+type AlertRuleBunModel struct {
+	bun.BaseModel   `bun:"table:alert_rules"`
+	ID              int64      `bun:"id,pk,autoincrement"`
+	UserID          int64      `bun:"user_id,notnull"`
+	LinkID          *int64     `bun:"link_id,nullzero"`
+	Name            string     `bun:"name,notnull"`
+	Kind            string     `bun:"kind,notnull"`
+	Threshold       int64      `bun:"threshold,notnull"`
+	Factor          float64    `bun:"factor,notnull"`
+	WindowHours     int        `bun:"window_hours,notnull"`
+	MinClicks       int64      `bun:"min_clicks,notnull"`
+	IncludeBots     bool       `bun:"include_bots,notnull"`
+	Channel         string     `bun:"channel,notnull"`
+	Target          string     `bun:"target,notnull"`
+	CooldownSeconds int64      `bun:"cooldown_seconds,notnull"`
+	Enabled         bool       `bun:"enabled,notnull"`
+	LastTriggeredAt *time.Time `bun:"last_triggered_at,nullzero"`
+	DeletedAt       *time.Time `bun:"deleted_at,nullzero,soft_delete"`
+	CreatedAt       time.Time  `bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt       *time.Time `bun:"updated_at,nullzero"`
+}
+
+// The cooldown is stored in seconds, so the mapping is spelled out instead
+// of going through copier.
+func (m *AlertRuleBunModel) ToDomain() *domain.AlertRule {
+	if m == nil {
+		return nil
+	}
+	return &domain.AlertRule{
+		ID:              m.ID,
+		UserID:          m.UserID,
+		LinkID:          m.LinkID,
+		Name:            m.Name,
+		Kind:            m.Kind,
+		Threshold:       m.Threshold,
+		Factor:          m.Factor,
+		WindowHours:     m.WindowHours,
+		MinClicks:       m.MinClicks,
+		IncludeBots:     m.IncludeBots,
+		Channel:         m.Channel,
+		Target:          m.Target,
+		Cooldown:        time.Duration(m.CooldownSeconds) * time.Second,
+		Enabled:         m.Enabled,
+		LastTriggeredAt: m.LastTriggeredAt,
+		DeletedAt:       m.DeletedAt,
+		CreatedAt:       m.CreatedAt,
+		UpdatedAt:       m.UpdatedAt,
+	}
+}
+
+func ToAlertRuleBunModel(r *domain.AlertRule) *AlertRuleBunModel {
+	if r == nil {
+		return nil
+	}
+	return &AlertRuleBunModel{
+		ID:              r.ID,
+		UserID:          r.UserID,
+		LinkID:          r.LinkID,
+		Name:            r.Name,
+		Kind:            r.Kind,
+		Threshold:       r.Threshold,
+		Factor:          r.Factor,
+		WindowHours:     r.WindowHours,
+		MinClicks:       r.MinClicks,
+		IncludeBots:     r.IncludeBots,
+		Channel:         r.Channel,
+		Target:          r.Target,
+		CooldownSeconds: int64(r.Cooldown / time.Second),
+		Enabled:         r.Enabled,
+		LastTriggeredAt: r.LastTriggeredAt,
+		DeletedAt:       r.DeletedAt,
+		CreatedAt:       r.CreatedAt,
+		UpdatedAt:       r.UpdatedAt,
+	}
+}
+
+type AlertEventBunModel struct {
+	bun.BaseModel `bun:"table:alert_events"`
+	ID            int64      `bun:"id,pk,autoincrement"`
+	RuleID        int64      `bun:"rule_id,notnull"`
+	UserID        int64      `bun:"user_id,notnull"`
+	LinkID        *int64     `bun:"link_id,nullzero"`
+	Kind          string     `bun:"kind,notnull"`
+	DedupKey      string     `bun:"dedup_key,notnull"`
+	Value         int64      `bun:"value,notnull"`
+	Baseline      float64    `bun:"baseline,notnull"`
+	Status        string     `bun:"status,notnull"`
+	Attempts      int        `bun:"attempts,notnull"`
+	Error         string     `bun:"error,nullzero"`
+	TriggeredAt   time.Time  `bun:"triggered_at,notnull,default:current_timestamp"`
+	NextAttemptAt *time.Time `bun:"next_attempt_at,nullzero"`
+	DeliveredAt   *time.Time `bun:"delivered_at,nullzero"`
+}
+
+func (m *AlertEventBunModel) ToDomain() *domain.AlertEvent {
+	if m == nil {
+		return nil
+	}
+	var d domain.AlertEvent
+	copier.Copy(&d, m)
+	return &d
+}
+
+func ToAlertEventBunModel(e *domain.AlertEvent) *AlertEventBunModel {
+	if e == nil {
+		return nil
+	}
+	var m AlertEventBunModel
+	copier.Copy(&m, e)
+	return &m
+}
@@ -2,7 +2,9 @@ package model
 
 import (
 	"time"
+	"url-shortener/internal/domain"
 
+	"github.com/jinzhu/copier"
 	"github.com/uptrace/bun"
 )
 
@@ -10,7 +12,31 @@ type ApiKeyBunModel struct {
 	bun.BaseModel `bun:"table:apikeys"`
 	ID            int64      `bun:"id,pk,autoincrement"`
 	UserID        int64      `bun:"user_id,notnull"`
-	Key           string     `bun:"key,notnull,unique"`
+	KeyHash       string     `bun:"key_hash,notnull,unique"`
+	Prefix        string     `bun:"key_prefix,notnull"`
+	Scopes        []string   `bun:"scopes,array"`
+	ExpiresAt     *time.Time `bun:"expires_at,nullzero"`
+	LastUsedAt    *time.Time `bun:"last_used_at,nullzero"`
+	LastUsedIP    string     `bun:"last_used_ip,nullzero"`
+	ReplacedBy    *int64     `bun:"replaced_by,nullzero"`
 	DeletedAt     *time.Time `bun:"deleted_at,nullzero,soft_delete"`
 	CreatedAt     time.Time  `bun:"created_at,notnull,default:current_timestamp"`
 }
+
+func (m *ApiKeyBunModel) ToDomain() *domain.ApiKey {
+	if m == nil {
+		return nil
+	}
+	var d domain.ApiKey
+	copier.Copy(&d, m)
+	return &d
+}
+
+func ToApiKeyBunModel(k *domain.ApiKey) *ApiKeyBunModel {
+	if k == nil {
+		return nil
+	}
+	var m ApiKeyBunModel
+	copier.Copy(&m, k)
+	return &m
+}
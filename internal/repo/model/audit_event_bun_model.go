@@ -0,0 +1,71 @@
+package model
+
+import (
+	"time"
+	"url-shortener/internal/domain"
+
+	"github.com/uptrace/bun"
+)
+
+type AuditEventBunModel struct {
+	bun.BaseModel `bun:"table:audit_events"`
+	ID            int64          `bun:"id,pk,autoincrement"`
+	ActorUserID   *int64         `bun:"actor_user_id,nullzero"`
+	ActorAPIKeyID *int64         `bun:"actor_api_key_id,nullzero"`
+	Action        string         `bun:"action,notnull"`
+	TargetType    string         `bun:"target_type,notnull"`
+	TargetID      string         `bun:"target_id,notnull"`
+	Before        map[string]any `bun:"before,type:jsonb,nullzero,json_use_number"`
+	After         map[string]any `bun:"after,type:jsonb,nullzero,json_use_number"`
+	IP            string         `bun:"ip,notnull"`
+	RequestID     string         `bun:"request_id,notnull"`
+	CreatedAt     time.Time      `bun:"created_at,notnull"`
+	PrevHash      string         `bun:"prev_hash,notnull"`
+	Hash          string         `bun:"hash,notnull,unique"`
+}
+
+// The actor is flattened into columns, so the mapping is spelled out
+// instead of going through copier.
+func (m *AuditEventBunModel) ToDomain() *domain.AuditEvent {
+	if m == nil {
+		return nil
+	}
+	return &domain.AuditEvent{
+		ID: m.ID,
+		Actor: domain.AuditActor{
+			UserID:    m.ActorUserID,
+			APIKeyID:  m.ActorAPIKeyID,
+			IP:        m.IP,
+			RequestID: m.RequestID,
+		},
+		Action:     m.Action,
+		TargetType: m.TargetType,
+		TargetID:   m.TargetID,
+		Before:     m.Before,
+		After:      m.After,
+		CreatedAt:  m.CreatedAt,
+		PrevHash:   m.PrevHash,
+		Hash:       m.Hash,
+	}
+}
+
+func ToAuditEventBunModel(e *domain.AuditEvent) *AuditEventBunModel {
+	if e == nil {
+		return nil
+	}
+	return &AuditEventBunModel{
+		ID:            e.ID,
+		ActorUserID:   e.Actor.UserID,
+		ActorAPIKeyID: e.Actor.APIKeyID,
+		Action:        e.Action,
+		TargetType:    e.TargetType,
+		TargetID:      e.TargetID,
+		Before:        e.Before,
+		After:         e.After,
+		IP:            e.Actor.IP,
+		RequestID:     e.Actor.RequestID,
+		CreatedAt:     e.CreatedAt,
+		PrevHash:      e.PrevHash,
+		Hash:          e.Hash,
+	}
+}
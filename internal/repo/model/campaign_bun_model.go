@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+	"url-shortener/internal/domain"
+
+	"github.com/jinzhu/copier"
+	"github.com/uptrace/bun"
+)
+
+type CampaignBunModel struct {
+	bun.BaseModel `bun:"table:campaigns"`
+	ID            int64      `bun:"id,pk,autoincrement"`
+	UserID        int64      `bun:"user_id,notnull"`
+	Name          string     `bun:"name,notnull"`
+	StartsAt      *time.Time `bun:"starts_at,nullzero"`
+	EndsAt        *time.Time `bun:"ends_at,nullzero"`
+	DeletedAt     *time.Time `bun:"deleted_at,nullzero,soft_delete"`
+	CreatedAt     time.Time  `bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt     *time.Time `bun:"updated_at,nullzero"`
+}
+
+func (m *CampaignBunModel) ToDomain() *domain.Campaign {
+	if m == nil {
+		return nil
+	}
+	var d domain.Campaign
+	copier.Copy(&d, m)
+	return &d
+}
+
+func ToCampaignBunModel(c *domain.Campaign) *CampaignBunModel {
+	if c == nil {
+		return nil
+	}
+	var m CampaignBunModel
+	copier.Copy(&m, c)
+	return &m
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+	"url-shortener/internal/domain"
+
+	"github.com/jinzhu/copier"
+	"github.com/uptrace/bun"
+)
+
+type ClickEventBunModel struct {
+	bun.BaseModel   `bun:"table:click_events"`
+	ID              int64     `bun:"id,pk,autoincrement"`
+	LinkID          int64     `bun:"link_id,notnull"`
+	UserID          int64     `bun:"user_id,notnull"`
+	ClickedAt       time.Time `bun:"clicked_at,notnull,default:current_timestamp"`
+	Referrer        string    `bun:"referrer,nullzero"`
+	ReferrerDomain  string    `bun:"referrer_domain,nullzero"`
+	Browser         string    `bun:"browser,nullzero"`
+	BrowserVersion  string    `bun:"browser_version,nullzero"`
+	OS              string    `bun:"os,nullzero"`
+	Device          string    `bun:"device,nullzero"`
+	Languages       string    `bun:"languages,nullzero"`
+	PrimaryLanguage string    `bun:"primary_language,nullzero"`
+	Country         string    `bun:"country,nullzero"`
+	IPHash          string    `bun:"ip_hash,nullzero"`
+	Source          string    `bun:"source,nullzero"`
+	OptOut          bool      `bun:"opt_out,notnull,default:false"`
+	IsBot           bool      `bun:"is_bot,notnull,default:false"`
+	BotReason       string    `bun:"bot_reason,nullzero"`
+}
+
+func (m *ClickEventBunModel) ToDomain() *domain.ClickEvent {
+	if m == nil {
+		return nil
+	}
+	var d domain.ClickEvent
+	copier.Copy(&d, m)
+	return &d
+}
+
+func ToClickEventBunModel(e *domain.ClickEvent) *ClickEventBunModel {
+	if e == nil {
+		return nil
+	}
+	var m ClickEventBunModel
+	copier.Copy(&m, e)
+	return &m
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// ClickRollupBunModel is one hour of clicks for one link, with bot clicks
+// counted apart. Buckets are UTC
+// hours; coarser intervals and other time zones are summed from them.
+type ClickRollupBunModel struct {
+	bun.BaseModel `bun:"table:click_rollups_hourly"`
+	LinkID        int64     `bun:"link_id,pk"`
+	UserID        int64     `bun:"user_id,notnull"`
+	Bucket        time.Time `bun:"bucket,pk"`
+	Clicks        int64     `bun:"clicks,notnull"`
+	BotClicks     int64     `bun:"bot_clicks,notnull"`
+}
+
+// ClickDimensionRollupBunModel counts one link's clicks per UTC day for one
+// value of a breakdown dimension (referrer, browser, country, ...).
+type ClickDimensionRollupBunModel struct {
+	bun.BaseModel `bun:"table:click_dimension_rollups_daily"`
+	LinkID        int64     `bun:"link_id,pk"`
+	UserID        int64     `bun:"user_id,notnull"`
+	Day           time.Time `bun:"day,pk,type:date"`
+	Dimension     string    `bun:"dimension,pk"`
+	Value         string    `bun:"value,pk"`
+	Clicks        int64     `bun:"clicks,notnull"`
+	BotClicks     int64     `bun:"bot_clicks,notnull"`
+}
+
+// ClickVisitorSketchBunModel holds the encoded HLL sketch of one link's
+// visitors on one UTC day.
+type ClickVisitorSketchBunModel struct {
+	bun.BaseModel `bun:"table:click_visitor_sketches_daily"`
+	LinkID        int64     `bun:"link_id,pk"`
+	UserID        int64     `bun:"user_id,notnull"`
+	Day           time.Time `bun:"day,pk,type:date"`
+	Sketch        []byte    `bun:"sketch,nullzero"`
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"time"
+	"url-shortener/internal/domain"
+
+	"github.com/uptrace/bun"
+)
+
+type ExportJobBunModel struct {
+	bun.BaseModel `bun:"table:export_jobs"`
+	ID            int64      `bun:"id,pk,autoincrement"`
+	Token         string     `bun:"token,notnull,unique"`
+	RequestedBy   int64      `bun:"requested_by,notnull"`
+	UserID        *int64     `bun:"user_id,nullzero"`
+	LinkID        *int64     `bun:"link_id,nullzero"`
+	Dataset       string     `bun:"dataset,notnull"`
+	Format        string     `bun:"format,notnull"`
+	FromAt        *time.Time `bun:"from_at,nullzero"`
+	ToAt          *time.Time `bun:"to_at,nullzero"`
+	Status        string     `bun:"status,notnull"`
+	Rows          int64      `bun:"rows,notnull"`
+	SizeBytes     int64      `bun:"size_bytes,notnull"`
+	Error         string     `bun:"error,nullzero"`
+	CreatedAt     time.Time  `bun:"created_at,notnull,default:current_timestamp"`
+	StartedAt     *time.Time `bun:"started_at,nullzero"`
+	FinishedAt    *time.Time `bun:"finished_at,nullzero"`
+	ExpiresAt     *time.Time `bun:"expires_at,nullzero"`
+}
+
+// The export query is stored flattened, so the mapping is spelled out
+// instead of going through copier.
+func (m *ExportJobBunModel) ToDomain() *domain.ExportJob {
+	if m == nil {
+		return nil
+	}
+	return &domain.ExportJob{
+		ID:          m.ID,
+		Token:       m.Token,
+		RequestedBy: m.RequestedBy,
+		Query: domain.ExportQuery{
+			UserID:  m.UserID,
+			LinkID:  m.LinkID,
+			Dataset: m.Dataset,
+			Format:  m.Format,
+			From:    m.FromAt,
+			To:      m.ToAt,
+		},
+		Status:     m.Status,
+		Rows:       m.Rows,
+		SizeBytes:  m.SizeBytes,
+		Error:      m.Error,
+		CreatedAt:  m.CreatedAt,
+		StartedAt:  m.StartedAt,
+		FinishedAt: m.FinishedAt,
+		ExpiresAt:  m.ExpiresAt,
+	}
+}
+
+func ToExportJobBunModel(j *domain.ExportJob) *ExportJobBunModel {
+	if j == nil {
+		return nil
+	}
+	return &ExportJobBunModel{
+		ID:          j.ID,
+		Token:       j.Token,
+		RequestedBy: j.RequestedBy,
+		UserID:      j.Query.UserID,
+		LinkID:      j.Query.LinkID,
+		Dataset:     j.Query.Dataset,
+		Format:      j.Query.Format,
+		FromAt:      j.Query.From,
+		ToAt:        j.Query.To,
+		Status:      j.Status,
+		Rows:        j.Rows,
+		SizeBytes:   j.SizeBytes,
+		Error:       j.Error,
+		CreatedAt:   j.CreatedAt,
+		StartedAt:   j.StartedAt,
+		FinishedAt:  j.FinishedAt,
+		ExpiresAt:   j.ExpiresAt,
+	}
+}
@@ -9,15 +9,26 @@ import (
 )
 
 type LinkBunModel struct {
-	bun.BaseModel `bun:"table:links"`
-	ID            int64      `bun:"id,pk,autoincrement"`
-	UserID        int64      `bun:"user_id,notnull"`
-	ShortCode     string     `bun:"short_code,notnull,unique"`
-	LongURL       string     `bun:"long_url,notnull"`
-	ClickCount    int64      `bun:"click_count,notnull,default:0"`
-	LastClickedAt *time.Time `bun:"last_clicked_at,nullzero"`
-	DeletedAt     *time.Time `bun:"deleted_at,nullzero,soft_delete"`
-	CreatedAt     time.Time  `bun:"created_at,notnull,default:current_timestamp"`
+	bun.BaseModel       `bun:"table:links"`
+	ID                  int64      `bun:"id,pk,autoincrement"`
+	UserID              int64      `bun:"user_id,notnull"`
+	CampaignID          *int64     `bun:"campaign_id,nullzero"`
+	ShortCode           string     `bun:"short_code,notnull,unique"`
+	LongURL             string     `bun:"long_url,notnull"`
+	ClickCount          int64      `bun:"click_count,notnull,default:0"`
+	BotClickCount       int64      `bun:"bot_click_count,notnull,default:0"`
+	LastClickedAt       *time.Time `bun:"last_clicked_at,nullzero"`
+	HealthStatus        string     `bun:"health_status,notnull,default:'unknown'"`
+	HealthStatusCode    int        `bun:"health_status_code,notnull,default:0"`
+	HealthLatencyMs     int64      `bun:"health_latency_ms,notnull,default:0"`
+	HealthRedirectChain []string   `bun:"health_redirect_chain,array"`
+	HealthError         string     `bun:"health_error,nullzero"`
+	HealthCheckedAt     *time.Time `bun:"health_checked_at,nullzero"`
+	HealthChangedAt     *time.Time `bun:"health_changed_at,nullzero"`
+	FlaggedAt           *time.Time `bun:"flagged_at,nullzero"`
+	FlagReason          string     `bun:"flag_reason,nullzero"`
+	DeletedAt           *time.Time `bun:"deleted_at,nullzero,soft_delete"`
+	CreatedAt           time.Time  `bun:"created_at,notnull,default:current_timestamp"`
 }
 
 func (m *LinkBunModel) ToDomain() *domain.Link {
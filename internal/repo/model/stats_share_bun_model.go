@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+	"url-shortener/internal/domain"
+
+	"github.com/jinzhu/copier"
+	"github.com/uptrace/bun"
+)
+
+type StatsShareBunModel struct {
+	bun.BaseModel `bun:"table:stats_shares"`
+	ID            int64      `bun:"id,pk,autoincrement"`
+	LinkID        int64      `bun:"link_id,notnull"`
+	UserID        int64      `bun:"user_id,notnull"`
+	Token         string     `bun:"token,notnull,unique"`
+	Dimensions    []string   `bun:"dimensions,array"`
+	ExpiresAt     *time.Time `bun:"expires_at,nullzero"`
+	RevokedAt     *time.Time `bun:"revoked_at,nullzero"`
+	CreatedAt     time.Time  `bun:"created_at,notnull,default:current_timestamp"`
+}
+
+func (m *StatsShareBunModel) ToDomain() *domain.StatsShare {
+	if m == nil {
+		return nil
+	}
+	var d domain.StatsShare
+	copier.Copy(&d, m)
+	return &d
+}
+
+func ToStatsShareBunModel(s *domain.StatsShare) *StatsShareBunModel {
+	if s == nil {
+		return nil
+	}
+	var m StatsShareBunModel
+	copier.Copy(&m, s)
+	return &m
+}
@@ -0,0 +1,107 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repo/model"
+	"url-shortener/internal/usecase"
+
+	"github.com/uptrace/bun"
+)
+
+type StatsSharePGRepository struct {
+	db *bun.DB
+}
+
+func NewStatsSharePGRepository(db *bun.DB) usecase.StatsShareRepository {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	return &StatsSharePGRepository{db: db}
+}
+
+// Create implements usecase.StatsShareRepository.
+func (r *StatsSharePGRepository) Create(ctx context.Context, share *domain.StatsShare) error {
+	shareModel := model.ToStatsShareBunModel(share)
+	if shareModel.Dimensions == nil {
+		shareModel.Dimensions = []string{}
+	}
+	_, err := r.db.NewInsert().
+		Model(shareModel).
+		ExcludeColumn("id").
+		Returning("id, created_at").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	share.ID = shareModel.ID
+	share.CreatedAt = shareModel.CreatedAt
+	return nil
+}
+
+// ListByLink implements usecase.StatsShareRepository.
+func (r *StatsSharePGRepository) ListByLink(ctx context.Context, linkID int64) ([]*domain.StatsShare, error) {
+	shareModels := []*model.StatsShareBunModel{}
+	err := r.db.NewSelect().
+		Model(&shareModels).
+		Where("link_id = ?", linkID).
+		Order("id DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	shares := make([]*domain.StatsShare, 0, len(shareModels))
+	for _, m := range shareModels {
+		shares = append(shares, m.ToDomain())
+	}
+	return shares, nil
+}
+
+type statsShareWithLink struct {
+	model.StatsShareBunModel `bun:",extend"`
+	ShortCode                string `bun:"short_code"`
+}
+
+// FindByToken implements usecase.StatsShareRepository. Shares of deleted
+// links are not found.
+func (r *StatsSharePGRepository) FindByToken(ctx context.Context, token string) (*domain.StatsShare, error) {
+	row := new(statsShareWithLink)
+	err := r.db.NewSelect().
+		Model(row).
+		ColumnExpr("stats_share_bun_model.*").
+		ColumnExpr("l.short_code").
+		Join("JOIN links AS l ON l.id = stats_share_bun_model.link_id AND l.deleted_at IS NULL").
+		Where("stats_share_bun_model.token = ?", token).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	share := row.StatsShareBunModel.ToDomain()
+	share.ShortCode = row.ShortCode
+	return share, nil
+}
+
+// Revoke implements usecase.StatsShareRepository. Revoking twice keeps the
+// first revocation time.
+func (r *StatsSharePGRepository) Revoke(ctx context.Context, linkID, shareID int64) (*domain.StatsShare, error) {
+	shareModel := new(model.StatsShareBunModel)
+	res, err := r.db.NewUpdate().
+		Model(shareModel).
+		Set("revoked_at = COALESCE(revoked_at, NOW())").
+		Where("id = ?", shareID).
+		Where("link_id = ?", linkID).
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, nil
+	}
+	return shareModel.ToDomain(), nil
+}
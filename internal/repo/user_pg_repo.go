@@ -2,12 +2,15 @@ package repo
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"time"
 	"url-shortener/internal/domain"
 	"url-shortener/internal/repo/model"
 	"url-shortener/internal/usecase"
 
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
 )
 
 type UserPGRepository struct {
@@ -21,28 +24,52 @@ func NewUserPGRepository(db *bun.DB) usecase.UserRepository {
 // Create implements usecase.UserRepository.
 func (r *UserPGRepository) Create(ctx context.Context, user *domain.User) error {
 	userModel := model.ToUserBunModel(user)
-	_, err := r.db.NewInsert().Model(userModel).Exec(ctx)
-	return err
+	_, err := r.db.NewInsert().
+		Model(userModel).
+		ExcludeColumn("id").
+		Returning("id, created_at").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	user.ID = userModel.ID
+	user.CreatedAt = userModel.CreatedAt
+	return nil
 }
 
-// FindByAPIKey implements usecase.UserRepository.
-func (r *UserPGRepository) FindByAPIKey(ctx context.Context, apiKey string) (*domain.User, error) {
-	var userModel model.UserBunModel
+// FindByAPIKeyHash implements usecase.UserRepository.
+func (r *UserPGRepository) FindByAPIKeyHash(ctx context.Context, keyHash string) (*domain.User, error) {
+	var row struct {
+		model.UserBunModel `bun:",extend"`
+		APIKeyID           int64    `bun:"api_key_id"`
+		APIKeyScopes       []string `bun:"api_key_scopes,array"`
+	}
 	err := r.db.NewSelect().
-		Model(&userModel).
+		Model(&row).
+		ColumnExpr("user_bun_model.*").
+		ColumnExpr("apikeys.id AS api_key_id").
+		ColumnExpr("apikeys.scopes AS api_key_scopes").
 		Join("JOIN apikeys ON apikeys.user_id = user_bun_model.id").
-		Where("apikeys.key = ?", apiKey).
+		Where("apikeys.key_hash = ?", keyHash).
+		Where("apikeys.deleted_at IS NULL").
+		Where("apikeys.expires_at IS NULL OR apikeys.expires_at > NOW()").
 		Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return userModel.ToDomain(), nil
+	user := row.UserBunModel.ToDomain()
+	user.APIKeyID = row.APIKeyID
+	user.APIKeyScopes = row.APIKeyScopes
+	return user, nil
 }
 
 // FindByID implements usecase.UserRepository.
 func (r *UserPGRepository) FindByID(ctx context.Context, id int64) (*domain.User, error) {
 	userModel := new(model.UserBunModel)
 	err := r.db.NewSelect().Model(userModel).Where("id = ?", id).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -70,8 +97,195 @@ func (r *UserPGRepository) UpdatePlanAndExpiry(ctx context.Context, userID int64
 	return err
 }
 
-func (r *UserPGRepository) CreateAPIKey(ctx context.Context, userID int64, key string) error {
-	api := &model.ApiKeyBunModel{UserID: userID, Key: key}
-	_, err := r.db.NewInsert().Model(api).Exec(ctx)
+// CreateAPIKey implements usecase.UserRepository. With a limit, the user
+// row is locked while counting, so concurrent creates cannot both pass.
+func (r *UserPGRepository) CreateAPIKey(ctx context.Context, key *domain.ApiKey, limit int) (bool, error) {
+	created := false
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if limit > 0 {
+			_, err := tx.NewSelect().
+				Model((*model.UserBunModel)(nil)).
+				Column("id").
+				Where("id = ?", key.UserID).
+				For("UPDATE").
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+			count, err := tx.NewSelect().
+				Model((*model.ApiKeyBunModel)(nil)).
+				Where("user_id = ?", key.UserID).
+				Apply(activeAPIKeys).
+				Count(ctx)
+			if err != nil {
+				return err
+			}
+			if count >= limit {
+				return nil
+			}
+		}
+		keyModel := model.ToApiKeyBunModel(key)
+		_, err := tx.NewInsert().
+			Model(keyModel).
+			ExcludeColumn("id").
+			Returning("id, created_at").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		key.ID = keyModel.ID
+		key.CreatedAt = keyModel.CreatedAt
+		created = true
+		return nil
+	})
+	return created, err
+}
+
+// activeAPIKeys limits q to keys that have not expired; soft delete
+// already hides revoked ones.
+func activeAPIKeys(q *bun.SelectQuery) *bun.SelectQuery {
+	return q.Where("expires_at IS NULL OR expires_at > NOW()")
+}
+
+// ListAPIKeys implements usecase.UserRepository.
+func (r *UserPGRepository) ListAPIKeys(ctx context.Context, userID int64) ([]*domain.ApiKey, error) {
+	var rows []*model.ApiKeyBunModel
+	err := r.db.NewSelect().
+		Model(&rows).
+		Where("user_id = ?", userID).
+		Apply(activeAPIKeys).
+		Order("id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]*domain.ApiKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, row.ToDomain())
+	}
+	return keys, nil
+}
+
+// FindAPIKey implements usecase.UserRepository.
+func (r *UserPGRepository) FindAPIKey(ctx context.Context, userID, keyID int64) (*domain.ApiKey, error) {
+	keyModel := new(model.ApiKeyBunModel)
+	err := r.db.NewSelect().
+		Model(keyModel).
+		Where("id = ?", keyID).
+		Where("user_id = ?", userID).
+		Apply(activeAPIKeys).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return keyModel.ToDomain(), nil
+}
+
+// RevokeAPIKey implements usecase.UserRepository.
+func (r *UserPGRepository) RevokeAPIKey(ctx context.Context, userID, keyID int64) (bool, error) {
+	res, err := r.db.NewDelete().
+		Model((*model.ApiKeyBunModel)(nil)).
+		Where("id = ?", keyID).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// RotateAPIKey implements usecase.UserRepository. The old key keeps an
+// earlier expiry if it already had one.
+func (r *UserPGRepository) RotateAPIKey(ctx context.Context, userID, keyID int64, next *domain.ApiKey, graceUntil time.Time, limit int) (bool, error) {
+	rotated := false
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if limit > 0 {
+			// The old key stays active through the grace period, so it is
+			// not counted: rotating swaps one key for another.
+			_, err := tx.NewSelect().
+				Model((*model.UserBunModel)(nil)).
+				Column("id").
+				Where("id = ?", userID).
+				For("UPDATE").
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+			count, err := tx.NewSelect().
+				Model((*model.ApiKeyBunModel)(nil)).
+				Where("user_id = ?", userID).
+				Where("id <> ?", keyID).
+				Apply(activeAPIKeys).
+				Count(ctx)
+			if err != nil {
+				return err
+			}
+			if count >= limit {
+				return nil
+			}
+		}
+		// Lock the old key so concurrent rotations cannot both replace it.
+		var ids []int64
+		err := tx.NewSelect().
+			Model((*model.ApiKeyBunModel)(nil)).
+			Column("id").
+			Where("id = ?", keyID).
+			Where("user_id = ?", userID).
+			Where("replaced_by IS NULL").
+			Apply(activeAPIKeys).
+			For("UPDATE").
+			Scan(ctx, &ids)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		keyModel := model.ToApiKeyBunModel(next)
+		_, err = tx.NewInsert().
+			Model(keyModel).
+			ExcludeColumn("id").
+			Returning("id, created_at").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = tx.NewUpdate().
+			Model((*model.ApiKeyBunModel)(nil)).
+			Set("expires_at = LEAST(COALESCE(expires_at, ?0), ?0)", graceUntil).
+			Set("replaced_by = ?", keyModel.ID).
+			Where("id = ?", keyID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		next.ID = keyModel.ID
+		next.CreatedAt = keyModel.CreatedAt
+		rotated = true
+		return nil
+	})
+	return rotated, err
+}
+
+// RecordAPIKeyUsage implements usecase.UserRepository. A use older than
+// the stored one, e.g. from another instance, does not overwrite it.
+func (r *UserPGRepository) RecordAPIKeyUsage(ctx context.Context, usage []domain.APIKeyUsage) error {
+	if len(usage) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(usage))
+	times := make([]time.Time, len(usage))
+	ips := make([]string, len(usage))
+	for i, u := range usage {
+		ids[i], times[i], ips[i] = u.KeyID, u.At, u.IP
+	}
+	_, err := r.db.NewRaw(`
+		UPDATE apikeys SET last_used_at = u.at, last_used_ip = NULLIF(u.ip, '')
+		FROM unnest(?::bigint[], ?::timestamptz[], ?::text[]) AS u(id, at, ip)
+		WHERE apikeys.id = u.id
+		  AND (apikeys.last_used_at IS NULL OR apikeys.last_used_at < u.at)`,
+		pgdialect.Array(ids), pgdialect.Array(times), pgdialect.Array(ips),
+	).Exec(ctx)
 	return err
 }
@@ -0,0 +1,212 @@
+package screener
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/usecase"
+)
+
+// Blocklist is a file-backed list of blocked domains, URL prefixes and
+// regexes. Edits made through the admin API are written back to the file.
+type Blocklist struct {
+	path string
+
+	editMu  sync.Mutex
+	mu      sync.RWMutex
+	list    domain.Blocklist
+	regexes []*regexp.Regexp
+}
+
+var _ usecase.URLScreener = (*Blocklist)(nil)
+var _ usecase.BlocklistStore = (*Blocklist)(nil)
+
+// LoadBlocklist reads the JSON blocklist at path. A missing file yields an
+// empty list that is created on the first edit; an empty path keeps the
+// list in memory only.
+func LoadBlocklist(path string) (*Blocklist, error) {
+	b := &Blocklist{path: path}
+	if path == "" {
+		return b, nil
+	}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list domain.Blocklist
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, fmt.Errorf("parse blocklist %s: %w", path, err)
+	}
+	if err := b.replace(list); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Screen implements usecase.URLScreener.
+func (b *Blocklist) Screen(ctx context.Context, rawURL string) (usecase.ScreenVerdict, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	lowered := strings.ToLower(rawURL)
+	if u, err := url.Parse(rawURL); err == nil {
+		host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+		for _, d := range b.list.Domains {
+			if host == d || strings.HasSuffix(host, "."+d) {
+				return flagged("blocked domain " + d), nil
+			}
+		}
+	}
+	for _, p := range b.list.Prefixes {
+		if strings.HasPrefix(lowered, p) {
+			return flagged("blocked URL prefix " + p), nil
+		}
+	}
+	for _, re := range b.regexes {
+		if re.MatchString(rawURL) {
+			return flagged("matched pattern " + re.String()), nil
+		}
+	}
+	return usecase.ScreenVerdict{}, nil
+}
+
+// List implements usecase.BlocklistStore.
+func (b *Blocklist) List() domain.Blocklist {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return domain.Blocklist{
+		Domains:  slices.Clone(b.list.Domains),
+		Prefixes: slices.Clone(b.list.Prefixes),
+		Regexes:  slices.Clone(b.list.Regexes),
+	}
+}
+
+// Add implements usecase.BlocklistStore.
+func (b *Blocklist) Add(ctx context.Context, kind, value string) error {
+	return b.edit(func(list *domain.Blocklist) error {
+		target, err := entries(list, kind)
+		if err != nil {
+			return err
+		}
+		if value = normalize(kind, value); value == "" {
+			return usecase.ErrInvalidBlocklistEntry
+		}
+		if !slices.Contains(*target, value) {
+			*target = append(*target, value)
+		}
+		return nil
+	})
+}
+
+// Remove implements usecase.BlocklistStore.
+func (b *Blocklist) Remove(ctx context.Context, kind, value string) error {
+	return b.edit(func(list *domain.Blocklist) error {
+		target, err := entries(list, kind)
+		if err != nil {
+			return err
+		}
+		value = normalize(kind, value)
+		*target = slices.DeleteFunc(*target, func(v string) bool { return v == value })
+		return nil
+	})
+}
+
+func (b *Blocklist) edit(fn func(list *domain.Blocklist) error) error {
+	b.editMu.Lock()
+	defer b.editMu.Unlock()
+
+	list := b.List()
+	if err := fn(&list); err != nil {
+		return err
+	}
+	if err := b.replace(list); err != nil {
+		return err
+	}
+	return b.save(list)
+}
+
+func (b *Blocklist) replace(list domain.Blocklist) error {
+	for i, d := range list.Domains {
+		list.Domains[i] = normalize(domain.BlocklistDomain, d)
+	}
+	for i, p := range list.Prefixes {
+		list.Prefixes[i] = normalize(domain.BlocklistPrefix, p)
+	}
+	regexes := make([]*regexp.Regexp, 0, len(list.Regexes))
+	for _, expr := range list.Regexes {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("%w: %v", usecase.ErrInvalidBlocklistEntry, err)
+		}
+		regexes = append(regexes, re)
+	}
+	b.mu.Lock()
+	b.list = list
+	b.regexes = regexes
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *Blocklist) save(list domain.Blocklist) error {
+	if b.path == "" {
+		return nil
+	}
+	raw, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Write to a temp file first so a crash never leaves a truncated list.
+	tmp, err := os.CreateTemp(filepath.Dir(b.path), ".blocklist-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), b.path)
+}
+
+func entries(list *domain.Blocklist, kind string) (*[]string, error) {
+	switch kind {
+	case domain.BlocklistDomain:
+		return &list.Domains, nil
+	case domain.BlocklistPrefix:
+		return &list.Prefixes, nil
+	case domain.BlocklistRegex:
+		return &list.Regexes, nil
+	}
+	return nil, fmt.Errorf("%w: unknown kind %q", usecase.ErrInvalidBlocklistEntry, kind)
+}
+
+func normalize(kind, value string) string {
+	value = strings.TrimSpace(value)
+	switch kind {
+	case domain.BlocklistDomain:
+		return strings.TrimSuffix(strings.ToLower(value), ".")
+	case domain.BlocklistPrefix:
+		return strings.ToLower(value)
+	}
+	return value
+}
+
+func flagged(reason string) usecase.ScreenVerdict {
+	return usecase.ScreenVerdict{Flagged: true, Reason: reason, Source: "blocklist"}
+}
@@ -0,0 +1,109 @@
+package screener
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/usecase"
+)
+
+func TestBlocklistScreen(t *testing.T) {
+	b, err := LoadBlocklist("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.replace(domain.Blocklist{
+		Domains:  []string{"Evil.example.", "203.0.113.7", "2001:db8::1"},
+		Prefixes: []string{"https://Docs.example/phish"},
+		Regexes:  []string{`/wp-admin/.*\.php$`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		url    string
+		reason string
+	}{
+		{name: "domain", url: "https://evil.example/", reason: "blocked domain evil.example"},
+		{name: "domain case and trailing dot", url: "https://EVIL.example./x", reason: "blocked domain evil.example"},
+		{name: "subdomain", url: "https://a.b.evil.example/", reason: "blocked domain evil.example"},
+		{name: "domain with port", url: "http://evil.example:8080/", reason: "blocked domain evil.example"},
+		{name: "other domain with same ending", url: "https://notevil.example/", reason: ""},
+		{name: "parent domain", url: "https://example/", reason: ""},
+		{name: "ipv4", url: "http://203.0.113.7/login", reason: "blocked domain 203.0.113.7"},
+		{name: "other ipv4", url: "http://203.0.113.8/", reason: ""},
+		{name: "ipv6", url: "http://[2001:db8::1]:8443/", reason: "blocked domain 2001:db8::1"},
+		{name: "domain in path", url: "https://example.com/evil.example", reason: ""},
+		{name: "prefix", url: "https://docs.example/phishing/form", reason: "blocked URL prefix https://docs.example/phish"},
+		{name: "prefix case", url: "HTTPS://DOCS.EXAMPLE/PHISH", reason: "blocked URL prefix https://docs.example/phish"},
+		{name: "prefix elsewhere", url: "https://docs.example/guide", reason: ""},
+		{name: "regex", url: "https://blog.example/wp-admin/x.php", reason: `matched pattern /wp-admin/.*\.php$`},
+		{name: "clean", url: "https://example.com/", reason: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict, err := b.Screen(context.Background(), tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if verdict.Flagged != (tt.reason != "") || verdict.Reason != tt.reason {
+				t.Errorf("Screen(%q) = %+v, want reason %q", tt.url, verdict, tt.reason)
+			}
+			if verdict.Flagged && verdict.Source != "blocklist" {
+				t.Errorf("source = %q, want blocklist", verdict.Source)
+			}
+		})
+	}
+}
+
+func TestBlocklistEdits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.json")
+	b, err := LoadBlocklist(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := b.Add(ctx, domain.BlocklistDomain, " Bad.Example. "); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Add(ctx, domain.BlocklistDomain, "bad.example"); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.List().Domains; len(got) != 1 || got[0] != "bad.example" {
+		t.Errorf("domains = %v, want [bad.example]", got)
+	}
+
+	if err := b.Add(ctx, domain.BlocklistRegex, "("); !errors.Is(err, usecase.ErrInvalidBlocklistEntry) {
+		t.Errorf("Add(bad regex) error = %v, want %v", err, usecase.ErrInvalidBlocklistEntry)
+	}
+	if err := b.Add(ctx, "ip", "203.0.113.7"); !errors.Is(err, usecase.ErrInvalidBlocklistEntry) {
+		t.Errorf("Add(unknown kind) error = %v, want %v", err, usecase.ErrInvalidBlocklistEntry)
+	}
+	if err := b.Add(ctx, domain.BlocklistDomain, "  "); !errors.Is(err, usecase.ErrInvalidBlocklistEntry) {
+		t.Errorf("Add(blank) error = %v, want %v", err, usecase.ErrInvalidBlocklistEntry)
+	}
+
+	// The file survives a reload.
+	if _, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := LoadBlocklist(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verdict, _ := reloaded.Screen(ctx, "https://www.bad.example/"); !verdict.Flagged {
+		t.Error("reloaded blocklist does not flag bad.example")
+	}
+
+	if err := b.Remove(ctx, domain.BlocklistDomain, "BAD.example"); err != nil {
+		t.Fatal(err)
+	}
+	if verdict, _ := b.Screen(ctx, "https://bad.example/"); verdict.Flagged {
+		t.Error("removed domain is still flagged")
+	}
+}
@@ -0,0 +1,111 @@
+package screener
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+	"url-shortener/internal/usecase"
+)
+
+// ReputationClient is the contract an external reputation API adapter has
+// to satisfy (Safe Browsing, URLhaus, an internal threat-intel service...).
+type ReputationClient interface {
+	Lookup(ctx context.Context, rawURL string) (malicious bool, reason string, err error)
+}
+
+// ReputationScreener adapts a ReputationClient to usecase.URLScreener.
+// With failOpen set, lookup errors are logged and the URL is allowed so an
+// outage of the provider does not block link creation.
+type ReputationScreener struct {
+	name     string
+	client   ReputationClient
+	timeout  time.Duration
+	failOpen bool
+}
+
+var _ usecase.URLScreener = (*ReputationScreener)(nil)
+
+func NewReputationScreener(name string, client ReputationClient, timeout time.Duration, failOpen bool) *ReputationScreener {
+	if client == nil {
+		panic("ReputationClient cannot be nil")
+	}
+	return &ReputationScreener{name: name, client: client, timeout: timeout, failOpen: failOpen}
+}
+
+// Screen implements usecase.URLScreener.
+func (s *ReputationScreener) Screen(ctx context.Context, rawURL string) (usecase.ScreenVerdict, error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	malicious, reason, err := s.client.Lookup(ctx, rawURL)
+	if err != nil {
+		if s.failOpen {
+			log.Printf("Reputation lookup via %s failed, allowing URL: %v", s.name, err)
+			return usecase.ScreenVerdict{}, nil
+		}
+		return usecase.ScreenVerdict{}, fmt.Errorf("reputation lookup via %s: %w", s.name, err)
+	}
+	if !malicious {
+		return usecase.ScreenVerdict{}, nil
+	}
+	if reason == "" {
+		reason = "reported by " + s.name
+	}
+	return usecase.ScreenVerdict{Flagged: true, Reason: reason, Source: s.name}, nil
+}
+
+// HTTPReputationClient talks to a simple JSON reputation endpoint:
+//
+//	POST <endpoint> {"url": "..."} -> {"malicious": true, "reason": "..."}
+//
+// Vendor APIs with a different shape get their own ReputationClient.
+type HTTPReputationClient struct {
+	endpoint string
+	apiKey   string
+	client   *http.Client
+}
+
+func NewHTTPReputationClient(endpoint, apiKey string, client *http.Client) *HTTPReputationClient {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPReputationClient{endpoint: endpoint, apiKey: apiKey, client: client}
+}
+
+// Lookup implements ReputationClient.
+func (c *HTTPReputationClient) Lookup(ctx context.Context, rawURL string) (bool, string, error) {
+	body, err := json.Marshal(map[string]string{"url": rawURL})
+	if err != nil {
+		return false, "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	var out struct {
+		Malicious bool   `json:"malicious"`
+		Reason    string `json:"reason"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return false, "", err
+	}
+	return out.Malicious, out.Reason, nil
+}
@@ -0,0 +1,99 @@
+package screener
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeReputation answers every lookup the same way.
+type fakeReputation struct {
+	malicious bool
+	reason    string
+	err       error
+}
+
+func (f fakeReputation) Lookup(ctx context.Context, rawURL string) (bool, string, error) {
+	return f.malicious, f.reason, f.err
+}
+
+func TestReputationScreener(t *testing.T) {
+	errProvider := errors.New("provider down")
+	tests := []struct {
+		name     string
+		client   fakeReputation
+		failOpen bool
+		flagged  bool
+		reason   string
+		wantErr  bool
+	}{
+		{name: "allowed", client: fakeReputation{}},
+		{name: "flagged", client: fakeReputation{malicious: true, reason: "phishing"}, flagged: true, reason: "phishing"},
+		{name: "flagged without reason", client: fakeReputation{malicious: true}, flagged: true, reason: "reported by test-api"},
+		{name: "error fails open", client: fakeReputation{err: errProvider}, failOpen: true},
+		{name: "error fails closed", client: fakeReputation{err: errProvider}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewReputationScreener("test-api", tt.client, time.Second, tt.failOpen)
+			verdict, err := s.Screen(context.Background(), "https://example.com/")
+			if tt.wantErr {
+				if !errors.Is(err, errProvider) {
+					t.Errorf("error = %v, want %v", err, errProvider)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if verdict.Flagged != tt.flagged || verdict.Reason != tt.reason {
+				t.Errorf("verdict = %+v, want flagged %v, reason %q", verdict, tt.flagged, tt.reason)
+			}
+			if verdict.Flagged && verdict.Source != "test-api" {
+				t.Errorf("source = %q, want test-api", verdict.Source)
+			}
+		})
+	}
+}
+
+func TestHTTPReputationClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var in struct {
+			URL string `json:"url"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if in.URL == "https://broken.example/" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"malicious": in.URL == "https://evil.example/", "reason": "malware"})
+	}))
+	defer srv.Close()
+	client := NewHTTPReputationClient(srv.URL, "secret", srv.Client())
+	ctx := context.Background()
+
+	if malicious, reason, err := client.Lookup(ctx, "https://evil.example/"); err != nil || !malicious || reason != "malware" {
+		t.Errorf("Lookup(evil) = %v, %q, %v", malicious, reason, err)
+	}
+	if malicious, _, err := client.Lookup(ctx, "https://example.com/"); err != nil || malicious {
+		t.Errorf("Lookup(clean) = %v, %v", malicious, err)
+	}
+	if _, _, err := client.Lookup(ctx, "https://broken.example/"); err == nil {
+		t.Error("Lookup with a 502 response succeeded")
+	}
+	unauthorized := NewHTTPReputationClient(srv.URL, "", srv.Client())
+	if _, _, err := unauthorized.Lookup(ctx, "https://example.com/"); err == nil {
+		t.Error("Lookup without the API key succeeded")
+	}
+}
@@ -0,0 +1,42 @@
+package screener
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+	"url-shortener/internal/usecase"
+)
+
+// NewBlocklistFromEnv loads the blocklist file named by BLOCKLIST_FILE.
+func NewBlocklistFromEnv() *Blocklist {
+	path := os.Getenv("BLOCKLIST_FILE")
+	b, err := LoadBlocklist(path)
+	if err != nil {
+		log.Fatalf("Failed to load blocklist: %v", err)
+	}
+	return b
+}
+
+func NewBlocklistStore(b *Blocklist) usecase.BlocklistStore {
+	return b
+}
+
+// NewURLScreener chains the local blocklist with the external reputation
+// provider configured by REPUTATION_API_URL, if any.
+func NewURLScreener(b *Blocklist) usecase.URLScreener {
+	screeners := usecase.MultiScreener{b}
+	if endpoint := os.Getenv("REPUTATION_API_URL"); endpoint != "" {
+		timeout := 3 * time.Second
+		if d, err := time.ParseDuration(os.Getenv("REPUTATION_API_TIMEOUT")); err == nil && d > 0 {
+			timeout = d
+		}
+		failOpen := true
+		if v, err := strconv.ParseBool(os.Getenv("REPUTATION_FAIL_OPEN")); err == nil {
+			failOpen = v
+		}
+		client := NewHTTPReputationClient(endpoint, os.Getenv("REPUTATION_API_KEY"), nil)
+		screeners = append(screeners, NewReputationScreener("reputation-api", client, timeout, failOpen))
+	}
+	return screeners
+}
@@ -0,0 +1,66 @@
+package screener
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"url-shortener/internal/domain"
+)
+
+func TestNewURLScreener(t *testing.T) {
+	lookups := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups++
+		var in struct {
+			URL string `json:"url"`
+		}
+		json.NewDecoder(r.Body).Decode(&in)
+		if in.URL == "https://down.example/" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"malicious": in.URL == "https://phish.example/", "reason": "phishing"})
+	}))
+	defer srv.Close()
+	t.Setenv("REPUTATION_API_URL", srv.URL)
+	t.Setenv("REPUTATION_FAIL_OPEN", "false")
+
+	b, err := LoadBlocklist("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Add(context.Background(), domain.BlocklistDomain, "blocked.example"); err != nil {
+		t.Fatal(err)
+	}
+	s := NewURLScreener(b)
+
+	tests := []struct {
+		name    string
+		url     string
+		source  string
+		lookups int
+		wantErr bool
+	}{
+		{name: "blocklist stops the chain", url: "https://blocked.example/", source: "blocklist", lookups: 0},
+		{name: "flagged by provider", url: "https://phish.example/", source: "reputation-api", lookups: 1},
+		{name: "allowed", url: "https://example.com/", lookups: 1},
+		{name: "provider error", url: "https://down.example/", lookups: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookups = 0
+			verdict, err := s.Screen(context.Background(), tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error: %v", err, tt.wantErr)
+			}
+			if verdict.Flagged != (tt.source != "") || verdict.Source != tt.source {
+				t.Errorf("verdict = %+v, want source %q", verdict, tt.source)
+			}
+			if lookups != tt.lookups {
+				t.Errorf("provider lookups = %d, want %d", lookups, tt.lookups)
+			}
+		})
+	}
+}
@@ -3,13 +3,14 @@ package seeder
 import (
 	"context"
 	"encoding/json"
-	"log"
 	"os"
 	"strings"
 	"url-shortener/internal/domain"
 	"url-shortener/internal/repo/model"
+	"url-shortener/internal/usecase"
 
 	"github.com/uptrace/bun"
+	"go.uber.org/zap"
 )
 
 type seedUser struct {
@@ -17,16 +18,18 @@ type seedUser struct {
 	APIKey string `json:"apikey"`
 	Plan   string `json:"plan"`
 	Role   string `json:"role"`
+	// Scopes default to "*", everything the role allows.
+	Scopes []string `json:"scopes"`
 }
 
-func parseSeedUsers() []seedUser {
+func parseSeedUsers(logger *zap.Logger) []seedUser {
 	// Priority: SEED_USERS_JSON > defaults
 	if raw := os.Getenv("SEED_USERS_JSON"); strings.TrimSpace(raw) != "" {
 		var users []seedUser
 		if err := json.Unmarshal([]byte(raw), &users); err == nil && len(users) > 0 {
 			return users
 		} else if err != nil {
-			log.Printf("Failed to parse SEED_USERS_JSON: %v", err)
+			logger.Warn("Failed to parse SEED_USERS_JSON", zap.Error(err))
 		}
 	}
 	// defaults
@@ -36,9 +39,9 @@ func parseSeedUsers() []seedUser {
 	}
 }
 
-func SeedApiKey(ctx context.Context, db *bun.DB) error {
+func SeedApiKey(ctx context.Context, db *bun.DB, logger *zap.Logger) error {
 	if strings.ToLower(os.Getenv("SEED_ENABLED")) != "true" {
-		log.Println("Seeding disabled (SEED_ENABLED != true), skipping.")
+		logger.Info("Seeding disabled (SEED_ENABLED != true), skipping")
 		return nil
 	}
 	mode := strings.ToLower(os.Getenv("SEED_MODE"))
@@ -46,9 +49,9 @@ func SeedApiKey(ctx context.Context, db *bun.DB) error {
 		mode = "enforce"
 	}
 
-	log.Println("START Seeding API Keys... (mode:", mode, ")")
+	logger.Info("Seeding API keys", zap.String("mode", mode))
 
-	seedUsers := parseSeedUsers()
+	seedUsers := parseSeedUsers(logger)
 
 	for _, su := range seedUsers {
 		// Upsert user row first
@@ -61,7 +64,7 @@ func SeedApiKey(ctx context.Context, db *bun.DB) error {
 				On("CONFLICT (email) DO NOTHING").
 				Exec(ctx)
 			if err != nil {
-				log.Printf("Error inserting user %s: %v", su.Email, err)
+				logger.Error("Error inserting user", zap.String("email", su.Email), zap.Error(err))
 				continue
 			}
 		} else {
@@ -73,7 +76,7 @@ func SeedApiKey(ctx context.Context, db *bun.DB) error {
 				Set("deleted_at = NULL").
 				Exec(ctx)
 			if err != nil {
-				log.Printf("Error upserting user %s: %v", su.Email, err)
+				logger.Error("Error upserting user", zap.String("email", su.Email), zap.Error(err))
 				continue
 			}
 		}
@@ -81,34 +84,41 @@ func SeedApiKey(ctx context.Context, db *bun.DB) error {
 		// Ensure we have the user id
 		var persistedUser model.UserBunModel
 		if err := db.NewSelect().Model(&persistedUser).Where("email = ?", su.Email).Scan(ctx); err != nil {
-			log.Printf("Failed to load user id for %s: %v", su.Email, err)
+			logger.Error("Failed to load user id", zap.String("email", su.Email), zap.Error(err))
 			continue
 		}
 
-		// Upsert API key row mapping to user
-		apiKey := &model.ApiKeyBunModel{UserID: persistedUser.ID, Key: su.APIKey}
+		// Upsert API key row mapping to user; only the digest is stored
+		scopes := su.Scopes
+		if len(scopes) == 0 {
+			scopes = []string{domain.ScopeAll}
+		}
+		apiKey := model.ToApiKeyBunModel(usecase.NewAPIKey(persistedUser.ID, su.APIKey, scopes))
 		if mode == "exist-only" {
-			_, err := db.NewInsert().Model(apiKey).On("CONFLICT (key) DO NOTHING").Exec(ctx)
+			_, err := db.NewInsert().Model(apiKey).ExcludeColumn("id").On("CONFLICT (key_hash) DO NOTHING").Exec(ctx)
 			if err != nil {
-				log.Printf("Error inserting apikey for %s: %v", su.Email, err)
+				logger.Error("Error inserting API key", zap.String("email", su.Email), zap.Error(err))
 				continue
 			}
-			log.Printf("Inserted apikey for %s (or existed)", su.Email)
+			logger.Info("Inserted API key (or existed)", zap.String("email", su.Email))
 			continue
 		}
 		_, err := db.NewInsert().
 			Model(apiKey).
-			On("CONFLICT (key) DO UPDATE").
+			ExcludeColumn("id").
+			On("CONFLICT (key_hash) DO UPDATE").
 			Set("user_id = EXCLUDED.user_id").
+			Set("key_prefix = EXCLUDED.key_prefix").
+			Set("scopes = EXCLUDED.scopes").
 			Set("deleted_at = NULL").
 			Exec(ctx)
 		if err != nil {
-			log.Printf("Error upserting apikey for %s: %v", su.Email, err)
+			logger.Error("Error upserting API key", zap.String("email", su.Email), zap.Error(err))
 			continue
 		}
-		log.Printf("Upserted apikey for %s successfully!", su.Email)
+		logger.Info("Upserted API key", zap.String("email", su.Email))
 	}
 
-	log.Println("Seeding process finished.")
+	logger.Info("Seeding process finished")
 	return nil
 }
@@ -0,0 +1,157 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Exporters.
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// redirectRoute is the route whose sampling TRACING_REDIRECT_SAMPLE_RATIO
+// controls.
+const redirectRoute = "/:shortCode"
+
+// Config comes from the TRACING_* env vars. The OTLP exporter also reads
+// the standard OTEL_EXPORTER_OTLP_* variables (endpoint, headers, TLS),
+// and OTEL_SERVICE_NAME / OTEL_RESOURCE_ATTRIBUTES describe the service.
+type Config struct {
+	Exporter string
+	// SampleRatio is the share of new traces kept, 0 to 1.
+	SampleRatio float64
+	// RedirectSampleRatio applies to redirects instead of SampleRatio;
+	// they are most of the traffic and rarely interesting one by one.
+	RedirectSampleRatio float64
+}
+
+func LoadConfig() Config {
+	exporter := strings.ToLower(strings.TrimSpace(os.Getenv("TRACING_EXPORTER")))
+	if exporter == "" {
+		exporter = ExporterNone
+	}
+	ratio := envRatio("TRACING_SAMPLE_RATIO", 1)
+	return Config{
+		Exporter:            exporter,
+		SampleRatio:         ratio,
+		RedirectSampleRatio: envRatio("TRACING_REDIRECT_SAMPLE_RATIO", ratio),
+	}
+}
+
+func envRatio(key string, def float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 && f <= 1 {
+			return f
+		}
+		log.Printf("Ignoring %s=%q: expected a number between 0 and 1", key, v)
+	}
+	return def
+}
+
+// Tracing installs the global tracer provider and W3C trace context
+// propagation. Instrumentation (gin middleware, Bun hook, usecase spans)
+// uses the globals, so it records nothing while the exporter is "none".
+type Tracing struct {
+	cfg      Config
+	provider *sdktrace.TracerProvider
+}
+
+func NewTracing() *Tracing {
+	return NewTracingWithConfig(LoadConfig())
+}
+
+func NewTracingWithConfig(cfg Config) *Tracing {
+	return &Tracing{cfg: cfg}
+}
+
+// Start sets up the exporter. Propagation is enabled even without one so
+// trace context still flows through to outgoing calls.
+func (t *Tracing) Start(ctx context.Context) error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch t.cfg.Exporter {
+	case ExporterNone:
+		return nil
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return fmt.Errorf("unknown TRACING_EXPORTER %q (want none, otlp or stdout)", t.cfg.Exporter)
+	}
+	if err != nil {
+		return err
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewSchemaless(semconv.ServiceName("url-shortener")),
+	)
+	if err != nil {
+		return err
+	}
+	// Env settings win over the default service name.
+	if envRes, err := resource.New(ctx, resource.WithFromEnv()); err == nil {
+		if merged, err := resource.Merge(res, envRes); err == nil {
+			res = merged
+		}
+	}
+
+	t.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(routeSampler{
+			base:     sdktrace.TraceIDRatioBased(t.cfg.SampleRatio),
+			redirect: sdktrace.TraceIDRatioBased(t.cfg.RedirectSampleRatio),
+		})),
+	)
+	otel.SetTracerProvider(t.provider)
+	log.Printf("Tracing enabled: exporter=%s sample_ratio=%g redirect_sample_ratio=%g", t.cfg.Exporter, t.cfg.SampleRatio, t.cfg.RedirectSampleRatio)
+	return nil
+}
+
+// Stop flushes the spans still buffered.
+func (t *Tracing) Stop(ctx context.Context) error {
+	if t.provider == nil {
+		return nil
+	}
+	return t.provider.Shutdown(ctx)
+}
+
+// routeSampler samples new traces of redirect requests with their own
+// ratio. Traces continued from an incoming sampled parent are decided by
+// ParentBased before this runs.
+type routeSampler struct {
+	base     sdktrace.Sampler
+	redirect sdktrace.Sampler
+}
+
+func (s routeSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	for _, attr := range p.Attributes {
+		if attr.Key == semconv.HTTPRouteKey && attr.Value.AsString() == redirectRoute {
+			return s.redirect.ShouldSample(p)
+		}
+	}
+	return s.base.ShouldSample(p)
+}
+
+func (s routeSampler) Description() string {
+	return fmt.Sprintf("RouteSampler{base:%s,redirect:%s}", s.base.Description(), s.redirect.Description())
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"time"
 	"url-shortener/internal/usecase"
@@ -19,8 +20,15 @@ func NewAdminHttpHandler(service *usecase.AdminService) *AdminHttpHandler {
 func (h *AdminHttpHandler) RegisterAdminRoutes(rg *gin.RouterGroup) {
 	rg.POST("/users", h.CreateUser)
 	rg.POST("/users/:id/apikeys", h.CreateAPIKey)
+	rg.GET("/users/:id/apikeys", h.ListAPIKeys)
+	rg.DELETE("/users/:id/apikeys/:keyId", h.RevokeAPIKey)
+	rg.POST("/users/:id/apikeys/:keyId/rotate", h.RotateAPIKey)
 	rg.DELETE("/users/:id", h.DeleteUser)
 	rg.PUT("/users/:id/plan", h.UpdateUserPlan)
+	rg.GET("/blocklist", h.GetBlocklist)
+	rg.POST("/blocklist", h.AddBlocklistEntry)
+	rg.DELETE("/blocklist", h.RemoveBlocklistEntry)
+	rg.GET("/clicks/stats", h.GetClickIngestionStats)
 }
 
 func (h *AdminHttpHandler) CreateUser(ctx *gin.Context) {
@@ -34,7 +42,7 @@ func (h *AdminHttpHandler) CreateUser(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	user, err := h.service.CreateUser(ctx, req.Email, req.Plan, req.Role, req.PlanExpiresAt)
+	user, err := h.service.CreateUser(ctx.Request.Context(), req.Email, req.Plan, req.Role, req.PlanExpiresAt)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -42,14 +50,35 @@ func (h *AdminHttpHandler) CreateUser(ctx *gin.Context) {
 	ctx.JSON(http.StatusCreated, user)
 }
 
+// CreateAPIKey generates a key for the user. The key is in the response
+// and cannot be retrieved again.
 func (h *AdminHttpHandler) CreateAPIKey(ctx *gin.Context) {
-	var req struct {
-		Key string `json:"key" binding:"required"`
+	var path struct {
+		ID int64 `uri:"id" binding:"required"`
 	}
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var req createAPIKeyRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	apiKey, key, err := h.service.CreateAPIKeyForUser(ctx.Request.Context(), path.ID, req.toInput())
+	if err != nil {
+		respondAPIKeyError(ctx, err)
+		return
+	}
+	respondCreatedAPIKey(ctx, apiKey, key)
+}
+
+type userAPIKeyPath struct {
+	ID    int64 `uri:"id" binding:"required"`
+	KeyID int64 `uri:"keyId" binding:"required"`
+}
+
+func (h *AdminHttpHandler) ListAPIKeys(ctx *gin.Context) {
 	var path struct {
 		ID int64 `uri:"id" binding:"required"`
 	}
@@ -57,11 +86,44 @@ func (h *AdminHttpHandler) CreateAPIKey(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := h.service.CreateAPIKeyForUser(ctx, path.ID, req.Key); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	keys, err := h.service.ListAPIKeysForUser(ctx.Request.Context(), path.ID)
+	if err != nil {
+		respondAPIKeyError(ctx, err)
 		return
 	}
-	ctx.Status(http.StatusCreated)
+	ctx.JSON(http.StatusOK, toAPIKeyResponses(keys))
+}
+
+func (h *AdminHttpHandler) RevokeAPIKey(ctx *gin.Context) {
+	var path userAPIKeyPath
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.service.RevokeAPIKeyForUser(ctx.Request.Context(), path.ID, path.KeyID); err != nil {
+		respondAPIKeyError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *AdminHttpHandler) RotateAPIKey(ctx *gin.Context) {
+	var path userAPIKeyPath
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	grace, err := bindRotateAPIKeyRequest(ctx)
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	rotation, err := h.service.RotateAPIKeyForUser(ctx.Request.Context(), path.ID, path.KeyID, grace)
+	if err != nil {
+		respondAPIKeyError(ctx, err)
+		return
+	}
+	respondRotatedAPIKey(ctx, rotation)
 }
 
 func (h *AdminHttpHandler) DeleteUser(ctx *gin.Context) {
@@ -72,7 +134,10 @@ func (h *AdminHttpHandler) DeleteUser(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := h.service.SoftDeleteUser(ctx, path.ID); err != nil {
+	if err := h.service.SoftDeleteUser(ctx.Request.Context(), path.ID); errors.Is(err, usecase.ErrUserNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -95,9 +160,58 @@ func (h *AdminHttpHandler) UpdateUserPlan(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := h.service.UpdateUserPlan(ctx, path.ID, req.Plan, req.PlanExpiresAt); err != nil {
+	if err := h.service.UpdateUserPlan(ctx.Request.Context(), path.ID, req.Plan, req.PlanExpiresAt); errors.Is(err, usecase.ErrUserNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	ctx.Status(http.StatusNoContent)
 }
+
+func (h *AdminHttpHandler) GetBlocklist(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, h.service.GetBlocklist(ctx))
+}
+
+type blocklistEntryRequest struct {
+	Kind  string `json:"kind" form:"kind" binding:"required,oneof=domain prefix regex"`
+	Value string `json:"value" form:"value" binding:"required"`
+}
+
+func (h *AdminHttpHandler) AddBlocklistEntry(ctx *gin.Context) {
+	var req blocklistEntryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.service.AddBlocklistEntry(ctx.Request.Context(), req.Kind, req.Value); err != nil {
+		ctx.JSON(blocklistErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Status(http.StatusCreated)
+}
+
+func (h *AdminHttpHandler) RemoveBlocklistEntry(ctx *gin.Context) {
+	var req blocklistEntryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.service.RemoveBlocklistEntry(ctx.Request.Context(), req.Kind, req.Value); err != nil {
+		ctx.JSON(blocklistErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *AdminHttpHandler) GetClickIngestionStats(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, h.service.ClickIngestionStats(ctx))
+}
+
+func blocklistErrorStatus(err error) int {
+	if errors.Is(err, usecase.ErrInvalidBlocklistEntry) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
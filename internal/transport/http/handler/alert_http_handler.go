@@ -0,0 +1,303 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AlertHttpHandler struct {
+	service *usecase.AlertService
+}
+
+func NewAlertHttpHandler(service *usecase.AlertService) *AlertHttpHandler {
+	return &AlertHttpHandler{service: service}
+}
+
+func (h *AlertHttpHandler) RegisterAuthRoutes(rg *gin.RouterGroup) {
+	registerRoutes(rg, []route{
+		{"POST", "/alerts", h.CreateRule},
+		{"GET", "/alerts", h.ListRules},
+		{"GET", "/alerts/history", h.GetHistory},
+		{"GET", "/alerts/:id", h.GetRule},
+		{"PUT", "/alerts/:id", h.UpdateRule},
+		{"DELETE", "/alerts/:id", h.DeleteRule},
+		{"GET", "/alerts/:id/history", h.GetRuleHistory},
+	})
+}
+
+type AlertRuleResponse struct {
+	ID              int64      `json:"id"`
+	Name            string     `json:"name"`
+	ShortCode       string     `json:"shortCode,omitempty"`
+	Kind            string     `json:"kind"`
+	Threshold       int64      `json:"threshold,omitempty"`
+	Factor          float64    `json:"factor,omitempty"`
+	WindowHours     int        `json:"windowHours,omitempty"`
+	MinClicks       int64      `json:"minClicks,omitempty"`
+	IncludeBots     bool       `json:"includeBots"`
+	Channel         string     `json:"channel"`
+	Target          string     `json:"target"`
+	Cooldown        string     `json:"cooldown"`
+	Enabled         bool       `json:"enabled"`
+	LastTriggeredAt *time.Time `json:"lastTriggeredAt"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       *time.Time `json:"updatedAt"`
+}
+
+type AlertEventResponse struct {
+	ID          int64      `json:"id"`
+	RuleID      int64      `json:"ruleId"`
+	RuleName    string     `json:"ruleName"`
+	ShortCode   string     `json:"shortCode,omitempty"`
+	Kind        string     `json:"kind"`
+	Value       int64      `json:"value"`
+	Baseline    float64    `json:"baseline"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	Error       string     `json:"error,omitempty"`
+	TriggeredAt time.Time  `json:"triggeredAt"`
+	DeliveredAt *time.Time `json:"deliveredAt"`
+}
+
+type alertRuleRequest struct {
+	Name        string  `json:"name"`
+	ShortCode   string  `json:"short_code"`
+	Kind        string  `json:"kind"`
+	Threshold   int64   `json:"threshold"`
+	Factor      float64 `json:"factor"`
+	WindowHours int     `json:"window_hours"`
+	MinClicks   int64   `json:"min_clicks"`
+	IncludeBots bool    `json:"include_bots"`
+	Channel     string  `json:"channel"`
+	Target      string  `json:"target"`
+	// Cooldown is a Go duration string such as "30m" or "6h".
+	Cooldown *string `json:"cooldown"`
+	Enabled  *bool   `json:"enabled"`
+}
+
+type alertPath struct {
+	ID int64 `uri:"id" binding:"required"`
+}
+
+type alertHistoryQuery struct {
+	RuleID *int64     `form:"rule_id"`
+	From   *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit  int        `form:"limit"`
+}
+
+func (r alertRuleRequest) toInput() (usecase.AlertRuleInput, error) {
+	input := usecase.AlertRuleInput{
+		Name:        r.Name,
+		ShortCode:   r.ShortCode,
+		Kind:        r.Kind,
+		Threshold:   r.Threshold,
+		Factor:      r.Factor,
+		WindowHours: r.WindowHours,
+		MinClicks:   r.MinClicks,
+		IncludeBots: r.IncludeBots,
+		Channel:     r.Channel,
+		Target:      r.Target,
+		Enabled:     r.Enabled,
+	}
+	if r.Cooldown != nil {
+		d, err := time.ParseDuration(*r.Cooldown)
+		if err != nil {
+			return input, usecase.ErrInvalidAlertCooldown
+		}
+		input.Cooldown = &d
+	}
+	return input, nil
+}
+
+func toAlertRuleResponse(r *domain.AlertRule) AlertRuleResponse {
+	return AlertRuleResponse{
+		ID:              r.ID,
+		Name:            r.Name,
+		ShortCode:       r.ShortCode,
+		Kind:            r.Kind,
+		Threshold:       r.Threshold,
+		Factor:          r.Factor,
+		WindowHours:     r.WindowHours,
+		MinClicks:       r.MinClicks,
+		IncludeBots:     r.IncludeBots,
+		Channel:         r.Channel,
+		Target:          r.Target,
+		Cooldown:        r.Cooldown.String(),
+		Enabled:         r.Enabled,
+		LastTriggeredAt: r.LastTriggeredAt,
+		CreatedAt:       r.CreatedAt,
+		UpdatedAt:       r.UpdatedAt,
+	}
+}
+
+func toAlertEventResponse(e *domain.AlertEvent) AlertEventResponse {
+	return AlertEventResponse{
+		ID:          e.ID,
+		RuleID:      e.RuleID,
+		RuleName:    e.RuleName,
+		ShortCode:   e.ShortCode,
+		Kind:        e.Kind,
+		Value:       e.Value,
+		Baseline:    e.Baseline,
+		Status:      e.Status,
+		Attempts:    e.Attempts,
+		Error:       e.Error,
+		TriggeredAt: e.TriggeredAt,
+		DeliveredAt: e.DeliveredAt,
+	}
+}
+
+func respondAlertError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrAlertRuleNotFound), errors.Is(err, usecase.ErrLinkNotFound):
+		respondError(ctx, http.StatusNotFound, err)
+	case errors.Is(err, usecase.ErrTooManyAlertRules):
+		respondError(ctx, http.StatusConflict, err)
+	case errors.Is(err, usecase.ErrInvalidAlertName), errors.Is(err, usecase.ErrInvalidAlertKind),
+		errors.Is(err, usecase.ErrInvalidAlertChannel), errors.Is(err, usecase.ErrInvalidAlertTarget),
+		errors.Is(err, usecase.ErrInvalidAlertThreshold), errors.Is(err, usecase.ErrInvalidAlertFactor),
+		errors.Is(err, usecase.ErrInvalidAlertWindow), errors.Is(err, usecase.ErrInvalidAlertMinClicks),
+		errors.Is(err, usecase.ErrInvalidAlertCooldown), errors.Is(err, usecase.ErrInvalidHistoryLimit),
+		errors.Is(err, usecase.ErrInvalidStatsRange):
+		respondError(ctx, http.StatusBadRequest, err)
+	default:
+		respondError(ctx, http.StatusInternalServerError, err)
+	}
+}
+
+func (h *AlertHttpHandler) CreateRule(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var req alertRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	input, err := req.toInput()
+	if err != nil {
+		respondAlertError(ctx, err)
+		return
+	}
+	rule, err := h.service.CreateRule(ctx.Request.Context(), currentUser.ID, input)
+	if err != nil {
+		respondAlertError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, toAlertRuleResponse(rule))
+}
+
+func (h *AlertHttpHandler) ListRules(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	rules, err := h.service.ListRules(ctx.Request.Context(), currentUser.ID)
+	if err != nil {
+		respondAlertError(ctx, err)
+		return
+	}
+	resp := make([]AlertRuleResponse, 0, len(rules))
+	for _, r := range rules {
+		resp = append(resp, toAlertRuleResponse(r))
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *AlertHttpHandler) GetRule(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var path alertPath
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	rule, err := h.service.GetRule(ctx.Request.Context(), currentUser.ID, path.ID)
+	if err != nil {
+		respondAlertError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toAlertRuleResponse(rule))
+}
+
+func (h *AlertHttpHandler) UpdateRule(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var path alertPath
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	var req alertRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	input, err := req.toInput()
+	if err != nil {
+		respondAlertError(ctx, err)
+		return
+	}
+	rule, err := h.service.UpdateRule(ctx.Request.Context(), currentUser.ID, path.ID, input)
+	if err != nil {
+		respondAlertError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toAlertRuleResponse(rule))
+}
+
+func (h *AlertHttpHandler) DeleteRule(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var path alertPath
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.service.DeleteRule(ctx.Request.Context(), currentUser.ID, path.ID); err != nil {
+		respondAlertError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *AlertHttpHandler) GetHistory(ctx *gin.Context) {
+	var query alertHistoryQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	h.history(ctx, query.RuleID, query)
+}
+
+func (h *AlertHttpHandler) GetRuleHistory(ctx *gin.Context) {
+	var path alertPath
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	var query alertHistoryQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	h.history(ctx, &path.ID, query)
+}
+
+func (h *AlertHttpHandler) history(ctx *gin.Context, ruleID *int64, query alertHistoryQuery) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	events, err := h.service.History(ctx.Request.Context(), domain.AlertHistoryQuery{
+		UserID: currentUser.ID,
+		RuleID: ruleID,
+		From:   query.From,
+		To:     query.To,
+		Limit:  query.Limit,
+	})
+	if err != nil {
+		respondAlertError(ctx, err)
+		return
+	}
+	resp := make([]AlertEventResponse, 0, len(events))
+	for _, e := range events {
+		resp = append(resp, toAlertEventResponse(e))
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
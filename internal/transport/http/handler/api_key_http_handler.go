@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHttpHandler struct {
+	service *usecase.APIKeyService
+}
+
+func NewAPIKeyHttpHandler(service *usecase.APIKeyService) *APIKeyHttpHandler {
+	return &APIKeyHttpHandler{service: service}
+}
+
+func (h *APIKeyHttpHandler) RegisterAuthRoutes(rg *gin.RouterGroup) {
+	registerRoutes(rg, []route{
+		{"POST", "/keys", h.CreateKey},
+		{"GET", "/keys", h.ListKeys},
+		{"DELETE", "/keys/:id", h.RevokeKey},
+		{"POST", "/keys/:id/rotate", h.RotateKey},
+	})
+}
+
+// CreatedAPIKeyResponse is the only response that carries the plaintext
+// key.
+type CreatedAPIKeyResponse struct {
+	ID        int64      `json:"id"`
+	Key       string     `json:"key"`
+	Prefix    string     `json:"prefix"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+func toCreatedAPIKeyResponse(apiKey *domain.ApiKey, key string) CreatedAPIKeyResponse {
+	return CreatedAPIKeyResponse{
+		ID:        apiKey.ID,
+		Key:       key,
+		Prefix:    apiKey.Prefix,
+		Scopes:    apiKey.Scopes,
+		ExpiresAt: apiKey.ExpiresAt,
+		CreatedAt: apiKey.CreatedAt,
+	}
+}
+
+// respondCreatedAPIKey sends the new key and keeps caches from storing it.
+func respondCreatedAPIKey(ctx *gin.Context, apiKey *domain.ApiKey, key string) {
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusCreated, toCreatedAPIKeyResponse(apiKey, key))
+}
+
+// RotatedAPIKeyResponse is the new key plus when the replaced one stops
+// working.
+type RotatedAPIKeyResponse struct {
+	CreatedAPIKeyResponse
+	ReplacedKeyID        int64     `json:"replacedKeyId"`
+	ReplacedKeyExpiresAt time.Time `json:"replacedKeyExpiresAt"`
+}
+
+func respondRotatedAPIKey(ctx *gin.Context, rotation *usecase.APIKeyRotation) {
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusCreated, RotatedAPIKeyResponse{
+		CreatedAPIKeyResponse: toCreatedAPIKeyResponse(rotation.Key, rotation.Secret),
+		ReplacedKeyID:         rotation.Replaced.ID,
+		ReplacedKeyExpiresAt:  *rotation.Replaced.ExpiresAt,
+	})
+}
+
+// APIKeyResponse describes a key without revealing it.
+type APIKeyResponse struct {
+	ID         int64      `json:"id"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	LastUsedIP string     `json:"lastUsedIp"`
+	// ReplacedByKeyID is set on keys in their rotation grace period.
+	ReplacedByKeyID *int64    `json:"replacedByKeyId,omitempty"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+func toAPIKeyResponses(keys []*domain.ApiKey) []APIKeyResponse {
+	resp := make([]APIKeyResponse, 0, len(keys))
+	for _, k := range keys {
+		resp = append(resp, APIKeyResponse{
+			ID:              k.ID,
+			Prefix:          k.Prefix,
+			Scopes:          k.Scopes,
+			ExpiresAt:       k.ExpiresAt,
+			LastUsedAt:      k.LastUsedAt,
+			LastUsedIP:      k.LastUsedIP,
+			ReplacedByKeyID: k.ReplacedBy,
+			CreatedAt:       k.CreatedAt,
+		})
+	}
+	return resp
+}
+
+type createAPIKeyRequest struct {
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (r createAPIKeyRequest) toInput() usecase.APIKeyInput {
+	return usecase.APIKeyInput{Scopes: r.Scopes, ExpiresAt: r.ExpiresAt}
+}
+
+type rotateAPIKeyRequest struct {
+	// GracePeriod is a Go duration string such as "1h"; "0s" ends the old
+	// key right away.
+	GracePeriod *string `json:"grace_period"`
+}
+
+// bindRotateAPIKeyRequest reads the optional body of a rotation and returns
+// the requested grace period, or nil for the default.
+func bindRotateAPIKeyRequest(ctx *gin.Context) (*time.Duration, error) {
+	var req rotateAPIKeyRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			return nil, err
+		}
+	}
+	if req.GracePeriod == nil {
+		return nil, nil
+	}
+	d, err := time.ParseDuration(*req.GracePeriod)
+	if err != nil {
+		return nil, usecase.ErrInvalidGracePeriod
+	}
+	return &d, nil
+}
+
+type apiKeyPath struct {
+	ID int64 `uri:"id" binding:"required"`
+}
+
+func respondAPIKeyError(ctx *gin.Context, err error) {
+	var scopeErr *usecase.ScopeError
+	switch {
+	case errors.As(err, &scopeErr), errors.Is(err, usecase.ErrNoScopes),
+		errors.Is(err, usecase.ErrInvalidAPIKeyExpiry), errors.Is(err, usecase.ErrInvalidGracePeriod):
+		respondError(ctx, http.StatusBadRequest, err)
+	case errors.Is(err, usecase.ErrUserNotFound), errors.Is(err, usecase.ErrAPIKeyNotFound):
+		respondError(ctx, http.StatusNotFound, err)
+	case errors.Is(err, usecase.ErrAPIKeyLimitExceeded):
+		respondError(ctx, http.StatusForbidden, err)
+	case errors.Is(err, usecase.ErrAPIKeyRotated):
+		respondError(ctx, http.StatusConflict, err)
+	default:
+		respondError(ctx, http.StatusInternalServerError, err)
+	}
+}
+
+func (h *APIKeyHttpHandler) CreateKey(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var req createAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	apiKey, key, err := h.service.Create(ctx.Request.Context(), currentUser, req.toInput())
+	if err != nil {
+		respondAPIKeyError(ctx, err)
+		return
+	}
+	respondCreatedAPIKey(ctx, apiKey, key)
+}
+
+// ListKeys returns the caller's keys that are not revoked or expired.
+func (h *APIKeyHttpHandler) ListKeys(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	keys, err := h.service.List(ctx.Request.Context(), currentUser.ID)
+	if err != nil {
+		respondAPIKeyError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toAPIKeyResponses(keys))
+}
+
+func (h *APIKeyHttpHandler) RevokeKey(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var path apiKeyPath
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.service.Revoke(ctx.Request.Context(), currentUser, path.ID); err != nil {
+		respondAPIKeyError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// RotateKey issues a replacement for the key; the old one keeps working
+// for the grace period.
+func (h *APIKeyHttpHandler) RotateKey(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var path apiKeyPath
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	grace, err := bindRotateAPIKeyRequest(ctx)
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	rotation, err := h.service.Rotate(ctx.Request.Context(), currentUser, path.ID, grace)
+	if err != nil {
+		respondAPIKeyError(ctx, err)
+		return
+	}
+	respondRotatedAPIKey(ctx, rotation)
+}
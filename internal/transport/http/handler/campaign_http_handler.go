@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CampaignHttpHandler struct {
+	service *usecase.CampaignService
+}
+
+func NewCampaignHttpHandler(service *usecase.CampaignService) *CampaignHttpHandler {
+	return &CampaignHttpHandler{service: service}
+}
+
+func (h *CampaignHttpHandler) RegisterAuthRoutes(rg *gin.RouterGroup) {
+	registerRoutes(rg, []route{
+		{"POST", "/campaigns", h.CreateCampaign},
+		{"GET", "/campaigns", h.ListCampaigns},
+		{"GET", "/campaigns/:id", h.GetCampaign},
+		{"PUT", "/campaigns/:id", h.UpdateCampaign},
+		{"DELETE", "/campaigns/:id", h.DeleteCampaign},
+		{"POST", "/campaigns/:id/links", h.AssignLinks},
+		{"DELETE", "/campaigns/:id/links/:shortCode", h.UnassignLink},
+		{"GET", "/campaigns/:id/stats", h.GetCampaignStats},
+	})
+}
+
+type CampaignResponse struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	StartsAt  *time.Time `json:"startsAt"`
+	EndsAt    *time.Time `json:"endsAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt *time.Time `json:"updatedAt"`
+}
+
+type CampaignStatsResponse struct {
+	CampaignID     int64                `json:"campaignId"`
+	From           *time.Time           `json:"from"`
+	To             *time.Time           `json:"to"`
+	IncludeBots    bool                 `json:"includeBots"`
+	Links          int                  `json:"links"`
+	Clicks         int64                `json:"clicks"`
+	UniqueVisitors int64                `json:"uniqueVisitors"`
+	LastClicked    *time.Time           `json:"lastClicked"`
+	TopReferrers   []ReferrerResponse   `json:"topReferrers"`
+	TimeSeries     []TimeBucketResponse `json:"timeSeries"`
+}
+
+type ReferrerResponse struct {
+	Referrer string `json:"referrer"`
+	Clicks   int64  `json:"clicks"`
+}
+
+type TimeBucketResponse struct {
+	Start          time.Time `json:"start"`
+	Clicks         int64     `json:"clicks"`
+	UniqueVisitors *int64    `json:"uniqueVisitors,omitempty"`
+}
+
+type campaignRequest struct {
+	Name     string     `json:"name" binding:"required"`
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+}
+
+type campaignPath struct {
+	ID int64 `uri:"id" binding:"required"`
+}
+
+func toCampaignResponse(c *domain.Campaign) CampaignResponse {
+	return CampaignResponse{
+		ID:        c.ID,
+		Name:      c.Name,
+		StartsAt:  c.StartsAt,
+		EndsAt:    c.EndsAt,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+func respondCampaignError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrCampaignNotFound):
+		respondError(ctx, http.StatusNotFound, err)
+	case errors.Is(err, usecase.ErrInvalidCampaignName), errors.Is(err, usecase.ErrInvalidCampaignRange):
+		respondError(ctx, http.StatusBadRequest, err)
+	default:
+		respondError(ctx, http.StatusInternalServerError, err)
+	}
+}
+
+func (h *CampaignHttpHandler) CreateCampaign(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var req campaignRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	campaign, err := h.service.CreateCampaign(ctx.Request.Context(), currentUser.ID, req.Name, req.StartsAt, req.EndsAt)
+	if err != nil {
+		respondCampaignError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, toCampaignResponse(campaign))
+}
+
+func (h *CampaignHttpHandler) ListCampaigns(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	campaigns, err := h.service.ListCampaigns(ctx.Request.Context(), currentUser.ID)
+	if err != nil {
+		respondCampaignError(ctx, err)
+		return
+	}
+	resp := make([]CampaignResponse, 0, len(campaigns))
+	for _, c := range campaigns {
+		resp = append(resp, toCampaignResponse(c))
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *CampaignHttpHandler) GetCampaign(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var path campaignPath
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	campaign, err := h.service.GetCampaign(ctx.Request.Context(), currentUser.ID, path.ID)
+	if err != nil {
+		respondCampaignError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toCampaignResponse(campaign))
+}
+
+func (h *CampaignHttpHandler) UpdateCampaign(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var path campaignPath
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	var req campaignRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	campaign, err := h.service.UpdateCampaign(ctx.Request.Context(), currentUser.ID, path.ID, req.Name, req.StartsAt, req.EndsAt)
+	if err != nil {
+		respondCampaignError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toCampaignResponse(campaign))
+}
+
+func (h *CampaignHttpHandler) DeleteCampaign(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var path campaignPath
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.service.DeleteCampaign(ctx.Request.Context(), currentUser.ID, path.ID); err != nil {
+		respondCampaignError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *CampaignHttpHandler) AssignLinks(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var path campaignPath
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	var req struct {
+		ShortCodes []string `json:"short_codes" binding:"required,min=1"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	n, err := h.service.AssignLinks(ctx.Request.Context(), currentUser.ID, path.ID, req.ShortCodes)
+	if err != nil {
+		respondCampaignError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"assigned": n})
+}
+
+func (h *CampaignHttpHandler) UnassignLink(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var path struct {
+		ID        int64  `uri:"id" binding:"required"`
+		ShortCode string `uri:"shortCode" binding:"required"`
+	}
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.service.UnassignLink(ctx.Request.Context(), currentUser.ID, path.ID, path.ShortCode); err != nil {
+		respondCampaignError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *CampaignHttpHandler) GetCampaignStats(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var path campaignPath
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	var query struct {
+		From        *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+		To          *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+		IncludeBots bool       `form:"include_bots"`
+	}
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	stats, err := h.service.GetCampaignStats(ctx.Request.Context(), currentUser.ID, path.ID, query.From, query.To, query.IncludeBots)
+	if err != nil {
+		respondCampaignError(ctx, err)
+		return
+	}
+
+	series := make([]TimeBucketResponse, 0, len(stats.TimeSeries))
+	for _, b := range stats.TimeSeries {
+		series = append(series, TimeBucketResponse{Start: b.Start, Clicks: b.Clicks, UniqueVisitors: b.UniqueVisitors})
+	}
+	referrers := make([]ReferrerResponse, 0, len(stats.TopReferrers))
+	for _, r := range stats.TopReferrers {
+		referrers = append(referrers, ReferrerResponse{Referrer: r.Referrer, Clicks: r.Clicks})
+	}
+	ctx.JSON(http.StatusOK, CampaignStatsResponse{
+		CampaignID:     stats.CampaignID,
+		From:           stats.From,
+		To:             stats.To,
+		IncludeBots:    stats.IncludeBots,
+		Links:          stats.Links,
+		Clicks:         stats.Clicks,
+		UniqueVisitors: stats.UniqueVisitors,
+		LastClicked:    stats.LastClickedAt,
+		TopReferrers:   referrers,
+		TimeSeries:     series,
+	})
+}
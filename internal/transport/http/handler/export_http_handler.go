@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/export"
+	"url-shortener/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportHttpHandler struct {
+	service *usecase.ExportService
+}
+
+func NewExportHttpHandler(service *usecase.ExportService) *ExportHttpHandler {
+	return &ExportHttpHandler{service: service}
+}
+
+// RegisterAuthRoutes streams exports on GET and starts background jobs on
+// POST. Both only read, so rg should require the read scope either way.
+func (h *ExportHttpHandler) RegisterAuthRoutes(rg *gin.RouterGroup) {
+	registerRoutes(rg, []route{
+		{"GET", "/links/:shortCode/events/export", h.ExportLinkEvents},
+		{"POST", "/links/:shortCode/events/export", h.ExportLinkEvents},
+		{"GET", "/events/export", h.ExportAccountEvents},
+		{"POST", "/events/export", h.ExportAccountEvents},
+		{"GET", "/exports/:token", h.GetExport},
+		{"GET", "/exports/:token/download", h.DownloadExport},
+	})
+}
+
+func (h *ExportHttpHandler) RegisterAdminRoutes(rg *gin.RouterGroup) {
+	registerRoutes(rg, []route{
+		{"GET", "/events/export", h.ExportAllEvents},
+		{"POST", "/events/export", h.ExportAllEvents},
+	})
+}
+
+type exportQuery struct {
+	From    *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To      *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Format  string     `form:"format"`
+	Dataset string     `form:"dataset"`
+	// UserID narrows an admin export to one account.
+	UserID *int64 `form:"user_id"`
+}
+
+func (q exportQuery) toDomain() domain.ExportQuery {
+	return domain.ExportQuery{Format: q.Format, Dataset: q.Dataset, From: q.From, To: q.To}
+}
+
+type ExportJobResponse struct {
+	Token       string     `json:"token"`
+	Status      string     `json:"status"`
+	Format      string     `json:"format"`
+	Dataset     string     `json:"dataset"`
+	From        *time.Time `json:"from,omitempty"`
+	To          *time.Time `json:"to,omitempty"`
+	Rows        int64      `json:"rows"`
+	SizeBytes   int64      `json:"sizeBytes"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	StatusURL   string     `json:"statusUrl"`
+	DownloadURL string     `json:"downloadUrl,omitempty"`
+}
+
+func toExportJobResponse(job *domain.ExportJob) ExportJobResponse {
+	resp := ExportJobResponse{
+		Token:      job.Token,
+		Status:     job.Status,
+		Format:     job.Query.Format,
+		Dataset:    job.Query.Dataset,
+		From:       job.Query.From,
+		To:         job.Query.To,
+		Rows:       job.Rows,
+		SizeBytes:  job.SizeBytes,
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		FinishedAt: job.FinishedAt,
+		ExpiresAt:  job.ExpiresAt,
+		StatusURL:  "/api/exports/" + job.Token,
+	}
+	if job.Status == domain.ExportJobDone {
+		resp.DownloadURL = resp.StatusURL + "/download"
+	}
+	return resp
+}
+
+func respondExportError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrLinkNotFound), errors.Is(err, usecase.ErrExportNotFound):
+		respondError(ctx, http.StatusNotFound, err)
+	case errors.Is(err, usecase.ErrExportNotReady):
+		respondError(ctx, http.StatusConflict, err)
+	case errors.Is(err, usecase.ErrInvalidExportFormat), errors.Is(err, usecase.ErrInvalidExportDataset),
+		errors.Is(err, usecase.ErrInvalidExportRange):
+		respondError(ctx, http.StatusBadRequest, err)
+	default:
+		respondError(ctx, http.StatusInternalServerError, err)
+	}
+}
+
+func (h *ExportHttpHandler) ExportLinkEvents(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var query exportQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	shortCode := ctx.Param("shortCode")
+	q, err := h.service.LinkQuery(ctx.Request.Context(), currentUser.ID, shortCode, query.toDomain())
+	if err != nil {
+		respondExportError(ctx, err)
+		return
+	}
+	h.export(ctx, currentUser.ID, q, isAsyncExport(ctx), shortCode)
+}
+
+func (h *ExportHttpHandler) ExportAccountEvents(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var query exportQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	q, err := h.service.AccountQuery(currentUser.ID, query.toDomain())
+	if err != nil {
+		respondExportError(ctx, err)
+		return
+	}
+	h.export(ctx, currentUser.ID, q, isAsyncExport(ctx), "account")
+}
+
+func (h *ExportHttpHandler) ExportAllEvents(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var query exportQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	q := query.toDomain()
+	q.UserID = query.UserID
+	q, err := h.service.AdminQuery(q)
+	if err != nil {
+		respondExportError(ctx, err)
+		return
+	}
+	scope := "all"
+	if q.UserID != nil {
+		scope = "user-" + strconv.FormatInt(*q.UserID, 10)
+	}
+	h.export(ctx, currentUser.ID, q, isAsyncExport(ctx), scope)
+}
+
+// isAsyncExport reports whether the request starts a background job rather
+// than streaming the export.
+func isAsyncExport(ctx *gin.Context) bool {
+	return ctx.Request.Method == http.MethodPost
+}
+
+// export either queues a background job or streams the rows straight into
+// the response. A streamed export that fails midway cannot change its
+// status any more, so the outcome is also sent in trailers.
+func (h *ExportHttpHandler) export(ctx *gin.Context, userID int64, q domain.ExportQuery, async bool, scope string) {
+	if async {
+		job, err := h.service.StartJob(ctx.Request.Context(), userID, q)
+		if err != nil {
+			respondExportError(ctx, err)
+			return
+		}
+		resp := toExportJobResponse(job)
+		ctx.Header("Location", resp.StatusURL)
+		ctx.JSON(http.StatusAccepted, resp)
+		return
+	}
+
+	filename := fmt.Sprintf("clicks-%s-%s-%s.%s", scope, q.Dataset, time.Now().UTC().Format("20060102"), q.Format)
+	ctx.Header("Content-Type", export.ContentType(q.Format))
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	ctx.Header("Trailer", "X-Export-Rows, X-Export-Error")
+	rows, err := h.service.Stream(ctx.Request.Context(), q, ctx.Writer)
+	if err != nil && !ctx.Writer.Written() {
+		ctx.Writer.Header().Del("Content-Disposition")
+		ctx.Writer.Header().Del("Trailer")
+		respondExportError(ctx, err)
+		return
+	}
+	ctx.Writer.Header().Set("X-Export-Rows", strconv.FormatInt(rows, 10))
+	if err != nil {
+		log.Printf("Export stream failed after %d rows: %v", rows, err)
+		ctx.Writer.Header().Set("X-Export-Error", err.Error())
+	}
+}
+
+func (h *ExportHttpHandler) GetExport(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	job, err := h.service.GetJob(ctx.Request.Context(), currentUser.ID, ctx.Param("token"))
+	if err != nil {
+		respondExportError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toExportJobResponse(job))
+}
+
+func (h *ExportHttpHandler) DownloadExport(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	job, path, err := h.service.JobFile(ctx.Request.Context(), currentUser.ID, ctx.Param("token"))
+	if err != nil {
+		respondExportError(ctx, err)
+		return
+	}
+	ctx.Header("Content-Type", export.ContentType(job.Query.Format))
+	ctx.FileAttachment(path, fmt.Sprintf("clicks-%s.%s", job.CreatedAt.UTC().Format("20060102-150405"), job.Query.Format))
+}
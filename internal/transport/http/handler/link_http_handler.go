@@ -4,9 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 	"url-shortener/internal/domain"
-	"url-shortener/internal/transport/middleware"
+	"url-shortener/internal/metrics"
 	"url-shortener/internal/usecase"
 
 	"github.com/gin-gonic/gin"
@@ -14,10 +15,11 @@ import (
 
 type LinkHttpHandler struct {
 	service *usecase.ShortenerService
+	metrics *metrics.Metrics
 }
 
-func NewLinkHttpHandler(service *usecase.ShortenerService) *LinkHttpHandler {
-	return &LinkHttpHandler{service: service}
+func NewLinkHttpHandler(service *usecase.ShortenerService, m *metrics.Metrics) *LinkHttpHandler {
+	return &LinkHttpHandler{service: service, metrics: m}
 }
 
 type route struct {
@@ -26,44 +28,28 @@ type route struct {
 	handler     gin.HandlerFunc
 }
 
+func registerRoutes(rg *gin.RouterGroup, routes []route) {
+	for _, r := range routes {
+		rg.Handle(r.method, r.relativeURL, r.handler)
+	}
+}
+
 func (h *LinkHttpHandler) RegisterAuthRoutes(rg *gin.RouterGroup) {
-	authRoutes := []route{
+	registerRoutes(rg, []route{
 		{"POST", "/links", h.CreateShortLink},
 		{"GET", "/links", h.GetLinksByUser},
+		{"PATCH", "/links/:shortCode", h.UpdateLink},
 		{"DELETE", "/links/:shortCode", h.SoftDeleteLink},
-	}
-	for _, r := range authRoutes {
-		switch r.method {
-		case "POST":
-			rg.POST(r.relativeURL, r.handler)
-		case "GET":
-			rg.GET(r.relativeURL, r.handler)
-		case "DELETE":
-			rg.DELETE(r.relativeURL, r.handler)
-		}
-	}
+	})
 }
 
 func (h *LinkHttpHandler) RegisterPublicRoutes(rg *gin.RouterGroup) {
-	publicRoutes := []route{
+	registerRoutes(rg, []route{
 		{"GET", "/:shortCode", h.ResolveShortCode},
-	}
-	for _, r := range publicRoutes {
-		switch r.method {
-		case "GET":
-			rg.GET(r.relativeURL, r.handler)
-		}
-	}
-}
-
-func (h *LinkHttpHandler) RegisterRoutes(r *gin.Engine, userRepo usecase.UserRepository) {
-	// Auth group
-	api := r.Group("/api")
-	api.Use(middleware.ApiKeyAuth(userRepo))
-	h.RegisterAuthRoutes(api)
-	// Public group
-	public := r.Group("/")
-	h.RegisterPublicRoutes(public)
+		// Link checkers and unfurlers often probe with HEAD; they are
+		// redirected like everyone else and recorded as bot clicks.
+		{"HEAD", "/:shortCode", h.ResolveShortCode},
+	})
 }
 
 // Helper for base URL
@@ -84,20 +70,127 @@ func respondError(ctx *gin.Context, status int, err error) {
 	ctx.JSON(status, gin.H{"error": err.Error()})
 }
 
+// Helper capturing the request data recorded for a click
+func clickContext(ctx *gin.Context) domain.ClickContext {
+	source := ctx.Query("utm_source")
+	if source == "" {
+		source = ctx.Query("src")
+	}
+	if source == "" {
+		source = ctx.Query("ref")
+	}
+	return domain.ClickContext{
+		Referrer:       ctx.Request.Referer(),
+		UserAgent:      ctx.Request.UserAgent(),
+		IP:             ctx.ClientIP(),
+		AcceptLanguage: ctx.GetHeader("Accept-Language"),
+		Source:         source,
+		DoNotTrack:     ctx.GetHeader("DNT") == "1" || ctx.GetHeader("Sec-GPC") == "1",
+		At:             time.Now(),
+		Method:         ctx.Request.Method,
+		Accept:         ctx.GetHeader("Accept"),
+		Purpose:        firstHeader(ctx, "Sec-Purpose", "Purpose", "X-Purpose", "X-Moz"),
+	}
+}
+
+func firstHeader(ctx *gin.Context, names ...string) string {
+	for _, name := range names {
+		if v := ctx.GetHeader(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Helper mapping usecase errors on link create/update to HTTP responses
+func respondLinkError(ctx *gin.Context, err error) {
+	var invalid *usecase.URLValidationError
+	switch {
+	case errors.As(err, &invalid):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": invalid.Message, "code": invalid.Code})
+	case errors.Is(err, usecase.ErrLinkAlreadyExists):
+		respondError(ctx, http.StatusConflict, err)
+	case errors.Is(err, usecase.ErrLinkNotFound), errors.Is(err, usecase.ErrCampaignNotFound):
+		respondError(ctx, http.StatusNotFound, err)
+	case errors.Is(err, usecase.ErrURLFlagged):
+		respondError(ctx, http.StatusUnprocessableEntity, err)
+	case errors.Is(err, usecase.ErrLinkLimitExceeded):
+		respondError(ctx, http.StatusForbidden, err)
+	default:
+		respondError(ctx, http.StatusInternalServerError, err)
+	}
+}
+
+// Helper classifying a create error for the link_creations_total metric
+func linkCreationOutcome(err error) string {
+	var invalid *usecase.URLValidationError
+	switch {
+	case err == nil:
+		return metrics.LinkCreated
+	case errors.Is(err, usecase.ErrLinkLimitExceeded):
+		return metrics.LinkLimitExceeded
+	case errors.Is(err, usecase.ErrLinkAlreadyExists):
+		return metrics.LinkDuplicate
+	case errors.Is(err, usecase.ErrMaxRetriesExceeded):
+		return metrics.LinkCollision
+	case errors.As(err, &invalid), errors.Is(err, usecase.ErrURLFlagged), errors.Is(err, usecase.ErrCampaignNotFound):
+		return metrics.LinkInvalid
+	default:
+		return metrics.LinkError
+	}
+}
+
 // Response struct
 type LinkResponse struct {
-	ShortURL    string     `json:"shortURL"`
-	LongURL     string     `json:"longURL"`
-	ClickCount  int64      `json:"clickCount"`
-	LastClicked *time.Time `json:"lastClicked"`
-	CreatedAt   time.Time  `json:"createdAt"`
+	ShortURL      string         `json:"shortURL"`
+	LongURL       string         `json:"longURL"`
+	CampaignID    *int64         `json:"campaignId,omitempty"`
+	ClickCount    int64          `json:"clickCount"`
+	BotClickCount int64          `json:"botClickCount"`
+	LastClicked   *time.Time     `json:"lastClicked"`
+	Health        HealthResponse `json:"health"`
+	FlaggedAt     *time.Time     `json:"flaggedAt,omitempty"`
+	FlagReason    string         `json:"flagReason,omitempty"`
+	CreatedAt     time.Time      `json:"createdAt"`
+}
+
+type HealthResponse struct {
+	Status        string     `json:"status"`
+	StatusCode    int        `json:"statusCode,omitempty"`
+	LatencyMs     int64      `json:"latencyMs,omitempty"`
+	RedirectChain []string   `json:"redirectChain,omitempty"`
+	Error         string     `json:"error,omitempty"`
+	CheckedAt     *time.Time `json:"checkedAt"`
+}
+
+func toLinkResponse(baseURL string, link *domain.Link) LinkResponse {
+	return LinkResponse{
+		ShortURL:      fmt.Sprintf("%s/%s", baseURL, link.ShortCode),
+		LongURL:       link.LongURL,
+		CampaignID:    link.CampaignID,
+		ClickCount:    link.ClickCount,
+		BotClickCount: link.BotClickCount,
+		LastClicked:   link.LastClickedAt,
+		Health: HealthResponse{
+			Status:        link.HealthStatus,
+			StatusCode:    link.HealthStatusCode,
+			LatencyMs:     link.HealthLatencyMs,
+			RedirectChain: link.HealthRedirectChain,
+			Error:         link.HealthError,
+			CheckedAt:     link.HealthCheckedAt,
+		},
+		FlaggedAt:  link.FlaggedAt,
+		FlagReason: link.FlagReason,
+		CreatedAt:  link.CreatedAt,
+	}
 }
 
 func (h *LinkHttpHandler) CreateShortLink(ctx *gin.Context) {
 	currentUser := ctx.MustGet("currentUser").(*domain.User)
 
 	var r struct {
-		LongURL string `json:"long_url"`
+		LongURL    string `json:"long_url"`
+		CampaignID *int64 `json:"campaign_id"`
 	}
 
 	if err := ctx.ShouldBindJSON(&r); err != nil {
@@ -105,13 +198,10 @@ func (h *LinkHttpHandler) CreateShortLink(ctx *gin.Context) {
 		return
 	}
 
-	link, err := h.service.CreateShortLink(ctx.Request.Context(), currentUser.ID, r.LongURL)
+	link, err := h.service.CreateShortLink(ctx.Request.Context(), currentUser.ID, r.LongURL, r.CampaignID)
+	h.metrics.ObserveLinkCreation(linkCreationOutcome(err))
 	if err != nil {
-		if errors.Is(err, usecase.ErrLinkAlreadyExists) {
-			respondError(ctx, http.StatusConflict, err)
-		} else {
-			respondError(ctx, http.StatusInternalServerError, err)
-		}
+		respondLinkError(ctx, err)
 		return
 	}
 
@@ -129,22 +219,50 @@ func (h *LinkHttpHandler) ResolveShortCode(ctx *gin.Context) {
 		return
 	}
 
-	link, err := h.service.ResolveLink(ctx.Request.Context(), shortCode)
+	link, err := h.service.ResolveLink(ctx.Request.Context(), shortCode, clickContext(ctx))
 	if err != nil {
-		if errors.Is(err, usecase.ErrLinkNotFound) {
+		var flagged *usecase.FlaggedLinkError
+		var invalid *usecase.URLValidationError
+		if errors.As(err, &flagged) {
+			h.metrics.ObserveRedirect(metrics.RedirectBlocked)
+			renderFlaggedLink(ctx, flagged.LongURL)
+		} else if errors.As(err, &invalid) {
+			h.metrics.ObserveRedirect(metrics.RedirectBlocked)
+			if invalid == usecase.ErrRedirectLoop || invalid == usecase.ErrRedirectTooDeep {
+				ctx.JSON(http.StatusLoopDetected, gin.H{"error": invalid.Message, "code": invalid.Code})
+			} else {
+				ctx.JSON(http.StatusForbidden, gin.H{"error": "link destination is not allowed", "code": invalid.Code})
+			}
+		} else if errors.Is(err, usecase.ErrLinkNotFound) {
+			h.metrics.ObserveRedirect(metrics.RedirectNotFound)
 			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		} else {
+			h.metrics.ObserveRedirect(metrics.RedirectError)
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve link"})
 		}
 		return
 	}
+	h.metrics.ObserveRedirect(metrics.RedirectFound)
 
 	ctx.Redirect(http.StatusFound, link)
 }
 
 func (h *LinkHttpHandler) GetLinksByUser(ctx *gin.Context) {
 	currentUser := ctx.MustGet("currentUser").(*domain.User)
-	links, err := h.service.ListLinksByUser(ctx, currentUser.ID)
+	filter := domain.LinkFilter{HealthStatus: ctx.Query("health")}
+	if filter.HealthStatus != "" && !domain.IsValidLinkHealth(filter.HealthStatus) {
+		respondError(ctx, http.StatusBadRequest, errors.New("health must be one of unknown, healthy, degraded, broken"))
+		return
+	}
+	if v := ctx.Query("campaign_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			respondError(ctx, http.StatusBadRequest, errors.New("campaign_id must be an integer"))
+			return
+		}
+		filter.CampaignID = &id
+	}
+	links, err := h.service.ListLinksByUser(ctx, currentUser.ID, filter)
 	if err != nil {
 		respondError(ctx, http.StatusInternalServerError, err)
 		return
@@ -153,17 +271,40 @@ func (h *LinkHttpHandler) GetLinksByUser(ctx *gin.Context) {
 	baseURL := getRequestBaseURL(ctx)
 	resp := make([]LinkResponse, 0, len(links))
 	for _, link := range links {
-		resp = append(resp, LinkResponse{
-			ShortURL:    fmt.Sprintf("%s/%s", baseURL, link.ShortCode),
-			LongURL:     link.LongURL,
-			ClickCount:  link.ClickCount,
-			LastClicked: link.LastClickedAt,
-			CreatedAt:   link.CreatedAt,
-		})
+		resp = append(resp, toLinkResponse(baseURL, link))
 	}
 	ctx.JSON(http.StatusOK, resp)
 }
 
+func (h *LinkHttpHandler) UpdateLink(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	shortCode := ctx.Param("shortCode")
+	if shortCode == "" {
+		respondError(ctx, http.StatusBadRequest, errors.New("short code is required"))
+		return
+	}
+
+	var r struct {
+		LongURL string `json:"long_url"`
+	}
+	if err := ctx.ShouldBindJSON(&r); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	link, err := h.service.UpdateLongURL(ctx.Request.Context(), currentUser.ID, shortCode, r.LongURL)
+	if err != nil {
+		respondLinkError(ctx, err)
+		return
+	}
+
+	baseURL := getRequestBaseURL(ctx)
+	ctx.JSON(http.StatusOK, gin.H{
+		"shortened_url": fmt.Sprintf("%s/%s", baseURL, link.ShortCode),
+		"long_url":      link.LongURL,
+	})
+}
+
 func (h *LinkHttpHandler) SoftDeleteLink(ctx *gin.Context) {
 	currentUser := ctx.MustGet("currentUser").(*domain.User)
 	shortCode := ctx.Param("shortCode")
@@ -171,7 +312,7 @@ func (h *LinkHttpHandler) SoftDeleteLink(ctx *gin.Context) {
 		respondError(ctx, http.StatusBadRequest, errors.New("short code is required"))
 		return
 	}
-	err := h.service.SoftDeleteByCode(ctx, currentUser.ID, shortCode)
+	err := h.service.SoftDeleteByCode(ctx.Request.Context(), currentUser.ID, shortCode)
 	if err != nil {
 		respondError(ctx, http.StatusInternalServerError, err)
 		return
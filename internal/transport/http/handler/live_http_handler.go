@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LiveHttpHandler struct {
+	hub          *usecase.LiveClickHub
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+}
+
+func NewLiveHttpHandler(hub *usecase.LiveClickHub) *LiveHttpHandler {
+	return &LiveHttpHandler{hub: hub, shutdown: make(chan struct{})}
+}
+
+// Shutdown ends the open streams, which would otherwise keep a graceful
+// server shutdown waiting until it times out.
+func (h *LiveHttpHandler) Shutdown() {
+	h.shutdownOnce.Do(func() { close(h.shutdown) })
+}
+
+func (h *LiveHttpHandler) RegisterAuthRoutes(rg *gin.RouterGroup) {
+	registerRoutes(rg, []route{
+		{"GET", "/links/:shortCode/live", h.StreamLinkClicks},
+		{"GET", "/live", h.StreamAccountClicks},
+	})
+}
+
+type liveQuery struct {
+	IncludeBots bool `form:"include_bots"`
+}
+
+type LiveClickResponse struct {
+	ShortCode string    `json:"shortCode"`
+	ClickedAt time.Time `json:"clickedAt"`
+	Country   string    `json:"country"`
+	Device    string    `json:"device"`
+	Referrer  string    `json:"referrer"`
+	IsBot     bool      `json:"isBot"`
+}
+
+func toLiveClickResponse(c domain.LiveClick) LiveClickResponse {
+	return LiveClickResponse{
+		ShortCode: c.ShortCode,
+		ClickedAt: c.ClickedAt,
+		Country:   c.Country,
+		Device:    c.Device,
+		Referrer:  c.Referrer,
+		IsBot:     c.IsBot,
+	}
+}
+
+func respondLiveError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrLinkNotFound):
+		respondError(ctx, http.StatusNotFound, err)
+	case errors.Is(err, usecase.ErrTooManyLiveStreams):
+		respondError(ctx, http.StatusTooManyRequests, err)
+	default:
+		respondError(ctx, http.StatusInternalServerError, err)
+	}
+}
+
+func (h *LiveHttpHandler) StreamLinkClicks(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var query liveQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	sub, err := h.hub.SubscribeLink(ctx.Request.Context(), currentUser.ID, ctx.Param("shortCode"), query.IncludeBots)
+	if err != nil {
+		respondLiveError(ctx, err)
+		return
+	}
+	h.stream(ctx, sub)
+}
+
+func (h *LiveHttpHandler) StreamAccountClicks(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var query liveQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	sub, err := h.hub.SubscribeAccount(currentUser.ID, query.IncludeBots)
+	if err != nil {
+		respondLiveError(ctx, err)
+		return
+	}
+	h.stream(ctx, sub)
+}
+
+// stream sends each click as a "click" event until the client goes away
+// or the server shuts down. Comment lines keep idle connections open
+// through proxies.
+func (h *LiveHttpHandler) stream(ctx *gin.Context, sub *usecase.LiveSubscription) {
+	defer sub.Close()
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.WriteHeaderNow()
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.hub.Heartbeat())
+	defer heartbeat.Stop()
+	done := ctx.Request.Context().Done()
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case <-done:
+			return false
+		case <-h.shutdown:
+			return false
+		case click, ok := <-sub.Clicks():
+			if !ok {
+				return false
+			}
+			ctx.SSEvent("click", toLiveClickResponse(click))
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		}
+	})
+}
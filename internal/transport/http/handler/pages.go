@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+	"url-shortener/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+var flaggedLinkPage = template.Must(template.New("flagged").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>Link disabled</title>
+</head>
+<body>
+<h1>This link has been disabled</h1>
+<p>The destination of this short link was flagged as potentially harmful and is no longer being redirected.</p>
+<p>Destination (not linked): <code>{{.LongURL}}</code></p>
+</body>
+</html>`))
+
+func renderPage(ctx *gin.Context, status int, tmpl *template.Template, data any) {
+	ctx.Status(status)
+	ctx.Header("Content-Type", "text/html; charset=utf-8")
+	if err := tmpl.Execute(ctx.Writer, data); err != nil {
+		_ = ctx.Error(err)
+	}
+}
+
+// renderFlaggedLink shows visitors a generic warning; the flag reason can
+// name blocklist rules, so only the owner and admins get to see it.
+func renderFlaggedLink(ctx *gin.Context, longURL string) {
+	renderPage(ctx, http.StatusForbidden, flaggedLinkPage, gin.H{"LongURL": longURL})
+}
+
+var publicStatsPage = template.Must(template.New("public-stats").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<meta name="referrer" content="no-referrer">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Click statistics for /{{.ShortCode}}</title>
+<style>
+body { font-family: sans-serif; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2rem; }
+td, th { padding: .25rem .5rem; text-align: left; }
+td.n { text-align: right; white-space: nowrap; }
+.bar { background: #4a7bd0; height: .8rem; }
+</style>
+</head>
+<body>
+<h1>Click statistics for /{{.ShortCode}}</h1>
+<p>{{.From}} to {{.To}} ({{.Timezone}}): <strong>{{.Total}}</strong> clicks{{with .UniqueVisitors}}, about <strong>{{.}}</strong> unique visitors{{end}}.</p>
+{{with .ExpiresAt}}<p>This page is available until {{.}}.</p>{{end}}
+<h2>Clicks per {{.Interval}}</h2>
+<table>
+{{range .Buckets}}<tr><td>{{.Label}}</td><td class="n">{{.Clicks}}</td><td style="width:60%"><div class="bar" style="width:{{.Percent}}%"></div></td></tr>
+{{end}}</table>
+{{range .Breakdowns}}<h2>Top {{.Dimension}}</h2>
+<table>
+{{range .Items}}<tr><td>{{.Label}}</td><td class="n">{{.Clicks}}</td><td style="width:60%"><div class="bar" style="width:{{.Percent}}%"></div></td></tr>
+{{else}}<tr><td>No clicks in this range.</td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>`))
+
+var sharePageError = template.Must(template.New("share-error").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>Statistics unavailable</title>
+</head>
+<body>
+<h1>Statistics unavailable</h1>
+<p>{{.Message}}</p>
+</body>
+</html>`))
+
+type pageRow struct {
+	Label   string
+	Clicks  int64
+	Percent int64
+}
+
+func pageRows(labels []string, clicks []int64) []pageRow {
+	var peak int64
+	for _, n := range clicks {
+		peak = max(peak, n)
+	}
+	rows := make([]pageRow, len(labels))
+	for i := range labels {
+		rows[i] = pageRow{Label: labels[i], Clicks: clicks[i]}
+		if peak > 0 {
+			rows[i].Percent = clicks[i] * 100 / peak
+		}
+	}
+	return rows
+}
+
+func renderPublicStats(ctx *gin.Context, stats *domain.PublicStats) {
+	series := stats.Series
+	layout := "2006-01-02"
+	if series.Interval == domain.StatsIntervalHour {
+		layout = "2006-01-02 15:04"
+	}
+	labels := make([]string, len(series.Buckets))
+	clicks := make([]int64, len(series.Buckets))
+	for i, b := range series.Buckets {
+		labels[i] = b.Start.Format(layout)
+		clicks[i] = b.Clicks
+	}
+
+	type breakdown struct {
+		Dimension string
+		Items     []pageRow
+	}
+	breakdowns := make([]breakdown, 0, len(stats.Breakdowns))
+	for _, b := range stats.Breakdowns {
+		labels := make([]string, len(b.Items))
+		clicks := make([]int64, len(b.Items))
+		for i, item := range b.Items {
+			labels[i] = item.Value
+			clicks[i] = item.Clicks
+		}
+		breakdowns = append(breakdowns, breakdown{Dimension: b.Dimension, Items: pageRows(labels, clicks)})
+	}
+
+	data := gin.H{
+		"ShortCode":      stats.Share.ShortCode,
+		"From":           series.From.Format(layout),
+		"To":             series.To.Format(layout),
+		"Timezone":       series.Timezone,
+		"Interval":       series.Interval,
+		"Total":          series.Total,
+		"UniqueVisitors": series.UniqueVisitors,
+		"Buckets":        pageRows(labels, clicks),
+		"Breakdowns":     breakdowns,
+	}
+	if stats.Share.ExpiresAt != nil {
+		data["ExpiresAt"] = stats.Share.ExpiresAt.UTC().Format("2006-01-02 15:04 UTC")
+	}
+	renderPage(ctx, http.StatusOK, publicStatsPage, data)
+}
+
+func renderSharePageError(ctx *gin.Context, status int, message string) {
+	renderPage(ctx, status, sharePageError, gin.H{"Message": message})
+}
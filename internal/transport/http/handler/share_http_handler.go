@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ShareHttpHandler struct {
+	service *usecase.StatsShareService
+}
+
+func NewShareHttpHandler(service *usecase.StatsShareService) *ShareHttpHandler {
+	return &ShareHttpHandler{service: service}
+}
+
+func (h *ShareHttpHandler) RegisterAuthRoutes(rg *gin.RouterGroup) {
+	registerRoutes(rg, []route{
+		{"POST", "/links/:shortCode/shares", h.CreateShare},
+		{"GET", "/links/:shortCode/shares", h.ListShares},
+		{"DELETE", "/links/:shortCode/shares/:id", h.RevokeShare},
+	})
+}
+
+func (h *ShareHttpHandler) RegisterPublicRoutes(rg *gin.RouterGroup) {
+	registerRoutes(rg, []route{
+		{"GET", "/s/:token", h.GetPublicStats},
+	})
+}
+
+type ShareResponse struct {
+	ID         int64      `json:"id"`
+	ShortCode  string     `json:"shortCode"`
+	URL        string     `json:"url"`
+	Dimensions []string   `json:"dimensions"`
+	Active     bool       `json:"active"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+type PublicStatsResponse struct {
+	ShortCode  string              `json:"shortCode"`
+	ExpiresAt  *time.Time          `json:"expiresAt"`
+	Series     ClickSeriesResponse `json:"series"`
+	Breakdowns []BreakdownResponse `json:"breakdowns"`
+}
+
+type shareRequest struct {
+	Dimensions []string   `json:"dimensions"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+type sharePath struct {
+	ShortCode string `uri:"shortCode" binding:"required"`
+	ID        int64  `uri:"id" binding:"required"`
+}
+
+type publicStatsQuery struct {
+	From     *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Interval string     `form:"interval"`
+	TZ       string     `form:"tz"`
+	Format   string     `form:"format"`
+}
+
+func toShareResponse(baseURL string, s *domain.StatsShare) ShareResponse {
+	return ShareResponse{
+		ID:         s.ID,
+		ShortCode:  s.ShortCode,
+		URL:        fmt.Sprintf("%s/s/%s", baseURL, s.Token),
+		Dimensions: s.Dimensions,
+		Active:     s.Active(time.Now()),
+		ExpiresAt:  s.ExpiresAt,
+		RevokedAt:  s.RevokedAt,
+		CreatedAt:  s.CreatedAt,
+	}
+}
+
+func toPublicStatsResponse(stats *domain.PublicStats) PublicStatsResponse {
+	breakdowns := make([]BreakdownResponse, 0, len(stats.Breakdowns))
+	for _, b := range stats.Breakdowns {
+		breakdowns = append(breakdowns, toBreakdownResponse(b))
+	}
+	return PublicStatsResponse{
+		ShortCode:  stats.Share.ShortCode,
+		ExpiresAt:  stats.Share.ExpiresAt,
+		Series:     toClickSeriesResponse(stats.Series),
+		Breakdowns: breakdowns,
+	}
+}
+
+func respondShareError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrLinkNotFound), errors.Is(err, usecase.ErrShareNotFound):
+		respondError(ctx, http.StatusNotFound, err)
+	case errors.Is(err, usecase.ErrShareGone):
+		respondError(ctx, http.StatusGone, err)
+	case errors.Is(err, usecase.ErrInvalidShareExpiry), errors.Is(err, usecase.ErrInvalidStatsDimension):
+		respondError(ctx, http.StatusBadRequest, err)
+	default:
+		respondStatsError(ctx, err)
+	}
+}
+
+func (h *ShareHttpHandler) CreateShare(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var req shareRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	share, err := h.service.CreateShare(ctx.Request.Context(), currentUser.ID, ctx.Param("shortCode"), req.Dimensions, req.ExpiresAt)
+	if err != nil {
+		respondShareError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, toShareResponse(getRequestBaseURL(ctx), share))
+}
+
+func (h *ShareHttpHandler) ListShares(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	shares, err := h.service.ListShares(ctx.Request.Context(), currentUser.ID, ctx.Param("shortCode"))
+	if err != nil {
+		respondShareError(ctx, err)
+		return
+	}
+	baseURL := getRequestBaseURL(ctx)
+	resp := make([]ShareResponse, 0, len(shares))
+	for _, s := range shares {
+		resp = append(resp, toShareResponse(baseURL, s))
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *ShareHttpHandler) RevokeShare(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var path sharePath
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if _, err := h.service.RevokeShare(ctx.Request.Context(), currentUser.ID, path.ShortCode, path.ID); err != nil {
+		respondShareError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// GetPublicStats serves a share as an HTML page to browsers and as JSON
+// to everything else; format=html|json overrides the Accept header.
+func (h *ShareHttpHandler) GetPublicStats(ctx *gin.Context) {
+	// The token is in the URL; keep it out of Referer headers and indexes.
+	ctx.Header("Referrer-Policy", "no-referrer")
+	ctx.Header("X-Robots-Tag", "noindex")
+	ctx.Header("Cache-Control", "private, max-age=60")
+
+	var query publicStatsQuery
+	err := ctx.ShouldBindQuery(&query)
+	format := query.Format
+	if format == "" {
+		format = ctx.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML)
+	}
+	html := format == "html" || format == gin.MIMEHTML
+	if err != nil {
+		h.respondPublicError(ctx, html, http.StatusBadRequest, err)
+		return
+	}
+
+	stats, err := h.service.PublicStats(ctx.Request.Context(), ctx.Param("token"), query.From, query.To, query.Interval, query.TZ)
+	if err != nil {
+		h.respondPublicError(ctx, html, publicStatsErrorStatus(err), err)
+		return
+	}
+	if html {
+		renderPublicStats(ctx, stats)
+		return
+	}
+	ctx.JSON(http.StatusOK, toPublicStatsResponse(stats))
+}
+
+func publicStatsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrShareNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrShareGone):
+		return http.StatusGone
+	case errors.Is(err, usecase.ErrInvalidStatsInterval), errors.Is(err, usecase.ErrInvalidTimezone),
+		errors.Is(err, usecase.ErrInvalidStatsRange), errors.Is(err, usecase.ErrStatsRangeTooLarge):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *ShareHttpHandler) respondPublicError(ctx *gin.Context, html bool, status int, err error) {
+	if !html {
+		respondError(ctx, status, err)
+		return
+	}
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		_ = ctx.Error(err)
+		message = "Statistics are unavailable right now. Please try again later."
+	}
+	renderSharePageError(ctx, status, message)
+}
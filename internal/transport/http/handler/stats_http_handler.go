@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StatsHttpHandler struct {
+	service *usecase.StatsService
+}
+
+func NewStatsHttpHandler(service *usecase.StatsService) *StatsHttpHandler {
+	return &StatsHttpHandler{service: service}
+}
+
+func (h *StatsHttpHandler) RegisterAuthRoutes(rg *gin.RouterGroup) {
+	registerRoutes(rg, []route{
+		{"GET", "/links/:shortCode/stats", h.GetLinkStats},
+		{"GET", "/links/:shortCode/stats/:dimension", h.GetLinkBreakdown},
+		{"GET", "/stats", h.GetAccountStats},
+		{"GET", "/stats/:dimension", h.GetAccountBreakdown},
+	})
+}
+
+type ClickSeriesResponse struct {
+	ShortCode      string               `json:"shortCode,omitempty"`
+	IncludeBots    bool                 `json:"includeBots"`
+	Interval       string               `json:"interval"`
+	Timezone       string               `json:"tz"`
+	From           time.Time            `json:"from"`
+	To             time.Time            `json:"to"`
+	Total          int64                `json:"total"`
+	UniqueVisitors *int64               `json:"uniqueVisitors,omitempty"`
+	Buckets        []TimeBucketResponse `json:"buckets"`
+}
+
+type statsQuery struct {
+	From        *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To          *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Interval    string     `form:"interval"`
+	TZ          string     `form:"tz"`
+	IncludeBots bool       `form:"include_bots"`
+}
+
+type BreakdownResponse struct {
+	ShortCode   string                   `json:"shortCode,omitempty"`
+	IncludeBots bool                     `json:"includeBots"`
+	Dimension   string                   `json:"dimension"`
+	From        time.Time                `json:"from"`
+	To          time.Time                `json:"to"`
+	Total       int64                    `json:"total"`
+	Items       []DimensionCountResponse `json:"items"`
+}
+
+type DimensionCountResponse struct {
+	Value  string `json:"value"`
+	Clicks int64  `json:"clicks"`
+}
+
+type breakdownQuery struct {
+	From        *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To          *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit       int        `form:"limit"`
+	IncludeBots bool       `form:"include_bots"`
+}
+
+func toBreakdownResponse(b *domain.Breakdown) BreakdownResponse {
+	items := make([]DimensionCountResponse, 0, len(b.Items))
+	for _, item := range b.Items {
+		items = append(items, DimensionCountResponse{Value: item.Value, Clicks: item.Clicks})
+	}
+	return BreakdownResponse{
+		ShortCode:   b.ShortCode,
+		IncludeBots: b.IncludeBots,
+		Dimension:   b.Dimension,
+		From:        b.From,
+		To:          b.To,
+		Total:       b.Total,
+		Items:       items,
+	}
+}
+
+func toClickSeriesResponse(s *domain.ClickSeries) ClickSeriesResponse {
+	buckets := make([]TimeBucketResponse, 0, len(s.Buckets))
+	for _, b := range s.Buckets {
+		buckets = append(buckets, TimeBucketResponse{Start: b.Start, Clicks: b.Clicks, UniqueVisitors: b.UniqueVisitors})
+	}
+	return ClickSeriesResponse{
+		ShortCode:      s.ShortCode,
+		IncludeBots:    s.IncludeBots,
+		Interval:       s.Interval,
+		Timezone:       s.Timezone,
+		From:           s.From,
+		To:             s.To,
+		Total:          s.Total,
+		UniqueVisitors: s.UniqueVisitors,
+		Buckets:        buckets,
+	}
+}
+
+func respondStatsError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrLinkNotFound):
+		respondError(ctx, http.StatusNotFound, err)
+	case errors.Is(err, usecase.ErrInvalidStatsInterval), errors.Is(err, usecase.ErrInvalidTimezone),
+		errors.Is(err, usecase.ErrInvalidStatsRange), errors.Is(err, usecase.ErrStatsRangeTooLarge),
+		errors.Is(err, usecase.ErrInvalidStatsDimension), errors.Is(err, usecase.ErrInvalidStatsLimit):
+		respondError(ctx, http.StatusBadRequest, err)
+	default:
+		respondError(ctx, http.StatusInternalServerError, err)
+	}
+}
+
+func (h *StatsHttpHandler) GetLinkStats(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var query statsQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	series, err := h.service.LinkClickSeries(ctx.Request.Context(), currentUser.ID, ctx.Param("shortCode"), query.From, query.To, query.Interval, query.TZ, query.IncludeBots)
+	if err != nil {
+		respondStatsError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toClickSeriesResponse(series))
+}
+
+func (h *StatsHttpHandler) GetAccountStats(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var query statsQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	series, err := h.service.AccountClickSeries(ctx.Request.Context(), currentUser.ID, query.From, query.To, query.Interval, query.TZ, query.IncludeBots)
+	if err != nil {
+		respondStatsError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toClickSeriesResponse(series))
+}
+
+func (h *StatsHttpHandler) GetLinkBreakdown(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var query breakdownQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	breakdown, err := h.service.LinkBreakdown(ctx.Request.Context(), currentUser.ID, ctx.Param("shortCode"), ctx.Param("dimension"), query.From, query.To, query.Limit, query.IncludeBots)
+	if err != nil {
+		respondStatsError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toBreakdownResponse(breakdown))
+}
+
+func (h *StatsHttpHandler) GetAccountBreakdown(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	var query breakdownQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	breakdown, err := h.service.AccountBreakdown(ctx.Request.Context(), currentUser.ID, ctx.Param("dimension"), query.From, query.To, query.Limit, query.IncludeBots)
+	if err != nil {
+		respondStatsError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toBreakdownResponse(breakdown))
+}
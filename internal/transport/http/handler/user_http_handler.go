@@ -1 +1,98 @@
 package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserHttpHandler struct {
+	service *usecase.AccountService
+}
+
+func NewUserHttpHandler(service *usecase.AccountService) *UserHttpHandler {
+	return &UserHttpHandler{service: service}
+}
+
+func (h *UserHttpHandler) RegisterAuthRoutes(rg *gin.RouterGroup) {
+	registerRoutes(rg, []route{
+		{"GET", "/me/summary", h.GetSummary},
+	})
+}
+
+type AccountSummaryResponse struct {
+	Plan           string                  `json:"plan"`
+	PlanExpiresAt  *time.Time              `json:"planExpiresAt"`
+	Links          LinkUsageResponse       `json:"links"`
+	Clicks         ClickTotalsResponse     `json:"clicks"`
+	TopLinks       []LinkResponse          `json:"topLinks"`
+	AttentionLinks []AttentionLinkResponse `json:"attentionLinks"`
+	GeneratedAt    time.Time               `json:"generatedAt"`
+}
+
+type LinkUsageResponse struct {
+	Total     int  `json:"total"`
+	Active    int  `json:"active"`
+	Limit     *int `json:"limit"`
+	Remaining *int `json:"remaining"`
+}
+
+type ClickTotalsResponse struct {
+	Today      int64 `json:"today"`
+	Last7Days  int64 `json:"last7Days"`
+	Last30Days int64 `json:"last30Days"`
+}
+
+type AttentionLinkResponse struct {
+	LinkResponse
+	Reason string    `json:"reason"`
+	Since  time.Time `json:"since"`
+}
+
+func (h *UserHttpHandler) GetSummary(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*domain.User)
+	summary, err := h.service.Summary(ctx.Request.Context(), currentUser, ctx.Query("tz"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidTimezone) {
+			respondError(ctx, http.StatusBadRequest, err)
+		} else {
+			respondError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	baseURL := getRequestBaseURL(ctx)
+	resp := AccountSummaryResponse{
+		Plan:          summary.Plan,
+		PlanExpiresAt: summary.PlanExpiresAt,
+		Links: LinkUsageResponse{
+			Total:     summary.TotalLinks,
+			Active:    summary.ActiveLinks,
+			Limit:     summary.LinkLimit,
+			Remaining: summary.LinksRemaining,
+		},
+		Clicks: ClickTotalsResponse{
+			Today:      summary.ClicksToday,
+			Last7Days:  summary.ClicksLast7Days,
+			Last30Days: summary.ClicksLast30Days,
+		},
+		TopLinks:       make([]LinkResponse, 0, len(summary.TopLinks)),
+		AttentionLinks: make([]AttentionLinkResponse, 0, len(summary.AttentionLinks)),
+		GeneratedAt:    summary.GeneratedAt,
+	}
+	for _, link := range summary.TopLinks {
+		resp.TopLinks = append(resp.TopLinks, toLinkResponse(baseURL, link))
+	}
+	for _, a := range summary.AttentionLinks {
+		resp.AttentionLinks = append(resp.AttentionLinks, AttentionLinkResponse{
+			LinkResponse: toLinkResponse(baseURL, a.Link),
+			Reason:       a.Reason,
+			Since:        a.Since,
+		})
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
@@ -3,15 +3,39 @@ package router
 import (
 	"context"
 	"net/http"
+	"os"
+	"strings"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/metrics"
 	"url-shortener/internal/transport/http/handler"
 	"url-shortener/internal/transport/middleware"
 	"url-shortener/internal/usecase"
 
 	"github.com/gin-gonic/gin"
 	"github.com/uptrace/bun"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.uber.org/zap"
 )
 
-func Register(r *gin.Engine, db *bun.DB, userRepo usecase.UserRepository, linkH *handler.LinkHttpHandler, adminH *handler.AdminHttpHandler) {
+func Register(r *gin.Engine, db *bun.DB, m *metrics.Metrics, logger *zap.Logger, userRepo usecase.UserRepository, keyUsage *usecase.APIKeyUsageTracker, linkH *handler.LinkHttpHandler, campaignH *handler.CampaignHttpHandler, statsH *handler.StatsHttpHandler, exportH *handler.ExportHttpHandler, liveH *handler.LiveHttpHandler, userH *handler.UserHttpHandler, shareH *handler.ShareHttpHandler, alertH *handler.AlertHttpHandler, keyH *handler.APIKeyHttpHandler, adminH *handler.AdminHttpHandler, auditH *handler.AuditHttpHandler) {
+	// ClientIP feeds the audit log, API key last-used IPs, click hashing,
+	// geo lookup and bot detection, so X-Forwarded-For is only believed
+	// from the proxies in TRUSTED_PROXIES. By default none are trusted and
+	// the client IP is the peer address.
+	if err := r.SetTrustedProxies(trustedProxies()); err != nil {
+		logger.Fatal("Invalid TRUSTED_PROXIES", zap.Error(err))
+	}
+
+	// The request logger runs outside Recovery so it sees the 500 of a
+	// recovered panic.
+	r.Use(middleware.RequestID(), middleware.RequestLogger(logger), middleware.Recovery(logger))
+
+	// Health checks and scrapes are not worth a trace each.
+	r.Use(otelgin.Middleware("url-shortener", otelgin.WithGinFilter(func(c *gin.Context) bool {
+		return c.FullPath() != "/healthz" && c.FullPath() != "/metrics"
+	})))
+	r.Use(m.Middleware())
+
 	// health
 	r.HEAD("/healthz", func(c *gin.Context) {
 		if err := db.RunInTx(c, nil, func(ctx context.Context, tx bun.Tx) error { return nil }); err != nil {
@@ -21,17 +45,49 @@ func Register(r *gin.Engine, db *bun.DB, userRepo usecase.UserRepository, linkH
 		c.Status(http.StatusOK)
 	})
 
+	// metrics (main port only with METRICS_TOKEN)
+	m.RegisterRoutes(r)
+
 	// public
 	public := r.Group("/")
 	linkH.RegisterPublicRoutes(public)
+	shareH.RegisterPublicRoutes(public)
 
-	// api (auth required)
+	// api (auth required; each handler needs the read or write scope of
+	// its resource)
 	api := r.Group("/api")
-	api.Use(middleware.ApiKeyAuth(userRepo))
-	linkH.RegisterAuthRoutes(api)
+	api.Use(middleware.ApiKeyAuth(userRepo, keyUsage))
+	scoped := func(resource string) *gin.RouterGroup {
+		return api.Group("", middleware.RequireScope(resource))
+	}
+	linkH.RegisterAuthRoutes(scoped(domain.ScopeLinks))
+	campaignH.RegisterAuthRoutes(scoped(domain.ScopeCampaigns))
+	statsH.RegisterAuthRoutes(scoped(domain.ScopeStats))
+	exportH.RegisterAuthRoutes(api.Group("", middleware.RequireReadScope(domain.ScopeStats)))
+	liveH.RegisterAuthRoutes(scoped(domain.ScopeStats))
+	userH.RegisterAuthRoutes(scoped(domain.ScopeStats))
+	shareH.RegisterAuthRoutes(scoped(domain.ScopeShares))
+	alertH.RegisterAuthRoutes(scoped(domain.ScopeAlerts))
+	keyH.RegisterAuthRoutes(scoped(domain.ScopeKeys))
 
-	// admin
+	// admin (admin scopes are only honoured for admin users)
 	admin := r.Group("/admin")
-	admin.Use(middleware.ApiKeyAuth(userRepo), middleware.RequireAdmin())
+	admin.Use(middleware.ApiKeyAuth(userRepo, keyUsage), middleware.RequireScope(domain.ScopeAdmin))
 	adminH.RegisterAdminRoutes(admin)
+	auditH.RegisterAdminRoutes(admin)
+	adminRead := r.Group("/admin")
+	adminRead.Use(middleware.ApiKeyAuth(userRepo, keyUsage), middleware.RequireReadScope(domain.ScopeAdmin))
+	exportH.RegisterAdminRoutes(adminRead)
+}
+
+// trustedProxies reads the comma-separated IPs and CIDRs in
+// TRUSTED_PROXIES.
+func trustedProxies() []string {
+	var proxies []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
 }
@@ -2,23 +2,52 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
+	"url-shortener/internal/domain"
 	"url-shortener/internal/usecase"
 
 	"github.com/gin-gonic/gin"
 )
 
-func ApiKeyAuth(userRepo usecase.UserRepository) gin.HandlerFunc {
+// ApiKeyAuth looks up the user of the X-API-KEY header and notes the use
+// of the key; revoked and expired keys are rejected.
+func ApiKeyAuth(userRepo usecase.UserRepository, usage *usecase.APIKeyUsageTracker) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		apiKey := ctx.GetHeader("X-API-KEY")
 		if apiKey == "" {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key required"})
 			return
 		}
-		user, err := userRepo.FindByAPIKey(ctx.Request.Context(), apiKey)
+		// Mistyped or made-up keys in the generated format fail their
+		// checksum and never reach the database.
+		if strings.HasPrefix(apiKey, usecase.APIKeyPrefixLive) && !usecase.ValidAPIKeyFormat(apiKey) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid API key"})
+			return
+		}
+		user, err := userRepo.FindByAPIKeyHash(ctx.Request.Context(), usecase.HashAPIKey(apiKey))
 		if err != nil {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid API key"})
+			return
 		}
+		usage.Touch(user.APIKeyID, ctx.ClientIP())
 		ctx.Set("currentUser", user)
+		ctx.Request = ctx.Request.WithContext(usecase.WithAuditActor(ctx.Request.Context(), auditActor(ctx, user)))
 		ctx.Next()
 	}
 }
+
+// auditActor attributes the audit events of the request to user and the
+// key it authenticated with.
+func auditActor(ctx *gin.Context, user *domain.User) domain.AuditActor {
+	userID := user.ID
+	actor := domain.AuditActor{
+		UserID:    &userID,
+		IP:        ctx.ClientIP(),
+		RequestID: ctx.GetString("requestID"),
+	}
+	if user.APIKeyID != 0 {
+		keyID := user.APIKeyID
+		actor.APIKeyID = &keyID
+	}
+	return actor
+}
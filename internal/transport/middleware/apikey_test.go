@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeUserRepo knows the users of a fixed set of keys.
+type fakeUserRepo struct {
+	usecase.UserRepository
+	users map[string]*domain.User
+}
+
+func (r *fakeUserRepo) FindByAPIKeyHash(ctx context.Context, keyHash string) (*domain.User, error) {
+	for key, user := range r.users {
+		if usecase.HashAPIKey(key) == keyHash {
+			return user, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func TestApiKeyAuthAndRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	readKey, err := usecase.GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	allKey, err := usecase.GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := readKey[:len(readKey)-1] + "x"
+	if readKey[len(readKey)-1] == 'x' {
+		tampered = readKey[:len(readKey)-1] + "y"
+	}
+	unknown, err := usecase.GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &fakeUserRepo{users: map[string]*domain.User{
+		readKey:    {ID: 1, Role: "user", APIKeyID: 10, APIKeyScopes: []string{"links:read"}},
+		allKey:     {ID: 1, Role: "user", APIKeyID: 11, APIKeyScopes: []string{"*"}},
+		"key1test": {ID: 2, Role: "user", APIKeyID: 12, APIKeyScopes: []string{"links:*"}},
+	}}
+	usage := usecase.NewAPIKeyUsageTrackerWithConfig(repo, usecase.APIKeyUsageConfig{})
+
+	r := gin.New()
+	api := r.Group("/api", ApiKeyAuth(repo, usage))
+	links := api.Group("", RequireScope(domain.ScopeLinks))
+	links.GET("/links", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	links.POST("/links", func(ctx *gin.Context) { ctx.Status(http.StatusCreated) })
+	admin := api.Group("/admin", RequireScope(domain.ScopeAdmin))
+	admin.GET("/users", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		want   int
+	}{
+		{name: "no key", method: http.MethodGet, path: "/api/links", want: http.StatusUnauthorized},
+		{name: "bad checksum", method: http.MethodGet, path: "/api/links", key: tampered, want: http.StatusUnauthorized},
+		{name: "unknown key", method: http.MethodGet, path: "/api/links", key: unknown, want: http.StatusUnauthorized},
+		{name: "unknown legacy key", method: http.MethodGet, path: "/api/links", key: "nope", want: http.StatusUnauthorized},
+		{name: "read scope reads", method: http.MethodGet, path: "/api/links", key: readKey, want: http.StatusOK},
+		{name: "read scope cannot write", method: http.MethodPost, path: "/api/links", key: readKey, want: http.StatusForbidden},
+		{name: "wildcard writes", method: http.MethodPost, path: "/api/links", key: allKey, want: http.StatusCreated},
+		{name: "wildcard is capped by role", method: http.MethodGet, path: "/api/admin/users", key: allKey, want: http.StatusForbidden},
+		{name: "legacy key", method: http.MethodPost, path: "/api/links", key: "key1test", want: http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set("X-API-KEY", tt.key)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
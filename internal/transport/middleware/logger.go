@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+	"url-shortener/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RequestLogger writes one structured line per request. The query string
+// is left out and path parameters named "token" are masked, so share
+// tokens and other secrets passed in URLs stay out of the log.
+func RequestLogger(logger *zap.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		fields := []zap.Field{
+			zap.String("request_id", ctx.GetString("requestID")),
+			zap.String("method", ctx.Request.Method),
+			zap.String("route", route),
+			zap.String("path", maskedPath(ctx)),
+			zap.Int("status", status),
+			zap.Duration("latency", time.Since(start)),
+			zap.Int("bytes", max(ctx.Writer.Size(), 0)),
+			zap.String("client_ip", ctx.ClientIP()),
+			zap.String("user_agent", ctx.Request.UserAgent()),
+		}
+		if v, ok := ctx.Get("currentUser"); ok {
+			if user, ok := v.(*domain.User); ok && user != nil {
+				fields = append(fields, zap.Int64("user_id", user.ID), zap.Int64("api_key_id", user.APIKeyID))
+			}
+		}
+		if len(ctx.Errors) > 0 {
+			fields = append(fields, zap.String("errors", ctx.Errors.String()))
+		}
+
+		switch {
+		case status >= http.StatusInternalServerError:
+			logger.Error("request", fields...)
+		case status >= http.StatusBadRequest:
+			logger.Warn("request", fields...)
+		default:
+			logger.Info("request", fields...)
+		}
+	}
+}
+
+func maskedPath(ctx *gin.Context) string {
+	path := ctx.Request.URL.Path
+	if token := ctx.Param("token"); token != "" {
+		path = strings.Replace(path, token, "[REDACTED]", 1)
+	}
+	return path
+}
+
+// Recovery turns panics into a 500 and logs them with their stack.
+func Recovery(logger *zap.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer func() {
+			if err := recover(); err != nil {
+				if err == http.ErrAbortHandler {
+					// The client went away (e.g. a live stream); nothing to report.
+					panic(err)
+				}
+				logger.Error("panic recovered",
+					zap.String("request_id", ctx.GetString("requestID")),
+					zap.String("route", ctx.FullPath()),
+					zap.Any("panic", err),
+					zap.Stack("stack"),
+				)
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			}
+		}()
+		ctx.Next()
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireScope lets a request through only if its API key has the read
+// (GET, HEAD) or write (other methods) scope on resource and the user's
+// role allows it. It runs after ApiKeyAuth.
+func RequireScope(resource string) gin.HandlerFunc {
+	return requireScope(func(ctx *gin.Context) string {
+		return usecase.ScopeFor(resource, ctx.Request.Method)
+	})
+}
+
+// RequireReadScope is RequireScope for routes that only read, whatever
+// their method, such as starting an export.
+func RequireReadScope(resource string) gin.HandlerFunc {
+	scope := resource + ":" + domain.ScopeRead
+	return requireScope(func(*gin.Context) string { return scope })
+}
+
+func requireScope(required func(*gin.Context) string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, _ := ctx.MustGet("currentUser").(*domain.User)
+		scope := required(ctx)
+		if !usecase.HasScope(user, scope) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key lacks the required scope", "required_scope": scope})
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"context"
+	"time"
+	"url-shortener/internal/domain"
+)
+
+const (
+	summaryTopLinks       = 10
+	summaryAttentionLinks = 20
+	// summaryAttentionWindow is how recently a link must have broken or been
+	// flagged to be listed in the summary.
+	summaryAttentionWindow = 7 * 24 * time.Hour
+)
+
+type AccountSummaryRepository interface {
+	// Summary fills the link counts, click totals, top links and attention
+	// links of the query's user. Plan fields are left to the caller.
+	Summary(ctx context.Context, query domain.AccountSummaryQuery) (*domain.AccountSummary, error)
+}
+
+// AccountService serves the account overview shown on dashboards.
+type AccountService struct {
+	summaryRepo AccountSummaryRepository
+}
+
+func NewAccountService(summaryRepo AccountSummaryRepository) *AccountService {
+	if summaryRepo == nil {
+		panic("AccountSummaryRepository cannot be nil")
+	}
+	return &AccountService{summaryRepo: summaryRepo}
+}
+
+// Summary returns the user's account overview. "Today" starts at midnight
+// in tz (default UTC); the 7 and 30 day windows end now. Click windows are
+// read from hourly rollups, so they start on the hour.
+func (s *AccountService) Summary(ctx context.Context, user *domain.User, tz string) (*domain.AccountSummary, error) {
+	if tz == "" {
+		tz = "UTC"
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+
+	now := time.Now()
+	summary, err := s.summaryRepo.Summary(ctx, domain.AccountSummaryQuery{
+		UserID:         user.ID,
+		TodayFrom:      truncateToInterval(now, domain.StatsIntervalDay, loc),
+		WeekFrom:       now.Add(-7 * 24 * time.Hour).Truncate(time.Hour),
+		MonthFrom:      now.Add(-30 * 24 * time.Hour).Truncate(time.Hour),
+		AttentionSince: now.Add(-summaryAttentionWindow),
+		TopLinks:       summaryTopLinks,
+		AttentionLinks: summaryAttentionLinks,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summary.Plan = user.Plan
+	summary.PlanExpiresAt = user.PlanExpiresAt
+	if limit, ok := LinkLimit(user); ok {
+		remaining := max(limit-summary.TotalLinks, 0)
+		summary.LinkLimit = &limit
+		summary.LinksRemaining = &remaining
+	}
+	summary.GeneratedAt = now
+	return summary, nil
+}
@@ -2,37 +2,146 @@ package usecase
 
 import (
 	"context"
+	"strconv"
 	"time"
 	"url-shortener/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type AdminService struct {
-	userRepo UserRepository
+	userRepo  UserRepository
+	blocklist BlocklistStore
+	clicks    *ClickRecorder
+	audit     *AuditService
+	keys      *APIKeyService
 }
 
-func NewAdminService(userRepo UserRepository) *AdminService {
+func NewAdminService(userRepo UserRepository, blocklist BlocklistStore, clicks *ClickRecorder, audit *AuditService, keys *APIKeyService) *AdminService {
 	if userRepo == nil {
 		panic("UserRepository cannot be nil")
 	}
-	return &AdminService{userRepo: userRepo}
+	if blocklist == nil {
+		panic("BlocklistStore cannot be nil")
+	}
+	if clicks == nil {
+		panic("ClickRecorder cannot be nil")
+	}
+	if audit == nil {
+		panic("AuditService cannot be nil")
+	}
+	if keys == nil {
+		panic("APIKeyService cannot be nil")
+	}
+	return &AdminService{userRepo: userRepo, blocklist: blocklist, clicks: clicks, audit: audit, keys: keys}
 }
 
-func (s *AdminService) CreateUser(ctx context.Context, email, plan, role string, planExpiresAt *time.Time) (*domain.User, error) {
+func (s *AdminService) CreateUser(ctx context.Context, email, plan, role string, planExpiresAt *time.Time) (_ *domain.User, err error) {
+	ctx, span := startSpan(ctx, "AdminService.CreateUser")
+	defer func() { endSpan(span, err) }()
+
 	user := &domain.User{Email: email, Plan: plan, Role: role, PlanExpiresAt: planExpiresAt}
 	if err := s.userRepo.Create(ctx, user); err != nil {
 		return nil, err
 	}
+	s.audit.Record(ctx, domain.AuditUserCreate, domain.AuditTargetUser, strconv.FormatInt(user.ID, 10), nil, auditUserFields(user))
 	return user, nil
 }
 
-func (s *AdminService) CreateAPIKeyForUser(ctx context.Context, userID int64, key string) error {
-	return s.userRepo.CreateAPIKey(ctx, userID, key)
+// CreateAPIKeyForUser generates a key for the user and returns it with
+// its plaintext, which is not stored anywhere.
+func (s *AdminService) CreateAPIKeyForUser(ctx context.Context, userID int64, input APIKeyInput) (_ *domain.ApiKey, _ string, err error) {
+	ctx, span := startSpan(ctx, "AdminService.CreateAPIKeyForUser", attribute.Int64("user.id", userID))
+	defer func() { endSpan(span, err) }()
+	return s.keys.Issue(ctx, userID, input)
+}
+
+// ListAPIKeysForUser returns the user's active keys.
+func (s *AdminService) ListAPIKeysForUser(ctx context.Context, userID int64) (_ []*domain.ApiKey, err error) {
+	ctx, span := startSpan(ctx, "AdminService.ListAPIKeysForUser", attribute.Int64("user.id", userID))
+	defer func() { endSpan(span, err) }()
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return s.keys.List(ctx, userID)
+}
+
+func (s *AdminService) RevokeAPIKeyForUser(ctx context.Context, userID, keyID int64) error {
+	return s.keys.RevokeForUser(ctx, userID, keyID)
+}
+
+func (s *AdminService) RotateAPIKeyForUser(ctx context.Context, userID, keyID int64, grace *time.Duration) (*APIKeyRotation, error) {
+	return s.keys.RotateForUser(ctx, userID, keyID, grace)
 }
 
-func (s *AdminService) SoftDeleteUser(ctx context.Context, userID int64) error {
-	return s.userRepo.SoftDeleteByID(ctx, userID)
+func (s *AdminService) SoftDeleteUser(ctx context.Context, userID int64) (err error) {
+	ctx, span := startSpan(ctx, "AdminService.SoftDeleteUser", attribute.Int64("user.id", userID))
+	defer func() { endSpan(span, err) }()
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	if err := s.userRepo.SoftDeleteByID(ctx, userID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, domain.AuditUserDelete, domain.AuditTargetUser, strconv.FormatInt(userID, 10), auditUserFields(user), nil)
+	return nil
+}
+
+func (s *AdminService) UpdateUserPlan(ctx context.Context, userID int64, plan string, planExpiresAt *time.Time) (err error) {
+	ctx, span := startSpan(ctx, "AdminService.UpdateUserPlan", attribute.Int64("user.id", userID), attribute.String("user.plan", plan))
+	defer func() { endSpan(span, err) }()
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	if err := s.userRepo.UpdatePlanAndExpiry(ctx, userID, plan, planExpiresAt); err != nil {
+		return err
+	}
+	before := map[string]any{"plan": user.Plan, "plan_expires_at": user.PlanExpiresAt}
+	after := map[string]any{"plan": plan, "plan_expires_at": planExpiresAt}
+	s.audit.Record(ctx, domain.AuditUserPlanUpdate, domain.AuditTargetUser, strconv.FormatInt(userID, 10), before, after)
+	return nil
+}
+
+func (s *AdminService) GetBlocklist(ctx context.Context) domain.Blocklist {
+	_, span := startSpan(ctx, "AdminService.GetBlocklist")
+	defer span.End()
+	return s.blocklist.List()
+}
+
+func (s *AdminService) AddBlocklistEntry(ctx context.Context, kind, value string) (err error) {
+	ctx, span := startSpan(ctx, "AdminService.AddBlocklistEntry", attribute.String("blocklist.kind", kind))
+	defer func() { endSpan(span, err) }()
+	if err := s.blocklist.Add(ctx, kind, value); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, domain.AuditBlocklistAdd, domain.AuditTargetBlocklist, kind+":"+value, nil, map[string]any{"kind": kind, "value": value})
+	return nil
+}
+
+func (s *AdminService) RemoveBlocklistEntry(ctx context.Context, kind, value string) (err error) {
+	ctx, span := startSpan(ctx, "AdminService.RemoveBlocklistEntry", attribute.String("blocklist.kind", kind))
+	defer func() { endSpan(span, err) }()
+	if err := s.blocklist.Remove(ctx, kind, value); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, domain.AuditBlocklistRemove, domain.AuditTargetBlocklist, kind+":"+value, map[string]any{"kind": kind, "value": value}, nil)
+	return nil
 }
 
-func (s *AdminService) UpdateUserPlan(ctx context.Context, userID int64, plan string, planExpiresAt *time.Time) error {
-	return s.userRepo.UpdatePlanAndExpiry(ctx, userID, plan, planExpiresAt)
+func (s *AdminService) ClickIngestionStats(ctx context.Context) ClickRecorderStats {
+	_, span := startSpan(ctx, "AdminService.ClickIngestionStats")
+	defer span.End()
+	return s.clicks.Stats()
 }
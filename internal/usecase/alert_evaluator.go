@@ -0,0 +1,261 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"url-shortener/internal/domain"
+)
+
+const (
+	alertRulePageSize  = 200
+	alertRetryPageSize = 100
+	maxAlertBackoff    = time.Hour
+)
+
+// AlertEvaluator checks every enabled rule against the hourly rollups each
+// EvalInterval and delivers the events that fire. Each rule fires at most
+// once per dedup key and not again within its cooldown; failed deliveries
+// are retried with backoff up to MaxAttempts times.
+type AlertEvaluator struct {
+	repo     AlertRepository
+	notifier AlertNotifier
+	cfg      AlertConfig
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewAlertEvaluator(repo AlertRepository, notifier AlertNotifier) *AlertEvaluator {
+	return NewAlertEvaluatorWithConfig(repo, notifier, LoadAlertConfig())
+}
+
+func NewAlertEvaluatorWithConfig(repo AlertRepository, notifier AlertNotifier, cfg AlertConfig) *AlertEvaluator {
+	if repo == nil {
+		panic("AlertRepository cannot be nil")
+	}
+	if notifier == nil {
+		panic("AlertNotifier cannot be nil")
+	}
+	if cfg.EvalInterval <= 0 {
+		cfg.EvalInterval = time.Minute
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+	return &AlertEvaluator{repo: repo, notifier: notifier, cfg: cfg}
+}
+
+func (e *AlertEvaluator) Start() {
+	if e.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+	e.done = make(chan struct{})
+	go func() {
+		defer close(e.done)
+		ticker := time.NewTicker(e.cfg.EvalInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := e.Evaluate(ctx); err != nil && !errors.Is(err, context.Canceled) {
+					log.Printf("Alert evaluation failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func (e *AlertEvaluator) Stop(ctx context.Context) error {
+	if e.cancel == nil {
+		return nil
+	}
+	e.cancel()
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Evaluate runs one pass over all enabled rules, then retries failed
+// deliveries. A failing rule is logged and does not stop the others.
+func (e *AlertEvaluator) Evaluate(ctx context.Context) error {
+	now := time.Now().UTC()
+	var afterID int64
+	for {
+		rules, err := e.repo.ListEnabledRules(ctx, afterID, alertRulePageSize)
+		if err != nil {
+			return err
+		}
+		for _, rule := range rules {
+			if err := e.evaluateRule(ctx, rule, now); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				log.Printf("Alert rule %d: %v", rule.ID, err)
+			}
+		}
+		if len(rules) < alertRulePageSize {
+			break
+		}
+		afterID = rules[len(rules)-1].ID
+	}
+	return e.retry(ctx, now)
+}
+
+func (e *AlertEvaluator) evaluateRule(ctx context.Context, rule *domain.AlertRule, now time.Time) error {
+	if rule.LastTriggeredAt != nil {
+		// Threshold rules fire once; updating the rule re-arms them.
+		if rule.Kind == domain.AlertKindThreshold || now.Before(rule.LastTriggeredAt.Add(rule.Cooldown)) {
+			return nil
+		}
+	}
+	event, err := e.check(ctx, rule, now)
+	if err != nil || event == nil {
+		return err
+	}
+	created, err := e.repo.CreateEvent(ctx, event)
+	if err != nil || !created {
+		return err
+	}
+	if err := e.repo.MarkTriggered(ctx, rule.ID, now); err != nil {
+		return err
+	}
+	e.deliver(ctx, rule, event)
+	return nil
+}
+
+// check returns the event the rule fires at now, or nil.
+func (e *AlertEvaluator) check(ctx context.Context, rule *domain.AlertRule, now time.Time) (*domain.AlertEvent, error) {
+	event := &domain.AlertEvent{
+		RuleID:      rule.ID,
+		RuleName:    rule.Name,
+		UserID:      rule.UserID,
+		LinkID:      rule.LinkID,
+		ShortCode:   rule.ShortCode,
+		Kind:        rule.Kind,
+		Status:      domain.AlertDeliveryPending,
+		Attempts:    1,
+		TriggeredAt: now,
+	}
+	next := now.Add(e.backoff(1))
+	event.NextAttemptAt = &next
+
+	switch rule.Kind {
+	case domain.AlertKindThreshold:
+		total, err := e.repo.SumClicks(ctx, rule.UserID, rule.LinkID, rule.IncludeBots, time.Time{}, time.Time{})
+		if err != nil || total < rule.Threshold {
+			return nil, err
+		}
+		event.DedupKey = fmt.Sprintf("threshold:%d", rule.Threshold)
+		event.Value = total
+		event.Baseline = float64(rule.Threshold)
+		return event, nil
+
+	case domain.AlertKindSpike:
+		// The current hour is still filling up, but once it exceeds the
+		// baseline it can only stay above it, so alerting early is safe.
+		hour := now.Truncate(time.Hour)
+		current, err := e.repo.SumClicks(ctx, rule.UserID, rule.LinkID, rule.IncludeBots, hour, hour.Add(time.Hour))
+		if err != nil || current < rule.MinClicks {
+			return nil, err
+		}
+		trailing, err := e.repo.SumClicks(ctx, rule.UserID, rule.LinkID, rule.IncludeBots, hour.Add(-time.Duration(rule.WindowHours)*time.Hour), hour)
+		if err != nil {
+			return nil, err
+		}
+		average := float64(trailing) / float64(rule.WindowHours)
+		if float64(current) <= rule.Factor*average {
+			return nil, nil
+		}
+		event.DedupKey = "spike:" + hour.Format(time.RFC3339)
+		event.Value = current
+		event.Baseline = average
+		return event, nil
+	}
+	return nil, ErrInvalidAlertKind
+}
+
+// retry delivers the events whose earlier attempts failed or were cut
+// short, e.g. by a restart.
+func (e *AlertEvaluator) retry(ctx context.Context, now time.Time) error {
+	events, err := e.repo.ListRetryableEvents(ctx, now, e.cfg.MaxAttempts, alertRetryPageSize)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		rule, err := e.repo.FindRule(ctx, event.UserID, event.RuleID)
+		if err != nil {
+			return err
+		}
+		next := now.Add(e.backoff(event.Attempts + 1))
+		claimed, err := e.repo.ClaimEvent(ctx, event.ID, event.Attempts, next)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		event.Attempts++
+		event.NextAttemptAt = &next
+		if rule == nil {
+			// The rule was deleted; there is nowhere to deliver to.
+			event.Status = domain.AlertDeliveryFailed
+			event.Error = ErrAlertRuleNotFound.Error()
+			event.NextAttemptAt = nil
+			if err := e.repo.FinishEvent(ctx, event); err != nil {
+				return err
+			}
+			continue
+		}
+		e.deliver(ctx, rule, event)
+	}
+	return nil
+}
+
+// deliver sends the event and records the outcome. Errors are kept on the
+// event rather than returned; the retry pass picks the event up again.
+func (e *AlertEvaluator) deliver(ctx context.Context, rule *domain.AlertRule, event *domain.AlertEvent) {
+	sendCtx := ctx
+	if e.cfg.DeliveryTimeout > 0 {
+		var cancel context.CancelFunc
+		sendCtx, cancel = context.WithTimeout(ctx, e.cfg.DeliveryTimeout)
+		defer cancel()
+	}
+	if err := e.notifier.Notify(sendCtx, rule, event); err != nil {
+		event.Status = domain.AlertDeliveryFailed
+		event.Error = err.Error()
+		if event.Attempts >= e.cfg.MaxAttempts {
+			event.NextAttemptAt = nil
+		}
+		log.Printf("Alert %d of rule %d not delivered (attempt %d): %v", event.ID, rule.ID, event.Attempts, err)
+	} else {
+		delivered := time.Now().UTC()
+		event.Status = domain.AlertDeliverySent
+		event.Error = ""
+		event.NextAttemptAt = nil
+		event.DeliveredAt = &delivered
+	}
+	// Record the outcome even if the evaluator is being stopped.
+	if err := e.repo.FinishEvent(context.WithoutCancel(ctx), event); err != nil {
+		log.Printf("Alert %d: recording delivery failed: %v", event.ID, err)
+	}
+}
+
+// backoff is the wait after the attempt-th attempt: one minute, doubling
+// up to an hour.
+func (e *AlertEvaluator) backoff(attempt int) time.Duration {
+	d := time.Minute
+	for i := 1; i < attempt && d < maxAlertBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxAlertBackoff)
+}
@@ -0,0 +1,319 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"url-shortener/internal/domain"
+)
+
+const (
+	maxAlertNameLength   = 100
+	maxAlertWindowHours  = 7 * 24
+	defaultAlertWindow   = 24
+	defaultAlertMinClick = 10
+	defaultHistoryLimit  = 50
+	maxHistoryLimit      = 500
+)
+
+var (
+	ErrAlertRuleNotFound     = errors.New("alert rule not found")
+	ErrInvalidAlertName      = fmt.Errorf("name is required and must be at most %d characters", maxAlertNameLength)
+	ErrInvalidAlertKind      = errors.New("kind must be one of " + strings.Join(domain.AlertKinds, ", "))
+	ErrInvalidAlertChannel   = errors.New("channel must be one of " + strings.Join(domain.AlertChannels, ", "))
+	ErrInvalidAlertTarget    = errors.New("target must be a webhook URL or an email address matching the channel")
+	ErrAlertEmailNotOwn      = fmt.Errorf("%w: email alerts can only be sent to the account's own address", ErrInvalidAlertTarget)
+	ErrInvalidAlertThreshold = errors.New("threshold must be positive")
+	ErrInvalidAlertFactor    = errors.New("factor must be greater than 1")
+	ErrInvalidAlertWindow    = fmt.Errorf("window_hours must be between 1 and %d", maxAlertWindowHours)
+	ErrInvalidAlertMinClicks = errors.New("min_clicks must not be negative")
+	ErrInvalidAlertCooldown  = errors.New("cooldown must not be negative")
+	ErrTooManyAlertRules     = errors.New("alert rule limit reached")
+	ErrInvalidHistoryLimit   = fmt.Errorf("limit must be between 1 and %d", maxHistoryLimit)
+)
+
+type AlertRepository interface {
+	CreateRule(ctx context.Context, rule *domain.AlertRule) error
+	// FindRule returns the user's rule with its link's short code, or nil.
+	FindRule(ctx context.Context, userID, ruleID int64) (*domain.AlertRule, error)
+	ListRules(ctx context.Context, userID int64) ([]*domain.AlertRule, error)
+	CountRules(ctx context.Context, userID int64) (int, error)
+	// UpdateRule replaces the rule's settings and re-arms it; it returns
+	// false if the user has no such rule.
+	UpdateRule(ctx context.Context, rule *domain.AlertRule) (bool, error)
+	DeleteRule(ctx context.Context, userID, ruleID int64) (bool, error)
+	// ListEnabledRules pages through the enabled rules of all users,
+	// skipping rules of deleted links.
+	ListEnabledRules(ctx context.Context, afterID int64, limit int) ([]*domain.AlertRule, error)
+	MarkTriggered(ctx context.Context, ruleID int64, at time.Time) error
+
+	// SumClicks sums the hourly rollups of the user, or only of linkID, in
+	// [from, to). Zero times leave that end open.
+	SumClicks(ctx context.Context, userID int64, linkID *int64, includeBots bool, from, to time.Time) (int64, error)
+
+	// CreateEvent stores a new event and returns false if the rule already
+	// has one with the same dedup key.
+	CreateEvent(ctx context.Context, event *domain.AlertEvent) (bool, error)
+	// ClaimEvent counts a delivery attempt if the event still has attempts
+	// attempts, so only one instance retries it.
+	ClaimEvent(ctx context.Context, eventID int64, attempts int, nextAttemptAt time.Time) (bool, error)
+	FinishEvent(ctx context.Context, event *domain.AlertEvent) error
+	// ListRetryableEvents returns undelivered events due for another
+	// attempt.
+	ListRetryableEvents(ctx context.Context, now time.Time, maxAttempts, limit int) ([]*domain.AlertEvent, error)
+	History(ctx context.Context, query domain.AlertHistoryQuery) ([]*domain.AlertEvent, error)
+}
+
+// AlertNotifier delivers alert events over the rule's channel.
+type AlertNotifier interface {
+	Notify(ctx context.Context, rule *domain.AlertRule, event *domain.AlertEvent) error
+}
+
+// AlertConfig is loaded from ALERT_* env vars, see LoadAlertConfig.
+type AlertConfig struct {
+	EvalInterval    time.Duration
+	DefaultCooldown time.Duration
+	MaxRulesPerUser int
+	// MaxAttempts is how often a delivery is tried before the event stays
+	// failed.
+	MaxAttempts     int
+	DeliveryTimeout time.Duration
+}
+
+func LoadAlertConfig() AlertConfig {
+	return AlertConfig{
+		EvalInterval:    envDuration("ALERT_EVAL_INTERVAL", time.Minute),
+		DefaultCooldown: envDuration("ALERT_DEFAULT_COOLDOWN", time.Hour),
+		MaxRulesPerUser: envInt("ALERT_MAX_RULES_PER_USER", 50),
+		MaxAttempts:     envInt("ALERT_MAX_ATTEMPTS", 5),
+		DeliveryTimeout: envDuration("ALERT_DELIVERY_TIMEOUT", 10*time.Second),
+	}
+}
+
+// AlertRuleInput is a rule as submitted by its owner. Nil Cooldown and
+// Enabled take their defaults; zero WindowHours and MinClicks too.
+type AlertRuleInput struct {
+	Name        string
+	ShortCode   string
+	Kind        string
+	Threshold   int64
+	Factor      float64
+	WindowHours int
+	MinClicks   int64
+	IncludeBots bool
+	Channel     string
+	Target      string
+	Cooldown    *time.Duration
+	Enabled     *bool
+}
+
+// AlertService manages alert rules and their history.
+type AlertService struct {
+	repo      AlertRepository
+	linkRepo  LinkRepository
+	userRepo  UserRepository
+	validator *URLValidator
+	cfg       AlertConfig
+}
+
+func NewAlertService(repo AlertRepository, linkRepo LinkRepository, userRepo UserRepository, validator *URLValidator) *AlertService {
+	return NewAlertServiceWithConfig(repo, linkRepo, userRepo, validator, LoadAlertConfig())
+}
+
+func NewAlertServiceWithConfig(repo AlertRepository, linkRepo LinkRepository, userRepo UserRepository, validator *URLValidator, cfg AlertConfig) *AlertService {
+	if repo == nil {
+		panic("AlertRepository cannot be nil")
+	}
+	if linkRepo == nil {
+		panic("LinkRepository cannot be nil")
+	}
+	if userRepo == nil {
+		panic("UserRepository cannot be nil")
+	}
+	if validator == nil {
+		panic("URLValidator cannot be nil")
+	}
+	return &AlertService{repo: repo, linkRepo: linkRepo, userRepo: userRepo, validator: validator, cfg: cfg}
+}
+
+func (s *AlertService) CreateRule(ctx context.Context, userID int64, input AlertRuleInput) (*domain.AlertRule, error) {
+	n, err := s.repo.CountRules(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if n >= s.cfg.MaxRulesPerUser {
+		return nil, ErrTooManyAlertRules
+	}
+	rule, err := s.buildRule(ctx, userID, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *AlertService) GetRule(ctx context.Context, userID, ruleID int64) (*domain.AlertRule, error) {
+	rule, err := s.repo.FindRule(ctx, userID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, ErrAlertRuleNotFound
+	}
+	return rule, nil
+}
+
+func (s *AlertService) ListRules(ctx context.Context, userID int64) ([]*domain.AlertRule, error) {
+	return s.repo.ListRules(ctx, userID)
+}
+
+// UpdateRule replaces all settings of a rule. The rule is re-armed, so a
+// threshold rule that already fired can fire again.
+func (s *AlertService) UpdateRule(ctx context.Context, userID, ruleID int64, input AlertRuleInput) (*domain.AlertRule, error) {
+	rule, err := s.buildRule(ctx, userID, input)
+	if err != nil {
+		return nil, err
+	}
+	rule.ID = ruleID
+	ok, err := s.repo.UpdateRule(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrAlertRuleNotFound
+	}
+	return s.GetRule(ctx, userID, ruleID)
+}
+
+func (s *AlertService) DeleteRule(ctx context.Context, userID, ruleID int64) error {
+	ok, err := s.repo.DeleteRule(ctx, userID, ruleID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAlertRuleNotFound
+	}
+	return nil
+}
+
+// History returns the user's alert events, newest first.
+func (s *AlertService) History(ctx context.Context, query domain.AlertHistoryQuery) ([]*domain.AlertEvent, error) {
+	if query.Limit == 0 {
+		query.Limit = defaultHistoryLimit
+	}
+	if query.Limit < 0 || query.Limit > maxHistoryLimit {
+		return nil, ErrInvalidHistoryLimit
+	}
+	if query.From != nil && query.To != nil && !query.From.Before(*query.To) {
+		return nil, ErrInvalidStatsRange
+	}
+	if query.RuleID != nil {
+		if _, err := s.GetRule(ctx, query.UserID, *query.RuleID); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.History(ctx, query)
+}
+
+// buildRule validates input and fills in defaults. Settings of the other
+// kind are zeroed so stored rules stay unambiguous.
+func (s *AlertService) buildRule(ctx context.Context, userID int64, input AlertRuleInput) (*domain.AlertRule, error) {
+	rule := &domain.AlertRule{
+		UserID:      userID,
+		Name:        strings.TrimSpace(input.Name),
+		Kind:        input.Kind,
+		IncludeBots: input.IncludeBots,
+		Channel:     input.Channel,
+		Target:      strings.TrimSpace(input.Target),
+		Cooldown:    s.cfg.DefaultCooldown,
+		Enabled:     true,
+	}
+	if rule.Name == "" || len(rule.Name) > maxAlertNameLength || strings.ContainsAny(rule.Name, "\r\n") {
+		return nil, ErrInvalidAlertName
+	}
+	if input.Cooldown != nil {
+		if *input.Cooldown < 0 {
+			return nil, ErrInvalidAlertCooldown
+		}
+		rule.Cooldown = *input.Cooldown
+	}
+	if input.Enabled != nil {
+		rule.Enabled = *input.Enabled
+	}
+
+	switch input.Kind {
+	case domain.AlertKindThreshold:
+		if input.Threshold <= 0 {
+			return nil, ErrInvalidAlertThreshold
+		}
+		rule.Threshold = input.Threshold
+	case domain.AlertKindSpike:
+		if input.Factor <= 1 {
+			return nil, ErrInvalidAlertFactor
+		}
+		rule.Factor = input.Factor
+		rule.WindowHours = input.WindowHours
+		if rule.WindowHours == 0 {
+			rule.WindowHours = defaultAlertWindow
+		}
+		if rule.WindowHours < 1 || rule.WindowHours > maxAlertWindowHours {
+			return nil, ErrInvalidAlertWindow
+		}
+		if input.MinClicks < 0 {
+			return nil, ErrInvalidAlertMinClicks
+		}
+		rule.MinClicks = input.MinClicks
+		if rule.MinClicks == 0 {
+			rule.MinClicks = defaultAlertMinClick
+		}
+	default:
+		return nil, ErrInvalidAlertKind
+	}
+
+	switch input.Channel {
+	case domain.AlertChannelWebhook:
+		// Webhooks are requested from our network, so they get the same
+		// checks as link destinations.
+		if err := s.validator.Validate(ctx, rule.Target); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidAlertTarget, err)
+		}
+	case domain.AlertChannelEmail:
+		addr, err := mail.ParseAddress(rule.Target)
+		if err != nil {
+			return nil, ErrInvalidAlertTarget
+		}
+		// Addresses are not verified, so mail only goes to the owner's
+		// own account address; anything else would make us a relay.
+		user, err := s.userRepo.FindByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, ErrUserNotFound
+		}
+		if !strings.EqualFold(addr.Address, user.Email) {
+			return nil, ErrAlertEmailNotOwn
+		}
+		rule.Target = user.Email
+	default:
+		return nil, ErrInvalidAlertChannel
+	}
+
+	if input.ShortCode != "" {
+		link, err := s.linkRepo.FindByShortCode(ctx, input.ShortCode)
+		if err != nil {
+			return nil, err
+		}
+		if link == nil || link.UserID != userID {
+			return nil, ErrLinkNotFound
+		}
+		rule.LinkID = &link.ID
+		rule.ShortCode = link.ShortCode
+	}
+	return rule, nil
+}
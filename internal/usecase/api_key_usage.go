@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+	"url-shortener/internal/domain"
+)
+
+// APIKeyUsageConfig controls last-used tracking. Values come from
+// API_KEY_USAGE_* env vars, see LoadAPIKeyUsageConfig.
+type APIKeyUsageConfig struct {
+	FlushInterval time.Duration
+	WriteTimeout  time.Duration
+}
+
+func LoadAPIKeyUsageConfig() APIKeyUsageConfig {
+	return APIKeyUsageConfig{
+		FlushInterval: envDuration("API_KEY_USAGE_FLUSH_INTERVAL", time.Minute),
+		WriteTimeout:  envDuration("API_KEY_USAGE_WRITE_TIMEOUT", 10*time.Second),
+	}
+}
+
+// APIKeyUsageTracker keeps the last use of every key in memory and writes
+// them in one batch per flush interval, so authentication never waits on
+// a write. Uses of the same key between flushes collapse into the latest,
+// which also bounds memory by the number of keys.
+type APIKeyUsageTracker struct {
+	userRepo UserRepository
+	cfg      APIKeyUsageConfig
+
+	mu      sync.Mutex
+	pending map[int64]domain.APIKeyUsage
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewAPIKeyUsageTracker(userRepo UserRepository) *APIKeyUsageTracker {
+	return NewAPIKeyUsageTrackerWithConfig(userRepo, LoadAPIKeyUsageConfig())
+}
+
+func NewAPIKeyUsageTrackerWithConfig(userRepo UserRepository, cfg APIKeyUsageConfig) *APIKeyUsageTracker {
+	if userRepo == nil {
+		panic("UserRepository cannot be nil")
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Minute
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = 10 * time.Second
+	}
+	return &APIKeyUsageTracker{
+		userRepo: userRepo,
+		cfg:      cfg,
+		pending:  make(map[int64]domain.APIKeyUsage),
+	}
+}
+
+// Touch notes that keyID was just used from ip.
+func (t *APIKeyUsageTracker) Touch(keyID int64, ip string) {
+	t.mu.Lock()
+	t.pending[keyID] = domain.APIKeyUsage{KeyID: keyID, At: time.Now(), IP: ip}
+	t.mu.Unlock()
+}
+
+// Start launches the flush loop.
+func (t *APIKeyUsageTracker) Start() {
+	if t.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	t.done = make(chan struct{})
+	go func() {
+		defer close(t.done)
+		ticker := time.NewTicker(t.cfg.FlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				t.Flush()
+			}
+		}
+	}()
+}
+
+// Stop ends the loop and writes what is still pending.
+func (t *APIKeyUsageTracker) Stop(ctx context.Context) error {
+	if t.cancel != nil {
+		t.cancel()
+		select {
+		case <-t.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	t.Flush()
+	return nil
+}
+
+// Flush writes the pending uses. It runs on a fresh context so a shutdown
+// still writes; a failed batch is logged and dropped, as last-used times
+// are informational.
+func (t *APIKeyUsageTracker) Flush() {
+	t.mu.Lock()
+	if len(t.pending) == 0 {
+		t.mu.Unlock()
+		return
+	}
+	usage := make([]domain.APIKeyUsage, 0, len(t.pending))
+	for _, u := range t.pending {
+		usage = append(usage, u)
+	}
+	t.pending = make(map[int64]domain.APIKeyUsage)
+	t.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.cfg.WriteTimeout)
+	defer cancel()
+	if err := t.userRepo.RecordAPIKeyUsage(ctx, usage); err != nil {
+		log.Printf("Failed to record last use of %d API keys: %v", len(usage), err)
+	}
+}
@@ -0,0 +1,493 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"url-shortener/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Generated keys look like us_live_<id>_<secret><checksum>. The id is
+// stored in the clear to tell keys apart; the checksum (CRC32 of
+// everything before it) lets secret scanners and the auth middleware
+// reject mistyped or made-up keys without a lookup.
+const (
+	APIKeyPrefixLive  = "us_live_"
+	apiKeyIDLength    = 8
+	apiKeySecretLen   = 32 // ~190 bits
+	apiKeyChecksumLen = 6
+	apiKeyLength      = len(APIKeyPrefixLive) + apiKeyIDLength + 1 + apiKeySecretLen + apiKeyChecksumLen
+)
+
+// maxAPIKeyPrefix is how many leading characters of a legacy key are
+// stored in the clear.
+const maxAPIKeyPrefix = 8
+
+var (
+	ErrAPIKeyLimitExceeded = errors.New("plan API key limit reached")
+	ErrAPIKeyNotFound      = errors.New("API key not found")
+	ErrAPIKeyRotated       = errors.New("API key was already rotated")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrNoScopes            = errors.New("at least one scope is required")
+	ErrInvalidAPIKeyExpiry = errors.New("expires_at must be in the future")
+	ErrInvalidGracePeriod  = errors.New("grace_period is negative or longer than allowed")
+)
+
+// ScopeError rejects a requested scope that does not exist or that the
+// user or creating key cannot grant.
+type ScopeError struct {
+	Scope  string
+	Reason string
+}
+
+func (e *ScopeError) Error() string {
+	return fmt.Sprintf("scope %q %s", e.Scope, e.Reason)
+}
+
+// checkScopesCovered rejects acting on a key with scopes that creator, the
+// scopes of the key making the request, lacks. A nil creator is not
+// limited.
+func checkScopesCovered(creator, scopes []string) error {
+	if creator == nil {
+		return nil
+	}
+	for _, scope := range scopes {
+		if !scopesCover(creator, scope) {
+			return &ScopeError{Scope: scope, Reason: "is not granted to the key making the request"}
+		}
+	}
+	return nil
+}
+
+// ScopeFor returns the scope an HTTP method needs on resource: reads for
+// GET and HEAD, writes for everything else.
+func ScopeFor(resource, method string) string {
+	if method == http.MethodGet || method == http.MethodHead {
+		return resource + ":" + domain.ScopeRead
+	}
+	return resource + ":" + domain.ScopeWrite
+}
+
+// scopeCovers reports whether granted includes requested. Both may be
+// wildcards.
+func scopeCovers(granted, requested string) bool {
+	if granted == domain.ScopeAll || granted == requested {
+		return true
+	}
+	resource, action, ok := strings.Cut(granted, ":")
+	return ok && action == domain.ScopeAll && strings.HasPrefix(requested, resource+":")
+}
+
+func scopesCover(granted []string, requested string) bool {
+	for _, g := range granted {
+		if scopeCovers(g, requested) {
+			return true
+		}
+	}
+	return false
+}
+
+// roleAllowsScope is the ceiling every key of a user is held to, however
+// it was scoped. Only admins may use admin scopes.
+func roleAllowsScope(role, scope string) bool {
+	if role == "admin" {
+		return true
+	}
+	return !strings.HasPrefix(scope, domain.ScopeAdmin+":")
+}
+
+// HasScope reports whether the key the user authenticated with grants
+// scope and the user's current role still allows it.
+func HasScope(user *domain.User, scope string) bool {
+	return user != nil && roleAllowsScope(user.Role, scope) && scopesCover(user.APIKeyScopes, scope)
+}
+
+// normalizeScopes checks that every requested scope exists, that role
+// allows it and, when creator is set, that the creating key has it too.
+// Duplicates are dropped.
+func normalizeScopes(requested []string, role string, creator []string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, ErrNoScopes
+	}
+	scopes := make([]string, 0, len(requested))
+	seen := map[string]bool{}
+	for _, scope := range requested {
+		scope = strings.TrimSpace(scope)
+		if seen[scope] {
+			continue
+		}
+		seen[scope] = true
+		if !validScope(scope) {
+			return nil, &ScopeError{Scope: scope, Reason: "does not exist"}
+		}
+		if scope != domain.ScopeAll && !roleAllowsScope(role, scope) {
+			return nil, &ScopeError{Scope: scope, Reason: "is not allowed for role " + role}
+		}
+		if creator != nil && !scopesCover(creator, scope) {
+			return nil, &ScopeError{Scope: scope, Reason: "is not granted to the key making the request"}
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+// validScope accepts the scopes in domain.Scopes, "<resource>:*" for
+// their resources and "*".
+func validScope(scope string) bool {
+	if scope == domain.ScopeAll {
+		return true
+	}
+	for _, s := range domain.Scopes {
+		if s == scope {
+			return true
+		}
+		if resource, _, _ := strings.Cut(s, ":"); scope == resource+":"+domain.ScopeAll {
+			return true
+		}
+	}
+	return false
+}
+
+// HashAPIKey returns the digest keys are stored and looked up by: the hex
+// SHA-256 of the key. Keys are long random strings, so a plain hash is
+// enough; the hash_api_keys migration computes the same digest in SQL.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyPrefix returns the part of key that is stored in the clear: the
+// "us_live_<id>" part of generated keys. Of other keys it is never more
+// than a quarter, so short legacy keys do not leak. Like the migration it
+// counts characters, not bytes.
+func APIKeyPrefix(key string) string {
+	if ValidAPIKeyFormat(key) {
+		return key[:len(APIKeyPrefixLive)+apiKeyIDLength]
+	}
+	runes := []rune(key)
+	return string(runes[:min(maxAPIKeyPrefix, len(runes)/4)])
+}
+
+// NewAPIKey returns the stored form of key for userID.
+func NewAPIKey(userID int64, key string, scopes []string) *domain.ApiKey {
+	return &domain.ApiKey{UserID: userID, KeyHash: HashAPIKey(key), Prefix: APIKeyPrefix(key), Scopes: scopes}
+}
+
+// GenerateAPIKey returns a new random key in the us_live_ format.
+func GenerateAPIKey() (string, error) {
+	id, err := randomBase62(apiKeyIDLength)
+	if err != nil {
+		return "", err
+	}
+	secret, err := randomBase62(apiKeySecretLen)
+	if err != nil {
+		return "", err
+	}
+	body := APIKeyPrefixLive + id + "_" + secret
+	return body + apiKeyChecksum(body), nil
+}
+
+// ValidAPIKeyFormat reports whether key is a well-formed us_live_ key with
+// a matching checksum. It says nothing about whether the key exists.
+func ValidAPIKeyFormat(key string) bool {
+	if len(key) != apiKeyLength || !strings.HasPrefix(key, APIKeyPrefixLive) {
+		return false
+	}
+	if key[len(APIKeyPrefixLive)+apiKeyIDLength] != '_' {
+		return false
+	}
+	body, checksum := key[:len(key)-apiKeyChecksumLen], key[len(key)-apiKeyChecksumLen:]
+	for _, part := range []string{body[len(APIKeyPrefixLive) : len(APIKeyPrefixLive)+apiKeyIDLength], body[len(APIKeyPrefixLive)+apiKeyIDLength+1:]} {
+		for i := 0; i < len(part); i++ {
+			if strings.IndexByte(alphabet, part[i]) < 0 {
+				return false
+			}
+		}
+	}
+	return apiKeyChecksum(body) == checksum
+}
+
+// apiKeyChecksum is the CRC32 (IEEE) of body in base62, zero-padded.
+func apiKeyChecksum(body string) string {
+	n := uint64(crc32.ChecksumIEEE([]byte(body)))
+	b := make([]byte, apiKeyChecksumLen)
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i] = alphabet[n%uint64(len(alphabet))]
+		n /= uint64(len(alphabet))
+	}
+	return string(b)
+}
+
+func randomBase62(n int) (string, error) {
+	b := make([]byte, n)
+	max := big.NewInt(int64(len(alphabet)))
+	for i := range b {
+		v, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = alphabet[v.Int64()]
+	}
+	return string(b), nil
+}
+
+// APIKeyInput is what a new key is created with. ExpiresAt is optional.
+type APIKeyInput struct {
+	Scopes    []string
+	ExpiresAt *time.Time
+}
+
+// APIKeyRotation is the result of a rotation: the new key with its
+// plaintext, and the replaced key with the time it stops working.
+type APIKeyRotation struct {
+	Key      *domain.ApiKey
+	Secret   string
+	Replaced *domain.ApiKey
+}
+
+// APIKeyService issues, lists, revokes and rotates generated API keys. The
+// plaintext key is only returned from the call that created it.
+type APIKeyService struct {
+	userRepo UserRepository
+	audit    *AuditService
+	// limits caps the keys a user can create for themselves, by plan.
+	limits map[string]int
+	// grace is how long a rotated key keeps working by default, and
+	// maxGrace the longest a caller may ask for.
+	grace    time.Duration
+	maxGrace time.Duration
+}
+
+func NewAPIKeyService(userRepo UserRepository, audit *AuditService) *APIKeyService {
+	if userRepo == nil {
+		panic("UserRepository cannot be nil")
+	}
+	if audit == nil {
+		panic("AuditService cannot be nil")
+	}
+	return &APIKeyService{
+		userRepo: userRepo,
+		audit:    audit,
+		limits: map[string]int{
+			FreePlan:    envInt("API_KEYS_MAX_FREE", 2),
+			PremiumPlan: envInt("API_KEYS_MAX_PREMIUM", 10),
+		},
+		grace:    envDuration("API_KEY_ROTATION_GRACE", 24*time.Hour),
+		maxGrace: envDuration("API_KEY_ROTATION_GRACE_MAX", 7*24*time.Hour),
+	}
+}
+
+// Limit returns how many keys the user may hold, and false when there is
+// no limit. Admins are unlimited; unknown plans get the free limit.
+func (s *APIKeyService) Limit(user *domain.User) (int, bool) {
+	if user == nil || user.Role == "admin" {
+		return 0, false
+	}
+	if limit, ok := s.limits[user.Plan]; ok {
+		return limit, true
+	}
+	return s.limits[FreePlan], true
+}
+
+// Create issues a key for the user themselves, within their plan's limit.
+// The new key cannot have scopes the key making the request lacks.
+func (s *APIKeyService) Create(ctx context.Context, user *domain.User, input APIKeyInput) (_ *domain.ApiKey, _ string, err error) {
+	ctx, span := startSpan(ctx, "APIKeyService.Create", attribute.Int64("user.id", user.ID))
+	defer func() { endSpan(span, err) }()
+
+	scopes, err := normalizeScopes(input.Scopes, user.Role, user.APIKeyScopes)
+	if err != nil {
+		return nil, "", err
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return nil, "", ErrInvalidAPIKeyExpiry
+	}
+	limit, ok := s.Limit(user)
+	if ok && limit <= 0 {
+		return nil, "", ErrAPIKeyLimitExceeded
+	}
+	return s.issue(ctx, user.ID, scopes, input.ExpiresAt, limit)
+}
+
+// Issue creates a key for any user without checking limits; it is meant
+// for admins. Scopes are still capped by the user's role.
+func (s *APIKeyService) Issue(ctx context.Context, userID int64, input APIKeyInput) (_ *domain.ApiKey, _ string, err error) {
+	ctx, span := startSpan(ctx, "APIKeyService.Issue", attribute.Int64("user.id", userID))
+	defer func() { endSpan(span, err) }()
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if user == nil {
+		return nil, "", ErrUserNotFound
+	}
+	scopes, err := normalizeScopes(input.Scopes, user.Role, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return nil, "", ErrInvalidAPIKeyExpiry
+	}
+	return s.issue(ctx, userID, scopes, input.ExpiresAt, 0)
+}
+
+// issue stores a new key; a positive limit caps the user's active keys.
+func (s *APIKeyService) issue(ctx context.Context, userID int64, scopes []string, expiresAt *time.Time, limit int) (*domain.ApiKey, string, error) {
+	key, err := GenerateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+	apiKey := NewAPIKey(userID, key, scopes)
+	apiKey.ExpiresAt = expiresAt
+	created, err := s.userRepo.CreateAPIKey(ctx, apiKey, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	if !created {
+		return nil, "", ErrAPIKeyLimitExceeded
+	}
+	s.audit.Record(ctx, domain.AuditAPIKeyCreate, domain.AuditTargetUser, strconv.FormatInt(userID, 10), nil, auditAPIKeyFields(apiKey))
+	return apiKey, key, nil
+}
+
+// List returns the user's keys that are not revoked or expired.
+func (s *APIKeyService) List(ctx context.Context, userID int64) (_ []*domain.ApiKey, err error) {
+	ctx, span := startSpan(ctx, "APIKeyService.List", attribute.Int64("user.id", userID))
+	defer func() { endSpan(span, err) }()
+	return s.userRepo.ListAPIKeys(ctx, userID)
+}
+
+// Revoke stops the user's key from working right away. The key making the
+// request must hold every scope of the key it revokes.
+func (s *APIKeyService) Revoke(ctx context.Context, user *domain.User, keyID int64) error {
+	return s.revoke(ctx, user.ID, keyID, user.APIKeyScopes)
+}
+
+// RevokeForUser revokes any user's key; it is meant for admins.
+func (s *APIKeyService) RevokeForUser(ctx context.Context, userID, keyID int64) error {
+	return s.revoke(ctx, userID, keyID, nil)
+}
+
+func (s *APIKeyService) revoke(ctx context.Context, userID, keyID int64, creator []string) (err error) {
+	ctx, span := startSpan(ctx, "APIKeyService.Revoke", attribute.Int64("user.id", userID), attribute.Int64("api_key.id", keyID))
+	defer func() { endSpan(span, err) }()
+
+	apiKey, err := s.userRepo.FindAPIKey(ctx, userID, keyID)
+	if err != nil {
+		return err
+	}
+	if apiKey == nil {
+		return ErrAPIKeyNotFound
+	}
+	if err := checkScopesCovered(creator, apiKey.Scopes); err != nil {
+		return err
+	}
+	ok, err := s.userRepo.RevokeAPIKey(ctx, userID, keyID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	s.audit.Record(ctx, domain.AuditAPIKeyRevoke, domain.AuditTargetUser, strconv.FormatInt(userID, 10), auditAPIKeyFields(apiKey), nil)
+	return nil
+}
+
+// Rotate replaces the user's key with a new one with the same scopes and
+// expiry. The old key keeps working for the grace period (default
+// API_KEY_ROTATION_GRACE), so clients can switch over; 0 ends it at once.
+// The new key cannot have scopes the key making the request lacks, and
+// the user's other active keys must leave room for it within their plan.
+func (s *APIKeyService) Rotate(ctx context.Context, user *domain.User, keyID int64, grace *time.Duration) (*APIKeyRotation, error) {
+	limit, ok := s.Limit(user)
+	if ok && limit <= 0 {
+		return nil, ErrAPIKeyLimitExceeded
+	}
+	return s.rotate(ctx, user.ID, keyID, user.APIKeyScopes, grace, limit)
+}
+
+// RotateForUser rotates any user's key without checking limits; it is
+// meant for admins.
+func (s *APIKeyService) RotateForUser(ctx context.Context, userID, keyID int64, grace *time.Duration) (*APIKeyRotation, error) {
+	return s.rotate(ctx, userID, keyID, nil, grace, 0)
+}
+
+// rotate replaces the key; a positive limit caps the user's active keys
+// other than the one being replaced.
+func (s *APIKeyService) rotate(ctx context.Context, userID, keyID int64, creator []string, grace *time.Duration, limit int) (_ *APIKeyRotation, err error) {
+	ctx, span := startSpan(ctx, "APIKeyService.Rotate", attribute.Int64("user.id", userID), attribute.Int64("api_key.id", keyID))
+	defer func() { endSpan(span, err) }()
+
+	period := s.grace
+	if grace != nil {
+		period = *grace
+	}
+	if period < 0 || period > s.maxGrace {
+		return nil, ErrInvalidGracePeriod
+	}
+	old, err := s.userRepo.FindAPIKey(ctx, userID, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if old == nil {
+		return nil, ErrAPIKeyNotFound
+	}
+	if old.ReplacedBy != nil {
+		return nil, ErrAPIKeyRotated
+	}
+	if err := checkScopesCovered(creator, old.Scopes); err != nil {
+		return nil, err
+	}
+
+	key, err := GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	next := NewAPIKey(userID, key, old.Scopes)
+	next.ExpiresAt = old.ExpiresAt
+	graceUntil := time.Now().Add(period)
+	ok, err := s.userRepo.RotateAPIKey(ctx, userID, keyID, next, graceUntil, limit)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// Over the limit, or revoked, expired or rotated by a concurrent
+		// request.
+		current, err := s.userRepo.FindAPIKey(ctx, userID, keyID)
+		switch {
+		case err != nil || current == nil:
+			return nil, ErrAPIKeyNotFound
+		case current.ReplacedBy != nil:
+			return nil, ErrAPIKeyRotated
+		}
+		return nil, ErrAPIKeyLimitExceeded
+	}
+	if old.ExpiresAt == nil || graceUntil.Before(*old.ExpiresAt) {
+		old.ExpiresAt = &graceUntil
+	}
+	s.audit.Record(ctx, domain.AuditAPIKeyRotate, domain.AuditTargetUser, strconv.FormatInt(userID, 10),
+		map[string]any{"api_key_id": old.ID, "prefix": old.Prefix},
+		map[string]any{"api_key_id": next.ID, "prefix": next.Prefix, "scopes": next.Scopes, "replaced_key_expires_at": old.ExpiresAt})
+	return &APIKeyRotation{Key: next, Secret: key, Replaced: old}, nil
+}
+
+func auditAPIKeyFields(apiKey *domain.ApiKey) map[string]any {
+	return map[string]any{
+		"api_key_id": apiKey.ID,
+		"prefix":     apiKey.Prefix,
+		"scopes":     apiKey.Scopes,
+		"expires_at": apiKey.ExpiresAt,
+	}
+}
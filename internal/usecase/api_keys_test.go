@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"url-shortener/internal/domain"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		key, err := GenerateAPIKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ValidAPIKeyFormat(key) {
+			t.Fatalf("generated key %q fails its own format check", key)
+		}
+		if seen[key] {
+			t.Fatalf("key %q generated twice", key)
+		}
+		seen[key] = true
+		if prefix := APIKeyPrefix(key); prefix != key[:len(APIKeyPrefixLive)+apiKeyIDLength] {
+			t.Errorf("APIKeyPrefix(%q) = %q", key, prefix)
+		}
+	}
+}
+
+func TestValidAPIKeyFormat(t *testing.T) {
+	key, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	replaceAt := func(i int) string {
+		c := byte('a')
+		if key[i] == c {
+			c = 'b'
+		}
+		return key[:i] + string(c) + key[i+1:]
+	}
+	idStart := len(APIKeyPrefixLive)
+	secretStart := idStart + apiKeyIDLength + 1
+	checksumStart := len(key) - apiKeyChecksumLen
+
+	tests := []struct {
+		name string
+		key  string
+		want bool
+	}{
+		{name: "generated", key: key, want: true},
+		{name: "empty", key: "", want: false},
+		{name: "legacy key", key: "key1test", want: false},
+		{name: "tampered id", key: replaceAt(idStart), want: false},
+		{name: "tampered secret", key: replaceAt(secretStart + 5), want: false},
+		{name: "tampered checksum", key: replaceAt(checksumStart), want: false},
+		{name: "missing separator", key: key[:secretStart-1] + "x" + key[secretStart:], want: false},
+		{name: "invalid character", key: key[:secretStart] + "-" + key[secretStart+1:], want: false},
+		{name: "truncated", key: key[:len(key)-1], want: false},
+		{name: "extra character", key: key + "0", want: false},
+		{name: "other prefix", key: "us_test_" + key[len(APIKeyPrefixLive):], want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidAPIKeyFormat(tt.key); got != tt.want {
+				t.Errorf("ValidAPIKeyFormat(%q) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIKeyPrefixOfLegacyKeys(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "key1test", want: "ke"},
+		{key: strings.Repeat("x", 64), want: "xxxxxxxx"},
+		{key: "abc", want: ""},
+		{key: "ключключ", want: "кл"},
+	}
+	for _, tt := range tests {
+		if got := APIKeyPrefix(tt.key); got != tt.want {
+			t.Errorf("APIKeyPrefix(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestScopeFor(t *testing.T) {
+	tests := []struct {
+		method string
+		want   string
+	}{
+		{method: http.MethodGet, want: "links:read"},
+		{method: http.MethodHead, want: "links:read"},
+		{method: http.MethodPost, want: "links:write"},
+		{method: http.MethodPut, want: "links:write"},
+		{method: http.MethodPatch, want: "links:write"},
+		{method: http.MethodDelete, want: "links:write"},
+	}
+	for _, tt := range tests {
+		if got := ScopeFor(domain.ScopeLinks, tt.method); got != tt.want {
+			t.Errorf("ScopeFor(links, %s) = %q, want %q", tt.method, got, tt.want)
+		}
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		role   string
+		scopes []string
+		scope  string
+		want   bool
+	}{
+		{name: "exact", role: "user", scopes: []string{"links:read"}, scope: "links:read", want: true},
+		{name: "other action", role: "user", scopes: []string{"links:read"}, scope: "links:write", want: false},
+		{name: "other resource", role: "user", scopes: []string{"links:read"}, scope: "stats:read", want: false},
+		{name: "resource wildcard", role: "user", scopes: []string{"links:*"}, scope: "links:write", want: true},
+		{name: "resource wildcard elsewhere", role: "user", scopes: []string{"links:*"}, scope: "linksx:read", want: false},
+		{name: "full wildcard", role: "user", scopes: []string{"*"}, scope: "alerts:write", want: true},
+		{name: "admin scope for user", role: "user", scopes: []string{"*"}, scope: "admin:read", want: false},
+		{name: "admin scope for admin", role: "admin", scopes: []string{"*"}, scope: "admin:read", want: true},
+		{name: "no scopes", role: "admin", scopes: nil, scope: "links:read", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &domain.User{Role: tt.role, APIKeyScopes: tt.scopes}
+			if got := HasScope(user, tt.scope); got != tt.want {
+				t.Errorf("HasScope(%v, %q) = %v, want %v", tt.scopes, tt.scope, got, tt.want)
+			}
+		})
+	}
+	if HasScope(nil, "links:read") {
+		t.Error("HasScope(nil) = true")
+	}
+}
+
+func TestNormalizeScopes(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested []string
+		role      string
+		creator   []string
+		want      []string
+		wantErr   bool
+	}{
+		{name: "deduplicated", requested: []string{"links:read", " links:read"}, role: "user", want: []string{"links:read"}},
+		{name: "wildcards", requested: []string{"*", "stats:*"}, role: "user", want: []string{"*", "stats:*"}},
+		{name: "none", requested: nil, role: "user", wantErr: true},
+		{name: "unknown", requested: []string{"links:delete"}, role: "user", wantErr: true},
+		{name: "admin scope for user", requested: []string{"admin:read"}, role: "user", wantErr: true},
+		{name: "admin scope for admin", requested: []string{"admin:read"}, role: "admin", want: []string{"admin:read"}},
+		{name: "within creator", requested: []string{"links:read"}, role: "user", creator: []string{"links:*"}, want: []string{"links:read"}},
+		{name: "beyond creator", requested: []string{"links:write"}, role: "user", creator: []string{"links:read"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeScopes(tt.requested, tt.role, tt.creator)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error: %v", err, tt.wantErr)
+			}
+			var scopeErr *ScopeError
+			if err != nil && !errors.Is(err, ErrNoScopes) && !errors.As(err, &scopeErr) {
+				t.Errorf("err = %T, want ErrNoScopes or *ScopeError", err)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("scopes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
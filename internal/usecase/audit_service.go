@@ -0,0 +1,269 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"reflect"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/export"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+var ErrInvalidAuditLimit = fmt.Errorf("limit must be between 1 and %d", maxAuditLimit)
+
+// errAuditChainBroken stops Verify at the first bad event.
+var errAuditChainBroken = errors.New("audit chain broken")
+
+type AuditRepository interface {
+	// Append stores event as the newest entry. Appends are serialized;
+	// hash is called with the hash of the entry before it and returns the
+	// hash of event.
+	Append(ctx context.Context, event *domain.AuditEvent, hash func(prevHash string) string) error
+	// List returns events matching query, newest first.
+	List(ctx context.Context, query domain.AuditQuery) ([]*domain.AuditEvent, error)
+	// Stream calls fn for every event matching query, oldest first. Limit
+	// and BeforeID are ignored.
+	Stream(ctx context.Context, query domain.AuditQuery, fn func(*domain.AuditEvent) error) error
+}
+
+type auditActorKey struct{}
+
+// WithAuditActor returns a context whose audit events are attributed to
+// actor.
+func WithAuditActor(ctx context.Context, actor domain.AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+func auditActorFromContext(ctx context.Context) domain.AuditActor {
+	actor, _ := ctx.Value(auditActorKey{}).(domain.AuditActor)
+	return actor
+}
+
+// AuditService keeps the append-only, hash-chained trail of admin and
+// link mutations.
+type AuditService struct {
+	repo AuditRepository
+}
+
+func NewAuditService(repo AuditRepository) *AuditService {
+	if repo == nil {
+		panic("AuditRepository cannot be nil")
+	}
+	return &AuditService{repo: repo}
+}
+
+// Record appends an event attributed to the actor in ctx. When both
+// before and after are given only the fields that differ are kept. The
+// mutation being audited already happened, so a failure is logged rather
+// than returned.
+func (s *AuditService) Record(ctx context.Context, action, targetType, targetID string, before, after map[string]any) {
+	event := &domain.AuditEvent{
+		Actor:      auditActorFromContext(ctx),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		// Postgres keeps microseconds; the hash must match what is read
+		// back.
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	err := func() error {
+		var err error
+		if event.Before, err = normalizeAuditFields(before); err != nil {
+			return err
+		}
+		if event.After, err = normalizeAuditFields(after); err != nil {
+			return err
+		}
+		if before != nil && after != nil {
+			event.Before, event.After = auditChanges(event.Before, event.After)
+		}
+		// The request may be cancelled once the response is written; the
+		// event must still be stored.
+		return s.repo.Append(context.WithoutCancel(ctx), event, func(prevHash string) string {
+			return auditHash(prevHash, event)
+		})
+	}()
+	if err != nil {
+		log.Printf("Failed to record audit event %s on %s %s: %v", action, targetType, targetID, err)
+	}
+}
+
+// List returns audit events, newest first.
+func (s *AuditService) List(ctx context.Context, query domain.AuditQuery) (_ []*domain.AuditEvent, err error) {
+	ctx, span := startSpan(ctx, "AuditService.List")
+	defer func() { endSpan(span, err) }()
+
+	if query.Limit == 0 {
+		query.Limit = defaultAuditLimit
+	}
+	if query.Limit < 0 || query.Limit > maxAuditLimit {
+		return nil, ErrInvalidAuditLimit
+	}
+	if query.From != nil && query.To != nil && !query.From.Before(*query.To) {
+		return nil, ErrInvalidStatsRange
+	}
+	return s.repo.List(ctx, query)
+}
+
+// Export writes the events matching query to w in one of the
+// domain.ExportFormat* formats, oldest first, and returns how many it
+// wrote.
+func (s *AuditService) Export(ctx context.Context, query domain.AuditQuery, format string, w io.Writer) (int64, error) {
+	if format == "" {
+		format = domain.ExportFormatCSV
+	}
+	if !domain.IsValidExportFormat(format) {
+		return 0, ErrInvalidExportFormat
+	}
+	if query.From != nil && query.To != nil && !query.From.Before(*query.To) {
+		return 0, ErrInvalidStatsRange
+	}
+	return writeRows(format, w, func(emit func(export.AuditRow) error) error {
+		return s.repo.Stream(ctx, query, func(e *domain.AuditEvent) error {
+			return emit(export.NewAuditRow(e))
+		})
+	})
+}
+
+// Verify walks the whole trail and recomputes every hash. It stops at the
+// first event that does not match.
+func (s *AuditService) Verify(ctx context.Context) (_ *domain.AuditVerification, err error) {
+	ctx, span := startSpan(ctx, "AuditService.Verify")
+	defer func() { endSpan(span, err) }()
+
+	result := &domain.AuditVerification{Valid: true}
+	err = s.repo.Stream(ctx, domain.AuditQuery{}, func(e *domain.AuditEvent) error {
+		result.Checked++
+		switch {
+		case e.PrevHash != result.HeadHash:
+			result.Reason = "prev_hash does not match the previous event"
+		case auditHash(e.PrevHash, e) != e.Hash:
+			result.Reason = "hash does not match the event"
+		default:
+			result.HeadHash = e.Hash
+			return nil
+		}
+		id := e.ID
+		result.Valid = false
+		result.BrokenAt = &id
+		return errAuditChainBroken
+	})
+	if err != nil && !errors.Is(err, errAuditChainBroken) {
+		return nil, err
+	}
+	return result, nil
+}
+
+// auditHashInput is what an event hash covers. The field order is fixed,
+// and encoding/json sorts the keys of Before and After.
+type auditHashInput struct {
+	PrevHash      string         `json:"prev_hash"`
+	CreatedAt     string         `json:"created_at"`
+	ActorUserID   *int64         `json:"actor_user_id"`
+	ActorAPIKeyID *int64         `json:"actor_api_key_id"`
+	IP            string         `json:"ip"`
+	RequestID     string         `json:"request_id"`
+	Action        string         `json:"action"`
+	TargetType    string         `json:"target_type"`
+	TargetID      string         `json:"target_id"`
+	Before        map[string]any `json:"before"`
+	After         map[string]any `json:"after"`
+}
+
+// auditHash returns the hex SHA-256 of the event's compact JSON form.
+func auditHash(prevHash string, e *domain.AuditEvent) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	// Only unsupported values fail to encode, and normalizeAuditFields
+	// already rejected those.
+	_ = enc.Encode(auditHashInput{
+		PrevHash:      prevHash,
+		CreatedAt:     e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		ActorUserID:   e.Actor.UserID,
+		ActorAPIKeyID: e.Actor.APIKeyID,
+		IP:            e.Actor.IP,
+		RequestID:     e.Actor.RequestID,
+		Action:        e.Action,
+		TargetType:    e.TargetType,
+		TargetID:      e.TargetID,
+		Before:        e.Before,
+		After:         e.After,
+	})
+	sum := sha256.Sum256(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeAuditFields round-trips m through JSON so it holds exactly what
+// is read back from the database, e.g. times as strings and numbers as
+// json.Number.
+func normalizeAuditFields(m map[string]any) (map[string]any, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var out map[string]any
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// auditChanges keeps the fields whose value differs between before and
+// after.
+func auditChanges(before, after map[string]any) (map[string]any, map[string]any) {
+	changedBefore := map[string]any{}
+	changedAfter := map[string]any{}
+	for k, v := range before {
+		if w, ok := after[k]; !ok || !reflect.DeepEqual(v, w) {
+			changedBefore[k] = v
+		}
+	}
+	for k, w := range after {
+		if v, ok := before[k]; !ok || !reflect.DeepEqual(v, w) {
+			changedAfter[k] = w
+		}
+	}
+	if len(changedBefore) == 0 {
+		changedBefore = nil
+	}
+	if len(changedAfter) == 0 {
+		changedAfter = nil
+	}
+	return changedBefore, changedAfter
+}
+
+func auditUserFields(user *domain.User) map[string]any {
+	return map[string]any{
+		"email":           user.Email,
+		"plan":            user.Plan,
+		"role":            user.Role,
+		"plan_expires_at": user.PlanExpiresAt,
+	}
+}
+
+func auditLinkFields(link *domain.Link) map[string]any {
+	return map[string]any{
+		"short_code":  link.ShortCode,
+		"long_url":    link.LongURL,
+		"campaign_id": link.CampaignID,
+		"user_id":     link.UserID,
+	}
+}
@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"url-shortener/internal/domain"
+)
+
+var (
+	ErrCampaignNotFound     = errors.New("campaign not found")
+	ErrInvalidCampaignName  = errors.New("campaign name is required")
+	ErrInvalidCampaignRange = errors.New("campaign must end after it starts")
+)
+
+type CampaignRepository interface {
+	Create(ctx context.Context, campaign *domain.Campaign) error
+	FindByID(ctx context.Context, userID, campaignID int64) (*domain.Campaign, error)
+	ListByUser(ctx context.Context, userID int64) ([]*domain.Campaign, error)
+	Update(ctx context.Context, campaign *domain.Campaign) error
+	// SoftDelete, AssignLinks and UnassignLink return the links whose
+	// campaign they changed, as they were before.
+	SoftDelete(ctx context.Context, userID, campaignID int64) ([]*domain.Link, error)
+	AssignLinks(ctx context.Context, userID, campaignID int64, shortCodes []string) ([]*domain.Link, error)
+	// UnassignLink returns nil if the link is not in the campaign.
+	UnassignLink(ctx context.Context, userID, campaignID int64, shortCode string) (*domain.Link, error)
+	Stats(ctx context.Context, campaignID int64, from, to *time.Time, includeBots bool) (*domain.CampaignStats, error)
+}
+
+type CampaignService struct {
+	campaignRepo CampaignRepository
+	audit        *AuditService
+}
+
+func NewCampaignService(campaignRepo CampaignRepository, audit *AuditService) *CampaignService {
+	if campaignRepo == nil {
+		panic("CampaignRepository cannot be nil")
+	}
+	if audit == nil {
+		panic("AuditService cannot be nil")
+	}
+	return &CampaignService{campaignRepo: campaignRepo, audit: audit}
+}
+
+func (s *CampaignService) CreateCampaign(ctx context.Context, userID int64, name string, startsAt, endsAt *time.Time) (*domain.Campaign, error) {
+	campaign := &domain.Campaign{UserID: userID, Name: strings.TrimSpace(name), StartsAt: startsAt, EndsAt: endsAt}
+	if err := validateCampaign(campaign); err != nil {
+		return nil, err
+	}
+	if err := s.campaignRepo.Create(ctx, campaign); err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+func (s *CampaignService) GetCampaign(ctx context.Context, userID, campaignID int64) (*domain.Campaign, error) {
+	campaign, err := s.campaignRepo.FindByID(ctx, userID, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	if campaign == nil {
+		return nil, ErrCampaignNotFound
+	}
+	return campaign, nil
+}
+
+func (s *CampaignService) ListCampaigns(ctx context.Context, userID int64) ([]*domain.Campaign, error) {
+	return s.campaignRepo.ListByUser(ctx, userID)
+}
+
+func (s *CampaignService) UpdateCampaign(ctx context.Context, userID, campaignID int64, name string, startsAt, endsAt *time.Time) (*domain.Campaign, error) {
+	campaign, err := s.GetCampaign(ctx, userID, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	campaign.Name = strings.TrimSpace(name)
+	campaign.StartsAt = startsAt
+	campaign.EndsAt = endsAt
+	if err := validateCampaign(campaign); err != nil {
+		return nil, err
+	}
+	if err := s.campaignRepo.Update(ctx, campaign); err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+func (s *CampaignService) DeleteCampaign(ctx context.Context, userID, campaignID int64) error {
+	if _, err := s.GetCampaign(ctx, userID, campaignID); err != nil {
+		return err
+	}
+	detached, err := s.campaignRepo.SoftDelete(ctx, userID, campaignID)
+	if err != nil {
+		return err
+	}
+	s.recordCampaignChanges(ctx, detached, nil)
+	return nil
+}
+
+// AssignLinks moves the given links of the user into the campaign and
+// returns how many were updated. Unknown short codes are ignored.
+func (s *CampaignService) AssignLinks(ctx context.Context, userID, campaignID int64, shortCodes []string) (int, error) {
+	if _, err := s.GetCampaign(ctx, userID, campaignID); err != nil {
+		return 0, err
+	}
+	assigned, err := s.campaignRepo.AssignLinks(ctx, userID, campaignID, shortCodes)
+	if err != nil {
+		return 0, err
+	}
+	s.recordCampaignChanges(ctx, assigned, &campaignID)
+	return len(assigned), nil
+}
+
+func (s *CampaignService) UnassignLink(ctx context.Context, userID, campaignID int64, shortCode string) error {
+	if _, err := s.GetCampaign(ctx, userID, campaignID); err != nil {
+		return err
+	}
+	link, err := s.campaignRepo.UnassignLink(ctx, userID, campaignID, shortCode)
+	if err != nil {
+		return err
+	}
+	if link != nil {
+		s.recordCampaignChanges(ctx, []*domain.Link{link}, nil)
+	}
+	return nil
+}
+
+// recordCampaignChanges audits a link.update for every link whose campaign
+// changed to campaignID.
+func (s *CampaignService) recordCampaignChanges(ctx context.Context, before []*domain.Link, campaignID *int64) {
+	for _, link := range before {
+		if link.CampaignID == nil && campaignID == nil ||
+			link.CampaignID != nil && campaignID != nil && *link.CampaignID == *campaignID {
+			continue
+		}
+		after := *link
+		after.CampaignID = campaignID
+		s.audit.Record(ctx, domain.AuditLinkUpdate, domain.AuditTargetLink, strconv.FormatInt(link.ID, 10), auditLinkFields(link), auditLinkFields(&after))
+	}
+}
+
+// GetCampaignStats aggregates across the campaign's links. Without an
+// explicit range it defaults to the campaign's own date range.
+func (s *CampaignService) GetCampaignStats(ctx context.Context, userID, campaignID int64, from, to *time.Time, includeBots bool) (*domain.CampaignStats, error) {
+	campaign, err := s.GetCampaign(ctx, userID, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	if from == nil {
+		from = campaign.StartsAt
+	}
+	if to == nil {
+		to = campaign.EndsAt
+	}
+	return s.campaignRepo.Stats(ctx, campaignID, from, to, includeBots)
+}
+
+func validateCampaign(c *domain.Campaign) error {
+	if c.Name == "" {
+		return ErrInvalidCampaignName
+	}
+	if c.StartsAt != nil && c.EndsAt != nil && !c.EndsAt.After(*c.StartsAt) {
+		return ErrInvalidCampaignRange
+	}
+	return nil
+}
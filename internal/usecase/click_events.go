@@ -0,0 +1,211 @@
+package usecase
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"net/netip"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/useragent"
+)
+
+const (
+	maxReferrerLength  = 1024
+	maxSourceLength    = 128
+	maxLanguageTags    = 3
+	ipHashHexLength    = 32
+	defaultClickIPSalt = "url-shortener-dev-salt"
+)
+
+// ClickPrivacyConfig controls what is kept from a click. Values come from
+// CLICK_* env vars, see LoadClickPrivacyConfig.
+type ClickPrivacyConfig struct {
+	IPSalt     string
+	TruncateIP bool
+	RespectDNT bool
+}
+
+func LoadClickPrivacyConfig() ClickPrivacyConfig {
+	return ClickPrivacyConfig{
+		IPSalt:     envString("CLICK_IP_SALT", defaultClickIPSalt),
+		TruncateIP: envBool("CLICK_IP_TRUNCATE", true),
+		RespectDNT: envBool("CLICK_RESPECT_DNT", true),
+	}
+}
+
+// CountryResolver maps a client address to an ISO 3166-1 alpha-2 country
+// code, or "" when unknown.
+type CountryResolver interface {
+	Country(addr netip.Addr) string
+}
+
+type BotVerdict struct {
+	Bot    bool
+	Reason string
+}
+
+// BotClassifier decides whether a click was made by a bot, crawler, link
+// unfurler, scanner or prefetcher.
+type BotClassifier interface {
+	Classify(click domain.ClickContext) BotVerdict
+}
+
+// ClickEventBuilder turns raw request data into a privacy-preserving
+// ClickEvent.
+type ClickEventBuilder struct {
+	cfg       ClickPrivacyConfig
+	countries CountryResolver
+	bots      BotClassifier
+}
+
+// NewClickEventBuilder creates a builder. countries may be nil, in which
+// case clicks are recorded without a country; without bots only
+// User-Agents the parser knows as bots are flagged.
+func NewClickEventBuilder(countries CountryResolver, bots BotClassifier) *ClickEventBuilder {
+	return NewClickEventBuilderWithConfig(LoadClickPrivacyConfig(), countries, bots)
+}
+
+func NewClickEventBuilderWithConfig(cfg ClickPrivacyConfig, countries CountryResolver, bots BotClassifier) *ClickEventBuilder {
+	return &ClickEventBuilder{cfg: cfg, countries: countries, bots: bots}
+}
+
+// Build creates the event for a click on link. Clicks from visitors who
+// sent DNT or GPC are still counted, but nothing about them is stored.
+// Bot clicks are flagged so they can be counted apart from human ones.
+func (b *ClickEventBuilder) Build(link *domain.Link, click domain.ClickContext) *domain.ClickEvent {
+	at := click.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+	event := &domain.ClickEvent{LinkID: link.ID, UserID: link.UserID, ShortCode: link.ShortCode, ClickedAt: at.UTC()}
+	ua := useragent.Parse(click.UserAgent)
+	if b.bots != nil {
+		verdict := b.bots.Classify(click)
+		event.IsBot, event.BotReason = verdict.Bot, verdict.Reason
+	} else if ua.Device == useragent.DeviceBot {
+		event.IsBot, event.BotReason = true, "user-agent"
+	}
+	if b.cfg.RespectDNT && click.DoNotTrack {
+		event.OptOut = true
+		return event
+	}
+
+	event.Referrer = truncate(click.Referrer, maxReferrerLength)
+	event.ReferrerDomain = referrerDomain(click.Referrer)
+
+	event.Browser = ua.Browser
+	event.BrowserVersion = ua.BrowserVersion
+	event.OS = ua.OS
+	event.Device = ua.Device
+
+	event.Languages, event.PrimaryLanguage = summarizeLanguages(click.AcceptLanguage)
+	if addr, err := netip.ParseAddr(strings.TrimSpace(click.IP)); err == nil {
+		addr = addr.Unmap()
+		event.IPHash = b.hashIP(addr)
+		if !event.IsBot {
+			event.VisitorHash = b.visitorHash(addr, click.UserAgent, event.ClickedAt)
+		}
+		if b.countries != nil {
+			event.Country = b.countries.Country(addr)
+		}
+	}
+	event.Source = truncate(strings.TrimSpace(click.Source), maxSourceLength)
+	return event
+}
+
+// hashIP returns a salted HMAC of the (optionally truncated) IP, so the
+// same visitor can be correlated without storing the address.
+func (b *ClickEventBuilder) hashIP(addr netip.Addr) string {
+	if b.cfg.TruncateIP {
+		bits := 24
+		if addr.Is6() {
+			bits = 48
+		}
+		if prefix, err := addr.Prefix(bits); err == nil {
+			addr = prefix.Addr()
+		}
+	}
+	mac := hmac.New(sha256.New, []byte(b.cfg.IPSalt))
+	mac.Write([]byte(addr.String()))
+	return hex.EncodeToString(mac.Sum(nil))[:ipHashHexLength]
+}
+
+// visitorHash fingerprints a visitor for unique counting. The key rotates
+// every UTC day, so the same visitor cannot be linked across days, and the
+// hash is only ever added to HLL sketches, never stored.
+func (b *ClickEventBuilder) visitorHash(addr netip.Addr, userAgent string, at time.Time) uint64 {
+	dayKey := hmac.New(sha256.New, []byte(b.cfg.IPSalt))
+	dayKey.Write([]byte("visitor|" + at.UTC().Format(time.DateOnly)))
+	mac := hmac.New(sha256.New, dayKey.Sum(nil))
+	mac.Write([]byte(addr.String()))
+	mac.Write([]byte{0})
+	mac.Write([]byte(userAgent))
+	return binary.BigEndian.Uint64(mac.Sum(nil))
+}
+
+func referrerDomain(referrer string) string {
+	if referrer == "" {
+		return ""
+	}
+	u, err := url.Parse(referrer)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// summarizeLanguages keeps the top Accept-Language tags by quality, e.g.
+// "vi-VN,vi;q=0.9,en;q=0.8" -> ("vi-vn,vi,en", "vi").
+func summarizeLanguages(header string) (string, string) {
+	type tag struct {
+		name string
+		q    float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if name == "" || name == "*" || len(name) > 35 {
+			continue
+		}
+		q := 1.0
+		for _, f := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(f), "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			tags = append(tags, tag{name, q})
+		}
+	}
+	if len(tags) == 0 {
+		return "", ""
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	if len(tags) > maxLanguageTags {
+		tags = tags[:maxLanguageTags]
+	}
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = t.name
+	}
+	primary, _, _ := strings.Cut(names[0], "-")
+	return strings.Join(names, ","), primary
+}
+
+// truncate caps s at n bytes and drops invalid UTF-8 (including a rune cut
+// in half), which Postgres would reject.
+func truncate(s string, n int) string {
+	if len(s) > n {
+		s = s[:n]
+	}
+	return strings.ToValidUTF8(s, "")
+}
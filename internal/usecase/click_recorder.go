@@ -0,0 +1,235 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"url-shortener/internal/domain"
+)
+
+const (
+	ClickQueueDrop  = "drop"
+	ClickQueueBlock = "block"
+)
+
+// ClickRecorderConfig controls click ingestion. Values come from CLICK_*
+// env vars, see LoadClickRecorderConfig.
+type ClickRecorderConfig struct {
+	QueueSize     int
+	Workers       int
+	BatchSize     int
+	FlushInterval time.Duration
+	// FullPolicy decides what Record does when the queue is full: "drop"
+	// discards the click, "block" waits for room until the request ends.
+	FullPolicy   string
+	WriteTimeout time.Duration
+}
+
+func LoadClickRecorderConfig() ClickRecorderConfig {
+	policy := strings.ToLower(envString("CLICK_QUEUE_FULL_POLICY", ClickQueueDrop))
+	if policy != ClickQueueBlock {
+		policy = ClickQueueDrop
+	}
+	return ClickRecorderConfig{
+		QueueSize:     envInt("CLICK_QUEUE_SIZE", 10000),
+		Workers:       envInt("CLICK_WORKERS", 2),
+		BatchSize:     envInt("CLICK_BATCH_SIZE", 500),
+		FlushInterval: envDuration("CLICK_FLUSH_INTERVAL", time.Second),
+		FullPolicy:    policy,
+		WriteTimeout:  envDuration("CLICK_WRITE_TIMEOUT", 10*time.Second),
+	}
+}
+
+// ClickRecorderStats is a snapshot of the ingestion counters since start.
+type ClickRecorderStats struct {
+	Enqueued      uint64 `json:"enqueued"`
+	Dropped       uint64 `json:"dropped"`
+	Recorded      uint64 `json:"recorded"`
+	Failed        uint64 `json:"failed"`
+	Batches       uint64 `json:"batches"`
+	QueueLength   int    `json:"queueLength"`
+	QueueCapacity int    `json:"queueCapacity"`
+	FullPolicy    string `json:"fullPolicy"`
+}
+
+// ClickRecorder takes click events off the redirect path. Events go into a
+// bounded queue that a pool of workers drains in batches, so redirect
+// latency no longer depends on how fast the database writes.
+type ClickRecorder struct {
+	linkRepo LinkRepository
+	live     *LiveClickHub
+	cfg      ClickRecorderConfig
+	queue    chan *domain.ClickEvent
+
+	// mu guards closed so Record never sends on a closed queue.
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+
+	enqueued atomic.Uint64
+	dropped  atomic.Uint64
+	recorded atomic.Uint64
+	failed   atomic.Uint64
+	batches  atomic.Uint64
+}
+
+func NewClickRecorder(linkRepo LinkRepository, live *LiveClickHub) *ClickRecorder {
+	return NewClickRecorderWithConfig(linkRepo, live, LoadClickRecorderConfig())
+}
+
+func NewClickRecorderWithConfig(linkRepo LinkRepository, live *LiveClickHub, cfg ClickRecorderConfig) *ClickRecorder {
+	if linkRepo == nil {
+		panic("LinkRepository cannot be nil")
+	}
+	if live == nil {
+		panic("LiveClickHub cannot be nil")
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = 10 * time.Second
+	}
+	return &ClickRecorder{
+		linkRepo: linkRepo,
+		live:     live,
+		cfg:      cfg,
+		queue:    make(chan *domain.ClickEvent, cfg.QueueSize),
+	}
+}
+
+// Start launches the workers.
+func (r *ClickRecorder) Start() {
+	for i := 0; i < r.cfg.Workers; i++ {
+		r.wg.Add(1)
+		go r.work()
+	}
+}
+
+// Stop stops accepting clicks and waits for the workers to flush what is
+// already queued, or for ctx to expire.
+func (r *ClickRecorder) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.queue)
+	}
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		if n := len(r.queue); n > 0 {
+			log.Printf("Click recorder stopped with %d events unflushed", n)
+		}
+		return ctx.Err()
+	}
+}
+
+// Record queues event for writing. With the drop policy it never waits;
+// with the block policy it waits for room until ctx is done. Lost events
+// are counted in Stats and are not returned as errors.
+func (r *ClickRecorder) Record(ctx context.Context, event *domain.ClickEvent) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		r.dropped.Add(1)
+		return
+	}
+
+	select {
+	case r.queue <- event:
+		r.enqueued.Add(1)
+		return
+	default:
+	}
+	if r.cfg.FullPolicy != ClickQueueBlock {
+		r.dropped.Add(1)
+		return
+	}
+	select {
+	case r.queue <- event:
+		r.enqueued.Add(1)
+	case <-ctx.Done():
+		r.dropped.Add(1)
+	}
+}
+
+func (r *ClickRecorder) Stats() ClickRecorderStats {
+	return ClickRecorderStats{
+		Enqueued:      r.enqueued.Load(),
+		Dropped:       r.dropped.Load(),
+		Recorded:      r.recorded.Load(),
+		Failed:        r.failed.Load(),
+		Batches:       r.batches.Load(),
+		QueueLength:   len(r.queue),
+		QueueCapacity: cap(r.queue),
+		FullPolicy:    r.cfg.FullPolicy,
+	}
+}
+
+// work collects events until the batch is full or the flush interval
+// passes. It returns once the queue is closed and drained.
+func (r *ClickRecorder) work() {
+	defer r.wg.Done()
+	ticker := time.NewTicker(r.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*domain.ClickEvent, 0, r.cfg.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		r.write(batch)
+		batch = make([]*domain.ClickEvent, 0, r.cfg.BatchSize)
+	}
+	for {
+		select {
+		case event, ok := <-r.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= r.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// write stores one batch and publishes it to live streams. It runs on a
+// fresh context so a shutdown still flushes; a failed batch is logged and
+// counted, not retried.
+func (r *ClickRecorder) write(batch []*domain.ClickEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.WriteTimeout)
+	defer cancel()
+	r.batches.Add(1)
+	if err := r.linkRepo.TrackClicks(ctx, batch); err != nil {
+		r.failed.Add(uint64(len(batch)))
+		log.Printf("Failed to record %d click events: %v", len(batch), err)
+		return
+	}
+	r.recorded.Add(uint64(len(batch)))
+	r.live.Publish(ctx, batch)
+}
@@ -0,0 +1,238 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"url-shortener/internal/domain"
+)
+
+type ClickMaintenanceRepository interface {
+	// EnsurePartitions creates the missing monthly click_events partitions
+	// for months months starting with the month of from, moving their rows
+	// out of the default partition. It returns the partitions it created.
+	EnsurePartitions(ctx context.Context, from time.Time, months int) ([]string, error)
+	// DropPartitionsBefore drops the monthly partitions that end at or
+	// before cutoff and returns their names.
+	DropPartitionsBefore(ctx context.Context, cutoff time.Time) ([]string, error)
+	// DeleteEventsBefore deletes up to limit click events older than before
+	// of users on plans, or, with excludePlans, on any other plan.
+	DeleteEventsBefore(ctx context.Context, plans []string, excludePlans bool, before time.Time, limit int) (int64, error)
+	// RebuildRollups recomputes one UTC day of rollups from the raw events,
+	// for every link or only linkID. With onlyMissing, only links with
+	// events but no hourly rollups that day are rebuilt.
+	RebuildRollups(ctx context.Context, day time.Time, linkID *int64, onlyMissing bool) (*domain.RollupRepair, error)
+	// CounterDrift lists links whose counters differ from their rollups.
+	CounterDrift(ctx context.Context, linkID *int64, limit int) ([]domain.CounterDrift, error)
+	// ResetCounters sets the links' counters to the sums of their rollups.
+	ResetCounters(ctx context.Context, linkIDs []int64) (int64, error)
+}
+
+// ClickRetentionConfig is loaded from CLICK_RETENTION_* and
+// CLICK_PARTITIONS_AHEAD env vars, see LoadClickRetentionConfig.
+type ClickRetentionConfig struct {
+	// Enabled turns on deleting old events; partitions are created either
+	// way.
+	Enabled  bool
+	Interval time.Duration
+	// PartitionsAhead is how many months of partitions exist beyond the
+	// current one.
+	PartitionsAhead int
+	// PlanDays is how long each plan keeps raw events; plans not listed
+	// keep them DefaultDays.
+	PlanDays    map[string]int
+	DefaultDays int
+	BatchSize   int
+}
+
+func LoadClickRetentionConfig() ClickRetentionConfig {
+	cfg := ClickRetentionConfig{
+		Enabled:         envBool("CLICK_RETENTION_ENABLED", true),
+		Interval:        envDuration("CLICK_RETENTION_INTERVAL", 6*time.Hour),
+		PartitionsAhead: envInt("CLICK_PARTITIONS_AHEAD", 3),
+		PlanDays:        map[string]int{FreePlan: 90, PremiumPlan: 730},
+		DefaultDays:     envInt("CLICK_RETENTION_DEFAULT_DAYS", 365),
+		BatchSize:       envInt("CLICK_RETENTION_BATCH_SIZE", 10000),
+	}
+	if entries := envList("CLICK_RETENTION_DAYS"); len(entries) > 0 {
+		cfg.PlanDays = map[string]int{}
+		for _, entry := range entries {
+			plan, days, ok := strings.Cut(entry, ":")
+			n, err := strconv.Atoi(strings.TrimSpace(days))
+			if !ok || err != nil || n <= 0 {
+				log.Printf("Ignoring invalid CLICK_RETENTION_DAYS entry %q", entry)
+				continue
+			}
+			cfg.PlanDays[strings.TrimSpace(plan)] = n
+		}
+	}
+	return cfg
+}
+
+// MaxDays is the longest retention of any plan. Whole partitions older
+// than that hold no event any plan still keeps.
+func (c ClickRetentionConfig) MaxDays() int {
+	days := c.DefaultDays
+	for _, d := range c.PlanDays {
+		days = max(days, d)
+	}
+	return days
+}
+
+// Floor is the earliest time from which no plan has lost raw events yet.
+// Without retention nothing is ever deleted.
+func (c ClickRetentionConfig) Floor(now time.Time) time.Time {
+	if !c.Enabled {
+		return time.Time{}
+	}
+	days := c.DefaultDays
+	for _, d := range c.PlanDays {
+		days = min(days, d)
+	}
+	return now.AddDate(0, 0, -days)
+}
+
+// ClickRetentionResult summarizes one retention run.
+type ClickRetentionResult struct {
+	CreatedPartitions []string
+	DroppedPartitions []string
+	DeletedEvents     int64
+}
+
+// ClickRetention keeps click_events partitioned and bounded: it creates
+// upcoming monthly partitions, drops months past every plan's retention
+// and deletes the older events of plans with a shorter retention. Rollups
+// and link counters are never touched, so statistics outlive raw events.
+type ClickRetention struct {
+	repo ClickMaintenanceRepository
+	cfg  ClickRetentionConfig
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewClickRetention(repo ClickMaintenanceRepository) *ClickRetention {
+	return NewClickRetentionWithConfig(repo, LoadClickRetentionConfig())
+}
+
+func NewClickRetentionWithConfig(repo ClickMaintenanceRepository, cfg ClickRetentionConfig) *ClickRetention {
+	if repo == nil {
+		panic("ClickMaintenanceRepository cannot be nil")
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 10000
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 6 * time.Hour
+	}
+	return &ClickRetention{repo: repo, cfg: cfg}
+}
+
+// Start runs retention right away, so partitions exist before the first
+// clicks are written, and then every Interval.
+func (r *ClickRetention) Start() {
+	if r.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			if _, err := r.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("Click retention failed: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (r *ClickRetention) Stop(ctx context.Context) error {
+	if r.cancel == nil {
+		return nil
+	}
+	r.cancel()
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run performs one retention pass.
+func (r *ClickRetention) Run(ctx context.Context) (*ClickRetentionResult, error) {
+	now := time.Now().UTC()
+	result := &ClickRetentionResult{}
+	created, err := r.repo.EnsurePartitions(ctx, now, r.cfg.PartitionsAhead+1)
+	result.CreatedPartitions = created
+	if err != nil {
+		return result, err
+	}
+	for _, name := range created {
+		log.Printf("Created click partition %s", name)
+	}
+	if !r.cfg.Enabled {
+		return result, nil
+	}
+
+	maxDays := r.cfg.MaxDays()
+	dropped, err := r.repo.DropPartitionsBefore(ctx, now.AddDate(0, 0, -maxDays))
+	result.DroppedPartitions = dropped
+	if err != nil {
+		return result, err
+	}
+	for _, name := range dropped {
+		log.Printf("Dropped click partition %s", name)
+	}
+
+	plans := make([]string, 0, len(r.cfg.PlanDays))
+	for plan, days := range r.cfg.PlanDays {
+		plans = append(plans, plan)
+		if days >= maxDays {
+			continue
+		}
+		n, err := r.deleteBefore(ctx, []string{plan}, false, now.AddDate(0, 0, -days))
+		result.DeletedEvents += n
+		if err != nil {
+			return result, err
+		}
+	}
+	if r.cfg.DefaultDays < maxDays {
+		n, err := r.deleteBefore(ctx, plans, true, now.AddDate(0, 0, -r.cfg.DefaultDays))
+		result.DeletedEvents += n
+		if err != nil {
+			return result, err
+		}
+	}
+	if result.DeletedEvents > 0 {
+		log.Printf("Deleted %d click events past their plan's retention", result.DeletedEvents)
+	}
+	return result, nil
+}
+
+// deleteBefore deletes in batches so no single statement holds locks on
+// millions of rows.
+func (r *ClickRetention) deleteBefore(ctx context.Context, plans []string, exclude bool, before time.Time) (int64, error) {
+	var total int64
+	for {
+		n, err := r.repo.DeleteEventsBefore(ctx, plans, exclude, before, r.cfg.BatchSize)
+		total += n
+		if err != nil || n < int64(r.cfg.BatchSize) {
+			return total, err
+		}
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+	}
+}
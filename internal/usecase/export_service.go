@@ -0,0 +1,437 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/export"
+)
+
+const exportSweepInterval = time.Minute
+
+var (
+	ErrInvalidExportFormat  = errors.New("format must be one of " + strings.Join(domain.ExportFormats, ", "))
+	ErrInvalidExportDataset = errors.New("dataset must be one of " + strings.Join(domain.ExportDatasets, ", "))
+	ErrInvalidExportRange   = errors.New("from must be before to")
+	ErrExportNotFound       = errors.New("export not found")
+	ErrExportNotReady       = errors.New("export is not ready")
+)
+
+type ClickExportRepository interface {
+	// StreamClicks calls fn for every click event matching query, in click
+	// order, without loading the result into memory.
+	StreamClicks(ctx context.Context, query domain.ExportQuery, fn func(*domain.ExportedClick) error) error
+	// StreamHourlyClicks does the same for the hourly click rollups.
+	StreamHourlyClicks(ctx context.Context, query domain.ExportQuery, fn func(*domain.HourlyClicks) error) error
+}
+
+type ExportJobRepository interface {
+	Create(ctx context.Context, job *domain.ExportJob) error
+	FindByToken(ctx context.Context, token string) (*domain.ExportJob, error)
+	// ListRunnable returns pending jobs and running jobs started before
+	// staleBefore, whose worker is presumed dead.
+	ListRunnable(ctx context.Context, staleBefore time.Time, limit int) ([]*domain.ExportJob, error)
+	// Claim marks a runnable job as running. It reports false when another
+	// worker got there first.
+	Claim(ctx context.Context, id int64, staleBefore time.Time) (bool, error)
+	// Finish stores the outcome of a job.
+	Finish(ctx context.Context, job *domain.ExportJob) error
+	// Release puts a running job back to pending.
+	Release(ctx context.Context, id int64) error
+	// DeleteExpired deletes and returns the jobs expired at now.
+	DeleteExpired(ctx context.Context, now time.Time) ([]*domain.ExportJob, error)
+}
+
+// ExportConfig controls background exports. Values come from EXPORT_* env
+// vars, see LoadExportConfig.
+type ExportConfig struct {
+	// Dir holds finished export files. With several app instances it must
+	// be shared storage.
+	Dir       string
+	Workers   int
+	QueueSize int
+	// TTL is how long a finished export can be downloaded.
+	TTL time.Duration
+	// JobTimeout bounds one job; a job running longer is presumed dead and
+	// picked up again.
+	JobTimeout time.Duration
+}
+
+func LoadExportConfig() ExportConfig {
+	return ExportConfig{
+		Dir:        envString("EXPORT_DIR", "exports"),
+		Workers:    envInt("EXPORT_WORKERS", 1),
+		QueueSize:  envInt("EXPORT_QUEUE_SIZE", 100),
+		TTL:        envDuration("EXPORT_TTL", 24*time.Hour),
+		JobTimeout: envDuration("EXPORT_JOB_TIMEOUT", time.Hour),
+	}
+}
+
+// ExportService streams click data to the caller or, for large exports,
+// writes it to a file in the background and hands out a download token.
+type ExportService struct {
+	linkRepo   LinkRepository
+	exportRepo ClickExportRepository
+	jobRepo    ExportJobRepository
+	cfg        ExportConfig
+
+	queue  chan *domain.ExportJob
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewExportService(linkRepo LinkRepository, exportRepo ClickExportRepository, jobRepo ExportJobRepository) *ExportService {
+	return NewExportServiceWithConfig(linkRepo, exportRepo, jobRepo, LoadExportConfig())
+}
+
+func NewExportServiceWithConfig(linkRepo LinkRepository, exportRepo ClickExportRepository, jobRepo ExportJobRepository, cfg ExportConfig) *ExportService {
+	if linkRepo == nil {
+		panic("LinkRepository cannot be nil")
+	}
+	if exportRepo == nil {
+		panic("ClickExportRepository cannot be nil")
+	}
+	if jobRepo == nil {
+		panic("ExportJobRepository cannot be nil")
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1
+	}
+	return &ExportService{
+		linkRepo:   linkRepo,
+		exportRepo: exportRepo,
+		jobRepo:    jobRepo,
+		cfg:        cfg,
+		queue:      make(chan *domain.ExportJob, cfg.QueueSize),
+	}
+}
+
+// LinkQuery validates q and scopes it to one of the user's links.
+func (s *ExportService) LinkQuery(ctx context.Context, userID int64, shortCode string, q domain.ExportQuery) (domain.ExportQuery, error) {
+	link, err := s.linkRepo.FindByShortCode(ctx, shortCode)
+	if err != nil {
+		return q, err
+	}
+	if link == nil || link.UserID != userID {
+		return q, ErrLinkNotFound
+	}
+	q.UserID = &userID
+	q.LinkID = &link.ID
+	return normalizeExportQuery(q)
+}
+
+// AccountQuery validates q and scopes it to all of the user's links.
+func (s *ExportService) AccountQuery(userID int64, q domain.ExportQuery) (domain.ExportQuery, error) {
+	q.UserID = &userID
+	q.LinkID = nil
+	return normalizeExportQuery(q)
+}
+
+// AdminQuery validates q, which covers every account unless q.UserID is
+// set.
+func (s *ExportService) AdminQuery(q domain.ExportQuery) (domain.ExportQuery, error) {
+	q.LinkID = nil
+	return normalizeExportQuery(q)
+}
+
+// normalizeExportQuery defaults to a CSV export of click events. Without
+// from or to the export is unbounded on that side.
+func normalizeExportQuery(q domain.ExportQuery) (domain.ExportQuery, error) {
+	if q.Format == "" {
+		q.Format = domain.ExportFormatCSV
+	}
+	if q.Dataset == "" {
+		q.Dataset = domain.ExportDatasetEvents
+	}
+	if !domain.IsValidExportFormat(q.Format) {
+		return q, ErrInvalidExportFormat
+	}
+	if !domain.IsValidExportDataset(q.Dataset) {
+		return q, ErrInvalidExportDataset
+	}
+	if q.From != nil && q.To != nil && !q.From.Before(*q.To) {
+		return q, ErrInvalidExportRange
+	}
+	return q, nil
+}
+
+// Stream writes the rows of q to w in q.Format and returns how many rows
+// it wrote. Rows are encoded as they are read from the database. On error
+// w holds a truncated export.
+func (s *ExportService) Stream(ctx context.Context, q domain.ExportQuery, w io.Writer) (int64, error) {
+	if q.Dataset == domain.ExportDatasetHourly {
+		return writeRows(q.Format, w, func(emit func(export.HourlyRow) error) error {
+			return s.exportRepo.StreamHourlyClicks(ctx, q, func(h *domain.HourlyClicks) error {
+				return emit(export.NewHourlyRow(h))
+			})
+		})
+	}
+	return writeRows(q.Format, w, func(emit func(export.EventRow) error) error {
+		return s.exportRepo.StreamClicks(ctx, q, func(c *domain.ExportedClick) error {
+			return emit(export.NewEventRow(c))
+		})
+	})
+}
+
+func writeRows[T export.Row](format string, w io.Writer, stream func(emit func(T) error) error) (int64, error) {
+	enc, err := export.NewWriter[T](format, w)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	err = stream(func(row T) error {
+		n++
+		return enc.Write(row)
+	})
+	if err != nil {
+		return n, err
+	}
+	return n, enc.Close()
+}
+
+// StartJob records a background export of q and queues it. When the queue
+// is full the job stays pending and the next sweep picks it up.
+func (s *ExportService) StartJob(ctx context.Context, requestedBy int64, q domain.ExportQuery) (*domain.ExportJob, error) {
+	token, err := newExportToken()
+	if err != nil {
+		return nil, err
+	}
+	job := &domain.ExportJob{
+		Token:       token,
+		RequestedBy: requestedBy,
+		Query:       q,
+		Status:      domain.ExportJobPending,
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	select {
+	case s.queue <- job:
+	default:
+	}
+	return job, nil
+}
+
+// GetJob returns one of the user's export jobs.
+func (s *ExportService) GetJob(ctx context.Context, userID int64, token string) (*domain.ExportJob, error) {
+	job, err := s.jobRepo.FindByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil || job.RequestedBy != userID {
+		return nil, ErrExportNotFound
+	}
+	return job, nil
+}
+
+// JobFile returns a finished job of the user with the path of its file.
+func (s *ExportService) JobFile(ctx context.Context, userID int64, token string) (*domain.ExportJob, string, error) {
+	job, err := s.GetJob(ctx, userID, token)
+	if err != nil {
+		return nil, "", err
+	}
+	if job.Status != domain.ExportJobDone {
+		return nil, "", ErrExportNotReady
+	}
+	if job.ExpiresAt != nil && !job.ExpiresAt.After(time.Now()) {
+		return nil, "", ErrExportNotFound
+	}
+	return job, s.jobPath(job), nil
+}
+
+// Start creates the export directory and launches the workers and the
+// sweep that requeues leftover jobs and deletes expired ones.
+func (s *ExportService) Start() error {
+	if s.cancel != nil {
+		return nil
+	}
+	if err := os.MkdirAll(s.cfg.Dir, 0o750); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	for i := 0; i < s.cfg.Workers; i++ {
+		s.wg.Add(1)
+		go s.work(ctx)
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(exportSweepInterval)
+		defer ticker.Stop()
+		for {
+			s.sweep(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop cancels running jobs, which go back to pending, and waits for the
+// workers to exit.
+func (s *ExportService) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *ExportService) work(ctx context.Context) {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-s.queue:
+			s.run(ctx, job)
+		}
+	}
+}
+
+// sweep requeues pending and stale jobs, e.g. after a restart or a full
+// queue, and removes expired jobs with their files.
+func (s *ExportService) sweep(ctx context.Context) {
+	jobs, err := s.jobRepo.ListRunnable(ctx, time.Now().Add(-s.cfg.JobTimeout), s.cfg.QueueSize)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Failed to list export jobs: %v", err)
+		}
+		return
+	}
+	for _, job := range jobs {
+		select {
+		case s.queue <- job:
+		default:
+		}
+	}
+
+	expired, err := s.jobRepo.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Failed to delete expired export jobs: %v", err)
+		}
+		return
+	}
+	for _, job := range expired {
+		if err := os.Remove(s.jobPath(job)); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove export %d: %v", job.ID, err)
+		}
+	}
+}
+
+// run writes one job to a temporary file and renames it into place once
+// complete, so a download never sees a partial export.
+func (s *ExportService) run(ctx context.Context, job *domain.ExportJob) {
+	claimed, err := s.jobRepo.Claim(ctx, job.ID, time.Now().Add(-s.cfg.JobTimeout))
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Failed to claim export %d: %v", job.ID, err)
+		}
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	jobCtx, cancel := context.WithTimeout(ctx, s.cfg.JobTimeout)
+	defer cancel()
+	path := s.jobPath(job)
+	rows, size, err := s.writeFile(jobCtx, job.Query, path)
+
+	// The job outcome is stored even when the service is stopping.
+	saveCtx, cancelSave := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelSave()
+	if err != nil && ctx.Err() != nil {
+		if err := s.jobRepo.Release(saveCtx, job.ID); err != nil {
+			log.Printf("Failed to release export %d: %v", job.ID, err)
+		}
+		return
+	}
+
+	now := time.Now()
+	expires := now.Add(s.cfg.TTL)
+	job.Rows, job.SizeBytes, job.FinishedAt, job.ExpiresAt = rows, size, &now, &expires
+	job.Status = domain.ExportJobDone
+	if err != nil {
+		job.Status = domain.ExportJobFailed
+		job.Error = err.Error()
+		log.Printf("Export %d failed: %v", job.ID, err)
+	}
+	if err := s.jobRepo.Finish(saveCtx, job); err != nil {
+		log.Printf("Failed to store export %d outcome: %v", job.ID, err)
+	}
+}
+
+func (s *ExportService) writeFile(ctx context.Context, q domain.ExportQuery, path string) (int64, int64, error) {
+	tmp := path + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer os.Remove(tmp)
+
+	cw := &countingWriter{w: f}
+	rows, err := s.Stream(ctx, q, cw)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return 0, 0, err
+	}
+	return rows, cw.n, nil
+}
+
+func (s *ExportService) jobPath(job *domain.ExportJob) string {
+	return filepath.Join(s.cfg.Dir, job.Token+"."+job.Query.Format)
+}
+
+// newExportToken returns 128 random bits, hex encoded. Tokens are also
+// file names, so they must stay free of path characters.
+func newExportToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,286 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"url-shortener/internal/domain"
+)
+
+const healthCheckPageSize = 200
+
+// HealthCheckConfig controls the background destination checker.
+// Values come from HEALTH_CHECK_* env vars, see LoadHealthCheckConfig.
+type HealthCheckConfig struct {
+	Enabled      bool
+	Interval     time.Duration
+	Timeout      time.Duration
+	Concurrency  int
+	HostDelay    time.Duration
+	SlowAfter    time.Duration
+	MaxRedirects int
+	UserAgent    string
+}
+
+func LoadHealthCheckConfig() HealthCheckConfig {
+	return HealthCheckConfig{
+		Enabled:      envBool("HEALTH_CHECK_ENABLED", false),
+		Interval:     envDuration("HEALTH_CHECK_INTERVAL", time.Hour),
+		Timeout:      envDuration("HEALTH_CHECK_TIMEOUT", 10*time.Second),
+		Concurrency:  envInt("HEALTH_CHECK_CONCURRENCY", 8),
+		HostDelay:    envDuration("HEALTH_CHECK_HOST_DELAY", time.Second),
+		SlowAfter:    envDuration("HEALTH_CHECK_SLOW_AFTER", 3*time.Second),
+		MaxRedirects: envInt("HEALTH_CHECK_MAX_REDIRECTS", 10),
+		UserAgent:    "url-shortener-healthcheck/1.0",
+	}
+}
+
+// HealthChecker periodically probes every active link's destination and
+// stores the result on the link. Requests to the same host are serialized
+// and spaced by HostDelay so we never hammer a single origin.
+type HealthChecker struct {
+	linkRepo LinkRepository
+	client   *http.Client
+	cfg      HealthCheckConfig
+
+	hostsMu sync.Mutex
+	hosts   map[string]*hostGate
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type hostGate struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+// NewHealthChecker probes destinations with a client that cannot reach
+// private or local addresses, so links cannot be used to scan the
+// internal network.
+func NewHealthChecker(linkRepo LinkRepository) *HealthChecker {
+	return NewHealthCheckerWithClient(linkRepo, NewPublicHTTPClient(), LoadHealthCheckConfig())
+}
+
+// NewHealthCheckerWithClient allows injecting the HTTP client, e.g. one
+// pointed at an httptest server. A nil client has no address checks.
+func NewHealthCheckerWithClient(linkRepo LinkRepository, client *http.Client, cfg HealthCheckConfig) *HealthChecker {
+	if linkRepo == nil {
+		panic("LinkRepository cannot be nil")
+	}
+	if client == nil {
+		client = &http.Client{}
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	if cfg.MaxRedirects <= 0 {
+		cfg.MaxRedirects = 10
+	}
+	return &HealthChecker{
+		linkRepo: linkRepo,
+		client:   client,
+		cfg:      cfg,
+		hosts:    make(map[string]*hostGate),
+	}
+}
+
+// Start launches the periodic check loop. It is a no-op when disabled.
+func (c *HealthChecker) Start() {
+	if !c.cfg.Enabled || c.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.done = make(chan struct{})
+	go func() {
+		defer close(c.done)
+		ticker := time.NewTicker(c.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			if err := c.CheckAll(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("Health check run failed: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop cancels the loop and waits for in-flight checks to finish.
+func (c *HealthChecker) Stop(ctx context.Context) error {
+	if c.cancel == nil {
+		return nil
+	}
+	c.cancel()
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CheckAll probes every active link once, bounded by cfg.Concurrency.
+func (c *HealthChecker) CheckAll(ctx context.Context) error {
+	c.hostsMu.Lock()
+	c.hosts = make(map[string]*hostGate)
+	c.hostsMu.Unlock()
+
+	jobs := make(chan *domain.Link)
+	var wg sync.WaitGroup
+	for i := 0; i < c.cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for link := range jobs {
+				result := c.Check(ctx, link)
+				if err := c.linkRepo.UpdateHealth(ctx, result); err != nil && ctx.Err() == nil {
+					log.Printf("Failed to store health for link %d: %v", link.ID, err)
+				}
+			}
+		}()
+	}
+
+	var err error
+	var afterID int64
+	for {
+		var links []*domain.Link
+		links, err = c.linkRepo.ListActive(ctx, afterID, healthCheckPageSize)
+		if err != nil || len(links) == 0 {
+			break
+		}
+		for _, link := range links {
+			select {
+			case jobs <- link:
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+			if err != nil {
+				break
+			}
+		}
+		if err != nil {
+			break
+		}
+		afterID = links[len(links)-1].ID
+	}
+	close(jobs)
+	wg.Wait()
+	return err
+}
+
+// Check probes a single link's destination and classifies the result.
+func (c *HealthChecker) Check(ctx context.Context, link *domain.Link) *domain.LinkHealthCheck {
+	result := &domain.LinkHealthCheck{LinkID: link.ID, CheckedAt: time.Now()}
+
+	u, err := url.Parse(link.LongURL)
+	if err != nil || u.Host == "" {
+		result.Status = domain.LinkHealthBroken
+		result.Error = "invalid destination URL"
+		return result
+	}
+
+	release := c.acquireHost(ctx, strings.ToLower(u.Host))
+	defer release()
+
+	start := time.Now()
+	resp, chain, err := c.probe(ctx, http.MethodHead, link.LongURL)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		// Some origins refuse HEAD; retry with GET before judging them.
+		resp, chain, err = c.probe(ctx, http.MethodGet, link.LongURL)
+	}
+	result.Latency = time.Since(start)
+	result.RedirectChain = chain
+	if err != nil {
+		result.Status = domain.LinkHealthBroken
+		result.Error = err.Error()
+		return result
+	}
+	result.StatusCode = resp.StatusCode
+	result.Status = c.classify(resp.StatusCode, result.Latency, len(chain))
+	return result
+}
+
+func (c *HealthChecker) probe(ctx context.Context, method, target string) (*http.Response, []string, error) {
+	if c.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.cfg.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("User-Agent", c.cfg.UserAgent)
+
+	var chain []string
+	client := *c.client
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		chain = append(chain, req.URL.String())
+		if len(via) > c.cfg.MaxRedirects {
+			return fmt.Errorf("stopped after %d redirects", c.cfg.MaxRedirects)
+		}
+		return nil
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, chain, err
+	}
+	// Only the status matters; don't download bodies of GET fallbacks.
+	_, _ = io.CopyN(io.Discard, resp.Body, 4096)
+	resp.Body.Close()
+	return resp, chain, nil
+}
+
+func (c *HealthChecker) classify(status int, latency time.Duration, redirects int) string {
+	switch {
+	case status >= 200 && status < 400:
+		if (c.cfg.SlowAfter > 0 && latency > c.cfg.SlowAfter) || redirects > 3 {
+			return domain.LinkHealthDegraded
+		}
+		return domain.LinkHealthHealthy
+	case status == http.StatusUnauthorized, status == http.StatusForbidden, status == http.StatusTooManyRequests:
+		// The destination exists but refuses automated clients.
+		return domain.LinkHealthDegraded
+	case status >= 500:
+		return domain.LinkHealthDegraded
+	default:
+		return domain.LinkHealthBroken
+	}
+}
+
+// acquireHost blocks until the host is free and HostDelay has passed since
+// the previous request to it.
+func (c *HealthChecker) acquireHost(ctx context.Context, host string) func() {
+	c.hostsMu.Lock()
+	gate, ok := c.hosts[host]
+	if !ok {
+		gate = &hostGate{}
+		c.hosts[host] = gate
+	}
+	c.hostsMu.Unlock()
+
+	gate.mu.Lock()
+	if wait := time.Until(gate.last.Add(c.cfg.HostDelay)); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+		}
+	}
+	return func() {
+		gate.last = time.Now()
+		gate.mu.Unlock()
+	}
+}
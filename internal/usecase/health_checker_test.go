@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"url-shortener/internal/domain"
+)
+
+func newTestHealthChecker(client *http.Client) *HealthChecker {
+	return NewHealthCheckerWithClient(&fakeLinkRepo{}, client, HealthCheckConfig{
+		Timeout:      200 * time.Millisecond,
+		Concurrency:  1,
+		SlowAfter:    time.Second,
+		MaxRedirects: 5,
+		UserAgent:    "test",
+	})
+}
+
+func TestHealthCheckerCheck(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) { http.NotFound(w, r) })
+	mux.HandleFunc("/error", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusInternalServerError) })
+	mux.HandleFunc("/forbidden", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusForbidden) })
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/loop", http.StatusFound) })
+	mux.HandleFunc("/hop", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/ok", http.StatusMovedPermanently) })
+	mux.HandleFunc("/nohead", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tests := []struct {
+		path       string
+		status     string
+		statusCode int
+		wantError  bool
+		redirects  int
+	}{
+		{path: "/ok", status: domain.LinkHealthHealthy, statusCode: 200},
+		{path: "/hop", status: domain.LinkHealthHealthy, statusCode: 200, redirects: 1},
+		{path: "/nohead", status: domain.LinkHealthHealthy, statusCode: 200},
+		{path: "/missing", status: domain.LinkHealthBroken, statusCode: 404},
+		{path: "/error", status: domain.LinkHealthDegraded, statusCode: 500},
+		{path: "/forbidden", status: domain.LinkHealthDegraded, statusCode: 403},
+		{path: "/slow", status: domain.LinkHealthBroken, wantError: true},
+		{path: "/loop", status: domain.LinkHealthBroken, wantError: true, redirects: 6},
+	}
+	checker := newTestHealthChecker(srv.Client())
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result := checker.Check(context.Background(), &domain.Link{ID: 1, LongURL: srv.URL + tt.path})
+			if result.Status != tt.status {
+				t.Errorf("status = %q, want %q", result.Status, tt.status)
+			}
+			if result.StatusCode != tt.statusCode {
+				t.Errorf("status code = %d, want %d", result.StatusCode, tt.statusCode)
+			}
+			if (result.Error != "") != tt.wantError {
+				t.Errorf("error = %q, want error: %v", result.Error, tt.wantError)
+			}
+			if len(result.RedirectChain) != tt.redirects {
+				t.Errorf("redirect chain = %v, want %d hops", result.RedirectChain, tt.redirects)
+			}
+		})
+	}
+}
+
+func TestHealthCheckerRejectsPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	checker := newTestHealthChecker(NewPublicHTTPClient())
+	result := checker.Check(context.Background(), &domain.Link{ID: 1, LongURL: srv.URL})
+	if result.Status != domain.LinkHealthBroken || !strings.Contains(result.Error, ErrPrivateAddress.Error()) {
+		t.Fatalf("got status %q, error %q; want broken by %v", result.Status, result.Error, ErrPrivateAddress)
+	}
+}
+
+func TestHealthCheckerRejectsRedirectToPrivateAddress(t *testing.T) {
+	// The public client must also refuse a redirect into the local network;
+	// the first hop is served through a client without checks to simulate
+	// a public origin.
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer internal.Close()
+	public := NewPublicHTTPClient()
+	base := public.Transport.(*http.Transport)
+	public.Transport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host == "public.example" {
+			return &http.Response{
+				StatusCode: http.StatusFound,
+				Header:     http.Header{"Location": []string{internal.URL}},
+				Body:       http.NoBody,
+				Request:    req,
+			}, nil
+		}
+		return base.RoundTrip(req)
+	})
+
+	checker := newTestHealthChecker(public)
+	result := checker.Check(context.Background(), &domain.Link{ID: 1, LongURL: "http://public.example/"})
+	if result.Status != domain.LinkHealthBroken || !strings.Contains(result.Error, ErrPrivateAddress.Error()) {
+		t.Fatalf("got status %q, error %q; want broken by %v", result.Status, result.Error, ErrPrivateAddress)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// fakeLinkRepo satisfies LinkRepository for tests that do not touch it.
+type fakeLinkRepo struct {
+	LinkRepository
+	links map[string]*domain.Link
+}
+
+func (r *fakeLinkRepo) FindByShortCode(ctx context.Context, shortCode string) (*domain.Link, error) {
+	return r.links[shortCode], nil
+}
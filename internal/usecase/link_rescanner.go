@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+	"url-shortener/internal/domain"
+)
+
+const rescanPageSize = 500
+
+// LinkRescanner re-screens existing links so destinations that turn
+// malicious after creation get disabled. Links whose destination is clean
+// again (e.g. an admin removed a blocklist entry) are re-enabled.
+type LinkRescanner struct {
+	linkRepo LinkRepository
+	screener URLScreener
+	audit    *AuditService
+	enabled  bool
+	interval time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewLinkRescanner(linkRepo LinkRepository, screener URLScreener, audit *AuditService) *LinkRescanner {
+	if linkRepo == nil {
+		panic("LinkRepository cannot be nil")
+	}
+	if audit == nil {
+		panic("AuditService cannot be nil")
+	}
+	return &LinkRescanner{
+		linkRepo: linkRepo,
+		screener: screener,
+		audit:    audit,
+		enabled:  envBool("SCREEN_RESCAN_ENABLED", true),
+		interval: envDuration("SCREEN_RESCAN_INTERVAL", 6*time.Hour),
+	}
+}
+
+// Start launches the periodic rescan loop. It is a no-op when disabled.
+func (r *LinkRescanner) Start() {
+	if !r.enabled || r.screener == nil || r.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if err := r.RescanAll(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("Link rescan failed: %v", err)
+			}
+		}
+	}()
+}
+
+func (r *LinkRescanner) Stop(ctx context.Context) error {
+	if r.cancel == nil {
+		return nil
+	}
+	r.cancel()
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RescanAll screens every active link once and updates its flag.
+func (r *LinkRescanner) RescanAll(ctx context.Context) error {
+	var afterID int64
+	for {
+		links, err := r.linkRepo.ListActive(ctx, afterID, rescanPageSize)
+		if err != nil {
+			return err
+		}
+		if len(links) == 0 {
+			return nil
+		}
+		for _, link := range links {
+			v, err := r.screener.Screen(ctx, link.LongURL)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				log.Printf("Failed to screen link %d: %v", link.ID, err)
+				continue
+			}
+			switch {
+			case v.Flagged && link.FlaggedAt == nil:
+				reason := v.Reason
+				if err := r.linkRepo.SetFlag(ctx, link.ID, &reason); err != nil {
+					return err
+				}
+				r.audit.Record(ctx, domain.AuditLinkFlag, domain.AuditTargetLink, strconv.FormatInt(link.ID, 10), nil, map[string]any{"flag_reason": reason})
+				log.Printf("Disabled link %s: %s", link.ShortCode, reason)
+			case !v.Flagged && link.FlaggedAt != nil:
+				if err := r.linkRepo.SetFlag(ctx, link.ID, nil); err != nil {
+					return err
+				}
+				r.audit.Record(ctx, domain.AuditLinkUnflag, domain.AuditTargetLink, strconv.FormatInt(link.ID, 10), map[string]any{"flag_reason": link.FlagReason}, nil)
+				log.Printf("Re-enabled link %s", link.ShortCode)
+			}
+		}
+		afterID = links[len(links)-1].ID
+	}
+}
@@ -0,0 +1,243 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+	"url-shortener/internal/domain"
+)
+
+var ErrTooManyLiveStreams = errors.New("too many live streams open for this account")
+
+// LiveClickBridge carries live clicks between app instances.
+type LiveClickBridge interface {
+	// Publish sends clicks to every instance, this one included.
+	Publish(ctx context.Context, clicks []domain.LiveClick) error
+	// Listen calls fn for every click published by any instance until ctx
+	// is done.
+	Listen(ctx context.Context, fn func(domain.LiveClick)) error
+}
+
+// LiveClickConfig is loaded from LIVE_* env vars, see LoadLiveClickConfig.
+type LiveClickConfig struct {
+	// SubscriberBuffer is how many clicks a slow stream may lag behind
+	// before further clicks are dropped for it.
+	SubscriberBuffer  int
+	MaxStreamsPerUser int
+	Heartbeat         time.Duration
+}
+
+func LoadLiveClickConfig() LiveClickConfig {
+	return LiveClickConfig{
+		SubscriberBuffer:  envInt("LIVE_SUBSCRIBER_BUFFER", 64),
+		MaxStreamsPerUser: envInt("LIVE_MAX_STREAMS_PER_USER", 5),
+		Heartbeat:         envDuration("LIVE_HEARTBEAT_INTERVAL", 15*time.Second),
+	}
+}
+
+// LiveClickHub is the in-process pub/sub behind live click streams. The
+// click recorder publishes every batch it stores; with a bridge the batch
+// goes through it so streams on every instance see it, otherwise it is
+// delivered locally.
+type LiveClickHub struct {
+	linkRepo LinkRepository
+	bridge   LiveClickBridge
+	cfg      LiveClickConfig
+
+	mu     sync.RWMutex
+	subs   map[int64]map[*LiveSubscription]struct{}
+	closed bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// LiveSubscription receives the clicks of one account, optionally of a
+// single link.
+type LiveSubscription struct {
+	hub         *LiveClickHub
+	userID      int64
+	linkID      *int64
+	includeBots bool
+	ch          chan domain.LiveClick
+}
+
+func NewLiveClickHub(linkRepo LinkRepository, bridge LiveClickBridge) *LiveClickHub {
+	return NewLiveClickHubWithConfig(linkRepo, bridge, LoadLiveClickConfig())
+}
+
+// NewLiveClickHubWithConfig builds a hub; a nil bridge keeps clicks within
+// this instance.
+func NewLiveClickHubWithConfig(linkRepo LinkRepository, bridge LiveClickBridge, cfg LiveClickConfig) *LiveClickHub {
+	if linkRepo == nil {
+		panic("LinkRepository cannot be nil")
+	}
+	if cfg.SubscriberBuffer <= 0 {
+		cfg.SubscriberBuffer = 1
+	}
+	if cfg.Heartbeat <= 0 {
+		cfg.Heartbeat = 15 * time.Second
+	}
+	return &LiveClickHub{
+		linkRepo: linkRepo,
+		bridge:   bridge,
+		cfg:      cfg,
+		subs:     map[int64]map[*LiveSubscription]struct{}{},
+	}
+}
+
+// Heartbeat is how often idle streams should send a keep-alive.
+func (h *LiveClickHub) Heartbeat() time.Duration {
+	return h.cfg.Heartbeat
+}
+
+// SubscribeLink streams the clicks of one of the user's links.
+func (h *LiveClickHub) SubscribeLink(ctx context.Context, userID int64, shortCode string, includeBots bool) (*LiveSubscription, error) {
+	link, err := h.linkRepo.FindByShortCode(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil || link.UserID != userID {
+		return nil, ErrLinkNotFound
+	}
+	return h.subscribe(userID, &link.ID, includeBots)
+}
+
+// SubscribeAccount streams the clicks of all of the user's links.
+func (h *LiveClickHub) SubscribeAccount(userID int64, includeBots bool) (*LiveSubscription, error) {
+	return h.subscribe(userID, nil, includeBots)
+}
+
+func (h *LiveClickHub) subscribe(userID int64, linkID *int64, includeBots bool) (*LiveSubscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, errors.New("live click hub is stopped")
+	}
+	if h.cfg.MaxStreamsPerUser > 0 && len(h.subs[userID]) >= h.cfg.MaxStreamsPerUser {
+		return nil, ErrTooManyLiveStreams
+	}
+	sub := &LiveSubscription{
+		hub:         h,
+		userID:      userID,
+		linkID:      linkID,
+		includeBots: includeBots,
+		ch:          make(chan domain.LiveClick, h.cfg.SubscriberBuffer),
+	}
+	if h.subs[userID] == nil {
+		h.subs[userID] = map[*LiveSubscription]struct{}{}
+	}
+	h.subs[userID][sub] = struct{}{}
+	return sub, nil
+}
+
+// Clicks is closed when the subscription is closed or the hub stops.
+func (s *LiveSubscription) Clicks() <-chan domain.LiveClick {
+	return s.ch
+}
+
+func (s *LiveSubscription) Close() {
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[s.userID][s]; !ok {
+		return
+	}
+	delete(h.subs[s.userID], s)
+	if len(h.subs[s.userID]) == 0 {
+		delete(h.subs, s.userID)
+	}
+	close(s.ch)
+}
+
+func (s *LiveSubscription) matches(c domain.LiveClick) bool {
+	if s.linkID != nil && *s.linkID != c.LinkID {
+		return false
+	}
+	return s.includeBots || !c.IsBot
+}
+
+// Publish fans stored click events out to the live streams. Failures are
+// logged; live streams are best effort and never hold up ingestion.
+func (h *LiveClickHub) Publish(ctx context.Context, events []*domain.ClickEvent) {
+	clicks := make([]domain.LiveClick, 0, len(events))
+	for _, e := range events {
+		clicks = append(clicks, e.LiveClick())
+	}
+	if h.bridge == nil {
+		for _, c := range clicks {
+			h.deliver(c)
+		}
+		return
+	}
+	if err := h.bridge.Publish(ctx, clicks); err != nil {
+		log.Printf("Failed to publish %d live clicks: %v", len(clicks), err)
+	}
+}
+
+// deliver hands c to the matching subscriptions without waiting; a stream
+// whose buffer is full misses it.
+func (h *LiveClickHub) deliver(c domain.LiveClick) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.subs[c.UserID] {
+		if !sub.matches(c) {
+			continue
+		}
+		select {
+		case sub.ch <- c:
+		default:
+		}
+	}
+}
+
+// Start listens on the bridge, if any, and reconnects when it fails.
+func (h *LiveClickHub) Start() {
+	if h.bridge == nil || h.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+	h.done = make(chan struct{})
+	go func() {
+		defer close(h.done)
+		for {
+			err := h.bridge.Listen(ctx, h.deliver)
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Live click bridge stopped, retrying: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+		}
+	}()
+}
+
+// Stop ends every open stream and stops listening on the bridge.
+func (h *LiveClickHub) Stop(ctx context.Context) error {
+	h.mu.Lock()
+	h.closed = true
+	for _, subs := range h.subs {
+		for sub := range subs {
+			close(sub.ch)
+		}
+	}
+	clear(h.subs)
+	h.mu.Unlock()
+
+	if h.cancel == nil {
+		return nil
+	}
+	h.cancel()
+	select {
+	case <-h.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,242 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"url-shortener/internal/domain"
+)
+
+const (
+	SelfReferenceResolve = "resolve"
+	SelfReferenceReject  = "reject"
+)
+
+var (
+	ErrSelfReference   = &URLValidationError{Code: "url_self_reference", Message: "destination points to one of our own short links"}
+	ErrRedirectLoop    = &URLValidationError{Code: "url_redirect_loop", Message: "destination redirects in a loop"}
+	ErrRedirectTooDeep = &URLValidationError{Code: "url_redirect_too_deep", Message: "destination chains through too many short links"}
+)
+
+var (
+	defaultShorteners   = []string{"bit.ly", "t.co", "tinyurl.com", "goo.gl", "ow.ly", "is.gd", "buff.ly", "rebrand.ly", "cutt.ly", "shorturl.at", "rb.gy", "tiny.cc"}
+	redirectStatusCodes = []int{http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect}
+)
+
+// RedirectGuardConfig comes from SHORT_DOMAINS, KNOWN_SHORTENERS and the
+// REDIRECT_* / SELF_REFERENCE_POLICY env vars.
+type RedirectGuardConfig struct {
+	ShortDomains     []string
+	KnownShorteners  []string
+	Policy           string
+	MaxDepth         int
+	FollowShorteners bool
+	FollowTimeout    time.Duration
+}
+
+func LoadRedirectGuardConfig() RedirectGuardConfig {
+	shorteners := envList("KNOWN_SHORTENERS")
+	if len(shorteners) == 0 {
+		shorteners = defaultShorteners
+	}
+	policy := strings.ToLower(envString("SELF_REFERENCE_POLICY", SelfReferenceResolve))
+	if policy != SelfReferenceReject {
+		policy = SelfReferenceResolve
+	}
+	return RedirectGuardConfig{
+		ShortDomains:     lowerAll(envList("SHORT_DOMAINS")),
+		KnownShorteners:  lowerAll(shorteners),
+		Policy:           policy,
+		MaxDepth:         envInt("REDIRECT_MAX_DEPTH", 5),
+		FollowShorteners: envBool("REDIRECT_FOLLOW_SHORTENERS", true),
+		FollowTimeout:    envDuration("REDIRECT_FOLLOW_TIMEOUT", 3*time.Second),
+	}
+}
+
+// RedirectGuard detects destinations that point back at our own short
+// domains or loop through other URL shorteners.
+type RedirectGuard struct {
+	linkRepo LinkRepository
+	cfg      RedirectGuardConfig
+	client   *http.Client
+}
+
+// NewRedirectGuard follows shorteners with a client that refuses to dial
+// private or local addresses, like the health checker's.
+func NewRedirectGuard(linkRepo LinkRepository) *RedirectGuard {
+	return NewRedirectGuardWithClient(linkRepo, NewPublicHTTPClient(), LoadRedirectGuardConfig())
+}
+
+func NewRedirectGuardWithClient(linkRepo LinkRepository, client *http.Client, cfg RedirectGuardConfig) *RedirectGuard {
+	if linkRepo == nil {
+		panic("LinkRepository cannot be nil")
+	}
+	if client == nil {
+		panic("http.Client cannot be nil")
+	}
+	// We only want to see the first hop of every shortener.
+	c := *client
+	c.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	if cfg.MaxDepth <= 0 {
+		cfg.MaxDepth = 1
+	}
+	return &RedirectGuard{linkRepo: linkRepo, cfg: cfg, client: &c}
+}
+
+// Check follows longURL through our own short links and known shorteners.
+// It returns the URL to store: unchanged unless the chain passes through
+// one of our own links under the "resolve" policy, in which case that
+// link's target is used instead. shortCode is the link being edited ("" on
+// create); reaching it again means the edit would close a loop.
+func (g *RedirectGuard) Check(ctx context.Context, shortCode, longURL string) (string, error) {
+	result := longURL
+	current := longURL
+	visited := map[string]bool{}
+	for depth := 0; ; depth++ {
+		if visited[current] {
+			return "", ErrRedirectLoop
+		}
+		visited[current] = true
+
+		u, err := url.Parse(current)
+		if err != nil {
+			return result, nil
+		}
+
+		var next string
+		switch {
+		case g.isOwnHost(u):
+			if g.cfg.Policy == SelfReferenceReject {
+				return "", ErrSelfReference
+			}
+			if shortCode != "" && ownShortCode(u) == shortCode {
+				return "", ErrRedirectLoop
+			}
+			link, err := g.ownLink(ctx, u)
+			if err != nil {
+				return "", err
+			}
+			// The owner of this link may not own the flagged one, so its
+			// flag reason is not passed on.
+			if link.FlaggedAt != nil {
+				return "", fmt.Errorf("%w: it points to a disabled short link", ErrURLFlagged)
+			}
+			next = link.LongURL
+			result = next
+		case g.cfg.FollowShorteners && g.isKnownShortener(u):
+			next = g.peek(ctx, u)
+			if next == "" {
+				return result, nil
+			}
+		default:
+			return result, nil
+		}
+
+		if depth+1 >= g.cfg.MaxDepth {
+			return "", ErrRedirectTooDeep
+		}
+		current = next
+	}
+}
+
+// Follow resolves a stored destination through our own links without any
+// network access. It is used on the redirect path to stop runtime loops,
+// and returns a *FlaggedLinkError if any link on the way is flagged, so a
+// flagged destination cannot be reached by wrapping it in a clean link.
+func (g *RedirectGuard) Follow(ctx context.Context, longURL string) (string, error) {
+	current := longURL
+	visited := map[string]bool{}
+	for depth := 0; ; depth++ {
+		u, err := url.Parse(current)
+		if err != nil || !g.isOwnHost(u) {
+			return current, nil
+		}
+		if visited[current] {
+			return "", ErrRedirectLoop
+		}
+		visited[current] = true
+		if depth >= g.cfg.MaxDepth {
+			return "", ErrRedirectTooDeep
+		}
+		link, err := g.ownLink(ctx, u)
+		if err != nil {
+			return "", err
+		}
+		if link.FlaggedAt != nil {
+			return "", &FlaggedLinkError{LongURL: link.LongURL, Reason: link.FlagReason}
+		}
+		current = link.LongURL
+	}
+}
+
+func (g *RedirectGuard) ownLink(ctx context.Context, u *url.URL) (*domain.Link, error) {
+	code := ownShortCode(u)
+	if code == "" {
+		return nil, ErrSelfReference
+	}
+	link, err := g.linkRepo.FindByShortCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return nil, ErrSelfReference
+	}
+	return link, nil
+}
+
+// peek returns the Location a shortener redirects to, or "" if it does not
+// redirect or cannot be reached. Failures never block link creation.
+func (g *RedirectGuard) peek(ctx context.Context, u *url.URL) string {
+	if g.cfg.FollowTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.cfg.FollowTimeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u.String(), nil)
+	if err != nil {
+		return ""
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return ""
+	}
+	resp.Body.Close()
+	if !slices.Contains(redirectStatusCodes, resp.StatusCode) {
+		return ""
+	}
+	loc, err := resp.Location()
+	if err != nil {
+		return ""
+	}
+	return loc.String()
+}
+
+func (g *RedirectGuard) isOwnHost(u *url.URL) bool {
+	host := strings.ToLower(u.Host)
+	return slices.Contains(g.cfg.ShortDomains, host) || slices.Contains(g.cfg.ShortDomains, strings.ToLower(u.Hostname()))
+}
+
+func (g *RedirectGuard) isKnownShortener(u *url.URL) bool {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	return slices.Contains(g.cfg.KnownShorteners, host)
+}
+
+func ownShortCode(u *url.URL) string {
+	code := strings.Trim(u.Path, "/")
+	if strings.Contains(code, "/") {
+		return ""
+	}
+	return code
+}
+
+func lowerAll(values []string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = strings.ToLower(v)
+	}
+	return out
+}
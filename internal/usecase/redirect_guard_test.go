@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"url-shortener/internal/domain"
+)
+
+func TestRedirectGuardFlaggedHop(t *testing.T) {
+	flaggedAt := time.Now()
+	repo := &fakeLinkRepo{links: map[string]*domain.Link{
+		"clean":   {ShortCode: "clean", LongURL: "https://sho.rt/flagged"},
+		"flagged": {ShortCode: "flagged", LongURL: "https://evil.example/", FlaggedAt: &flaggedAt, FlagReason: "malware"},
+		"safe":    {ShortCode: "safe", LongURL: "https://example.com/"},
+	}}
+	guard := NewRedirectGuardWithClient(repo, NewPublicHTTPClient(), RedirectGuardConfig{ShortDomains: []string{"sho.rt"}, Policy: SelfReferenceResolve, MaxDepth: 5})
+
+	target, err := guard.Follow(context.Background(), "https://sho.rt/safe")
+	if err != nil || target != "https://example.com/" {
+		t.Fatalf("Follow(safe) = %q, %v", target, err)
+	}
+
+	_, err = guard.Follow(context.Background(), "https://sho.rt/clean")
+	var flagged *FlaggedLinkError
+	if !errors.As(err, &flagged) || flagged.LongURL != "https://evil.example/" {
+		t.Fatalf("Follow(clean -> flagged) error = %v, want *FlaggedLinkError", err)
+	}
+
+	if _, err := guard.Check(context.Background(), "", "https://sho.rt/clean"); !errors.Is(err, ErrURLFlagged) {
+		t.Fatalf("Check(clean -> flagged) error = %v, want ErrURLFlagged", err)
+	}
+}
@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"url-shortener/internal/domain"
+)
+
+const maxCounterDriftRows = 1000
+
+var (
+	ErrInvalidRollupRange  = errors.New("from must be before to")
+	ErrRollupDayIncomplete = errors.New("only complete UTC days can be rebuilt; to must not be after the start of today")
+)
+
+// ErrRollupBeforeRetention is returned when a rebuild would reach back to
+// days whose raw events may already be gone.
+type ErrRollupBeforeRetention struct {
+	Floor time.Time
+}
+
+func (e ErrRollupBeforeRetention) Error() string {
+	return fmt.Sprintf("raw events before %s may have been deleted by retention; rebuilding would lose clicks", e.Floor.Format(time.DateOnly))
+}
+
+// RollupService repairs the click rollups from the raw events. It backs
+// the rollup command and is not exposed over HTTP.
+type RollupService struct {
+	linkRepo  LinkRepository
+	repo      ClickMaintenanceRepository
+	retention ClickRetentionConfig
+}
+
+func NewRollupService(linkRepo LinkRepository, repo ClickMaintenanceRepository) *RollupService {
+	return NewRollupServiceWithConfig(linkRepo, repo, LoadClickRetentionConfig())
+}
+
+func NewRollupServiceWithConfig(linkRepo LinkRepository, repo ClickMaintenanceRepository, retention ClickRetentionConfig) *RollupService {
+	if linkRepo == nil {
+		panic("LinkRepository cannot be nil")
+	}
+	if repo == nil {
+		panic("ClickMaintenanceRepository cannot be nil")
+	}
+	return &RollupService{linkRepo: linkRepo, repo: repo, retention: retention}
+}
+
+// Backfill builds the rollups of links that have events but no rollups on
+// a day, e.g. after events were imported. Days that already have rollups
+// are left alone, so it is safe to run over any range.
+func (s *RollupService) Backfill(ctx context.Context, from, to time.Time, shortCode string, progress func(*domain.RollupRepair)) error {
+	return s.rebuild(ctx, from, to, shortCode, true, progress)
+}
+
+// Rerollup replaces the rollups of every day in the range with ones
+// recomputed from the raw events and moves the link counters by the
+// difference. The range must not reach back past the retention floor.
+func (s *RollupService) Rerollup(ctx context.Context, from, to time.Time, shortCode string, progress func(*domain.RollupRepair)) error {
+	if floor := s.retention.Floor(time.Now().UTC()); from.Before(floor) {
+		return ErrRollupBeforeRetention{Floor: floor}
+	}
+	return s.rebuild(ctx, from, to, shortCode, false, progress)
+}
+
+// rebuild walks the UTC days touched by [from, to), one transaction per
+// day.
+func (s *RollupService) rebuild(ctx context.Context, from, to time.Time, shortCode string, onlyMissing bool, progress func(*domain.RollupRepair)) error {
+	start := truncateToInterval(from, domain.StatsIntervalDay, time.UTC)
+	if !start.Before(to) {
+		return ErrInvalidRollupRange
+	}
+	if to.After(truncateToInterval(time.Now(), domain.StatsIntervalDay, time.UTC)) {
+		return ErrRollupDayIncomplete
+	}
+	linkID, err := s.linkID(ctx, shortCode)
+	if err != nil {
+		return err
+	}
+	for day := start; day.Before(to); day = day.AddDate(0, 0, 1) {
+		repair, err := s.repo.RebuildRollups(ctx, day, linkID, onlyMissing)
+		if err != nil {
+			return fmt.Errorf("rebuild %s: %w", day.Format(time.DateOnly), err)
+		}
+		if progress != nil {
+			progress(repair)
+		}
+	}
+	return nil
+}
+
+// CounterDrift lists links whose counters differ from their rollups,
+// optionally only the link with shortCode.
+func (s *RollupService) CounterDrift(ctx context.Context, shortCode string) ([]domain.CounterDrift, error) {
+	linkID, err := s.linkID(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.CounterDrift(ctx, linkID, maxCounterDriftRows)
+}
+
+// ResetCounters sets the counters of the drifted links to their rollup
+// sums.
+func (s *RollupService) ResetCounters(ctx context.Context, drift []domain.CounterDrift) (int64, error) {
+	ids := make([]int64, 0, len(drift))
+	for _, d := range drift {
+		ids = append(ids, d.LinkID)
+	}
+	return s.repo.ResetCounters(ctx, ids)
+}
+
+func (s *RollupService) linkID(ctx context.Context, shortCode string) (*int64, error) {
+	if shortCode == "" {
+		return nil, nil
+	}
+	link, err := s.linkRepo.FindByShortCode(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return nil, ErrLinkNotFound
+	}
+	return &link.ID, nil
+}
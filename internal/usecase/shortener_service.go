@@ -6,8 +6,12 @@ import (
 	"math/rand"
 	"os"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 	domain "url-shortener/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
@@ -36,53 +40,150 @@ var FreePlanMaxLinks = func() int {
 	return 10
 }()
 
+// LinkLimit returns how many links the user may own, and false when the
+// user has no limit. Admins and paid plans are unlimited.
+func LinkLimit(user *domain.User) (int, bool) {
+	if user == nil || user.Role == "admin" || user.Plan != FreePlan {
+		return 0, false
+	}
+	return FreePlanMaxLinks, true
+}
+
 type LinkRepository interface {
 	Create(ctx context.Context, link *domain.Link) error
 	FindByShortCode(ctx context.Context, shortCode string) (*domain.Link, error)
-	ListByUser(ctx context.Context, userID int64) ([]*domain.Link, error)
+	ListByUser(ctx context.Context, userID int64, filter domain.LinkFilter) ([]*domain.Link, error)
+	ListActive(ctx context.Context, afterID int64, limit int) ([]*domain.Link, error)
+	UpdateHealth(ctx context.Context, check *domain.LinkHealthCheck) error
+	UpdateLongURL(ctx context.Context, userID int64, shortCode, longURL string) (*domain.Link, error)
+	SetFlag(ctx context.Context, linkID int64, reason *string) error
 	SoftDeleteByShortCode(ctx context.Context, userID int64, shortCode string) error
-	TrackClick(ctx context.Context, shortCode string) error
+	TrackClicks(ctx context.Context, events []*domain.ClickEvent) error
 
 	FindLinkCountByUserIDAndLongURL(ctx context.Context, userID int64, longURL string) (int, error)
 	FindLinkCountByUserID(ctx context.Context, userID int64) (int, error)
 }
 
 type UserRepository interface {
-	FindByAPIKey(ctx context.Context, apiKey string) (*domain.User, error)
+	// FindByAPIKeyHash returns the owner of the active (not revoked or
+	// expired) key with the given HashAPIKey digest.
+	FindByAPIKeyHash(ctx context.Context, keyHash string) (*domain.User, error)
 	Create(ctx context.Context, user *domain.User) error
+	// FindByID returns nil if there is no such user.
 	FindByID(ctx context.Context, id int64) (*domain.User, error)
 	SoftDeleteByID(ctx context.Context, userID int64) error
 	UpdatePlanAndExpiry(ctx context.Context, userID int64, plan string, expiresAt *time.Time) error
-	CreateAPIKey(ctx context.Context, userID int64, key string) error
+	// CreateAPIKey stores key unless limit is positive and the user
+	// already has that many active keys, in which case it returns false.
+	CreateAPIKey(ctx context.Context, key *domain.ApiKey, limit int) (bool, error)
+	// ListAPIKeys returns the user's keys that are not revoked or expired,
+	// oldest first.
+	ListAPIKeys(ctx context.Context, userID int64) ([]*domain.ApiKey, error)
+	// FindAPIKey returns nil if the user has no such active key.
+	FindAPIKey(ctx context.Context, userID, keyID int64) (*domain.ApiKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID int64) (bool, error)
+	// RotateAPIKey makes the active key expire by graceUntil, stores next
+	// and records it as the replacement in one transaction. It returns
+	// false if there is no such key, it was already replaced, or limit is
+	// positive and the user has that many other active keys.
+	RotateAPIKey(ctx context.Context, userID, keyID int64, next *domain.ApiKey, graceUntil time.Time, limit int) (bool, error)
+	RecordAPIKeyUsage(ctx context.Context, usage []domain.APIKeyUsage) error
 }
 
 type ShortenerService struct {
-	linkRepo LinkRepository
-	userRepo UserRepository
+	linkRepo     LinkRepository
+	userRepo     UserRepository
+	campaignRepo CampaignRepository
+	screener     URLScreener
+	validator    *URLValidator
+	guard        *RedirectGuard
+	clicks       *ClickEventBuilder
+	recorder     *ClickRecorder
+	audit        *AuditService
+
+	collisions atomic.Uint64
 }
 
-func NewShortenerService(linkRepo LinkRepository, userRepo UserRepository) *ShortenerService {
+func NewShortenerService(
+	linkRepo LinkRepository,
+	userRepo UserRepository,
+	campaignRepo CampaignRepository,
+	screener URLScreener,
+	validator *URLValidator,
+	guard *RedirectGuard,
+	clicks *ClickEventBuilder,
+	recorder *ClickRecorder,
+	audit *AuditService,
+) *ShortenerService {
 	if linkRepo == nil {
 		panic("LinkRepository cannot be nil")
 	}
 	if userRepo == nil {
 		panic("UserRepository cannot be nil")
 	}
-	return &ShortenerService{linkRepo: linkRepo, userRepo: userRepo}
+	if campaignRepo == nil {
+		panic("CampaignRepository cannot be nil")
+	}
+	if validator == nil {
+		panic("URLValidator cannot be nil")
+	}
+	if guard == nil {
+		panic("RedirectGuard cannot be nil")
+	}
+	if clicks == nil {
+		panic("ClickEventBuilder cannot be nil")
+	}
+	if recorder == nil {
+		panic("ClickRecorder cannot be nil")
+	}
+	if audit == nil {
+		panic("AuditService cannot be nil")
+	}
+	return &ShortenerService{
+		linkRepo:     linkRepo,
+		userRepo:     userRepo,
+		campaignRepo: campaignRepo,
+		screener:     screener,
+		validator:    validator,
+		guard:        guard,
+		clicks:       clicks,
+		recorder:     recorder,
+		audit:        audit,
+	}
 }
 
-func (s *ShortenerService) CreateShortLink(ctx context.Context, userID int64, longURL string) (*domain.Link, error) {
+// CreateShortLink creates a link, optionally inside one of the user's
+// campaigns.
+func (s *ShortenerService) CreateShortLink(ctx context.Context, userID int64, longURL string, campaignID *int64) (_ *domain.Link, err error) {
+	ctx, span := startSpan(ctx, "ShortenerService.CreateShortLink", attribute.Int64("user.id", userID))
+	defer func() { endSpan(span, err) }()
+
+	longURL, err = s.prepareDestination(ctx, "", longURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if campaignID != nil {
+		campaign, err := s.campaignRepo.FindByID(ctx, userID, *campaignID)
+		if err != nil {
+			return nil, err
+		}
+		if campaign == nil {
+			return nil, ErrCampaignNotFound
+		}
+	}
+
 	// Enforce plan limits
 	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
-	if user != nil && user.Role != "admin" && user.Plan == FreePlan {
+	if limit, ok := LinkLimit(user); ok {
 		cnt, err := s.linkRepo.FindLinkCountByUserID(ctx, userID)
 		if err != nil {
 			return nil, err
 		}
-		if cnt >= FreePlanMaxLinks {
+		if cnt >= limit {
 			return nil, ErrLinkLimitExceeded
 		}
 	}
@@ -107,20 +208,39 @@ func (s *ShortenerService) CreateShortLink(ctx context.Context, userID int64, lo
 			shortCode = tmpCode
 			break
 		}
+		s.collisions.Add(1)
+	}
+	if shortCode == "" {
+		return nil, ErrMaxRetriesExceeded
 	}
 
 	link := &domain.Link{
-		UserID:    userID,
-		LongURL:   longURL,
-		ShortCode: shortCode,
+		UserID:     userID,
+		CampaignID: campaignID,
+		LongURL:    longURL,
+		ShortCode:  shortCode,
 	}
 	if err := s.linkRepo.Create(ctx, link); err != nil {
 		return nil, err
 	}
+	span.SetAttributes(attribute.String("link.short_code", shortCode))
+	s.audit.Record(ctx, domain.AuditLinkCreate, domain.AuditTargetLink, strconv.FormatInt(link.ID, 10), nil, auditLinkFields(link))
 	return link, nil
 }
 
-func (s *ShortenerService) ResolveLink(ctx context.Context, shortCode string) (string, error) {
+// Collisions returns how many generated short codes were already taken
+// since start.
+func (s *ShortenerService) Collisions() uint64 {
+	return s.collisions.Load()
+}
+
+// ResolveLink returns the destination for shortCode and queues the click.
+// Recording is asynchronous, so a slow or failing database does not fail
+// the redirect.
+func (s *ShortenerService) ResolveLink(ctx context.Context, shortCode string, click domain.ClickContext) (_ string, err error) {
+	ctx, span := startSpan(ctx, "ShortenerService.ResolveLink", attribute.String("link.short_code", shortCode))
+	defer func() { endSpan(span, err) }()
+
 	link, err := s.linkRepo.FindByShortCode(ctx, shortCode)
 	if err != nil {
 		return "", err
@@ -130,19 +250,106 @@ func (s *ShortenerService) ResolveLink(ctx context.Context, shortCode string) (s
 		return "", ErrLinkNotFound
 	}
 
-	if err := s.linkRepo.TrackClick(ctx, shortCode); err != nil {
+	if link.FlaggedAt != nil {
+		return "", &FlaggedLinkError{LongURL: link.LongURL, Reason: link.FlagReason}
+	}
+
+	target, err := s.guard.Follow(ctx, link.LongURL)
+	if err != nil {
 		return "", err
 	}
 
-	return link.LongURL, nil
+	if err := s.validator.ValidateStatic(target); err != nil {
+		return "", err
+	}
+
+	s.recorder.Record(ctx, s.clicks.Build(link, click))
+
+	return target, nil
 }
 
-func (s *ShortenerService) ListLinksByUser(ctx context.Context, userID int64) ([]*domain.Link, error) {
-	return s.linkRepo.ListByUser(ctx, userID)
+// UpdateLongURL points an existing link at a new destination. The new
+// destination goes through the same checks as on create.
+func (s *ShortenerService) UpdateLongURL(ctx context.Context, userID int64, shortCode, longURL string) (_ *domain.Link, err error) {
+	ctx, span := startSpan(ctx, "ShortenerService.UpdateLongURL", attribute.Int64("user.id", userID), attribute.String("link.short_code", shortCode))
+	defer func() { endSpan(span, err) }()
+
+	longURL, err = s.prepareDestination(ctx, shortCode, longURL)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.linkRepo.FindLinkCountByUserIDAndLongURL(ctx, userID, longURL)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrLinkAlreadyExists
+	}
+
+	before, err := s.linkRepo.FindByShortCode(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	link, err := s.linkRepo.UpdateLongURL(ctx, userID, shortCode, longURL)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return nil, ErrLinkNotFound
+	}
+	if before != nil {
+		s.audit.Record(ctx, domain.AuditLinkUpdate, domain.AuditTargetLink, strconv.FormatInt(link.ID, 10), auditLinkFields(before), auditLinkFields(link))
+	}
+	return link, nil
 }
 
-func (s *ShortenerService) SoftDeleteByCode(ctx context.Context, userID int64, shortCode string) error {
-	return s.linkRepo.SoftDeleteByShortCode(ctx, userID, shortCode)
+// prepareDestination validates, loop-checks and screens a destination and
+// returns the URL that should be stored.
+func (s *ShortenerService) prepareDestination(ctx context.Context, shortCode, longURL string) (string, error) {
+	longURL = strings.TrimSpace(longURL)
+	if err := s.validator.Validate(ctx, longURL); err != nil {
+		return "", err
+	}
+	resolved, err := s.guard.Check(ctx, shortCode, longURL)
+	if err != nil {
+		return "", err
+	}
+	if resolved != longURL {
+		// The chain went through one of our own links; the final target
+		// must pass the same checks.
+		if err := s.validator.Validate(ctx, resolved); err != nil {
+			return "", err
+		}
+		longURL = resolved
+	}
+	if err := screenURL(ctx, s.screener, longURL); err != nil {
+		return "", err
+	}
+	return longURL, nil
+}
+
+func (s *ShortenerService) ListLinksByUser(ctx context.Context, userID int64, filter domain.LinkFilter) (_ []*domain.Link, err error) {
+	ctx, span := startSpan(ctx, "ShortenerService.ListLinksByUser", attribute.Int64("user.id", userID))
+	defer func() { endSpan(span, err) }()
+	return s.linkRepo.ListByUser(ctx, userID, filter)
+}
+
+func (s *ShortenerService) SoftDeleteByCode(ctx context.Context, userID int64, shortCode string) (err error) {
+	ctx, span := startSpan(ctx, "ShortenerService.SoftDeleteByCode", attribute.Int64("user.id", userID), attribute.String("link.short_code", shortCode))
+	defer func() { endSpan(span, err) }()
+	link, err := s.linkRepo.FindByShortCode(ctx, shortCode)
+	if err != nil {
+		return err
+	}
+	if err := s.linkRepo.SoftDeleteByShortCode(ctx, userID, shortCode); err != nil {
+		return err
+	}
+	// Deleting someone else's or an already deleted link changes nothing.
+	if link != nil && link.UserID == userID {
+		s.audit.Record(ctx, domain.AuditLinkDelete, domain.AuditTargetLink, strconv.FormatInt(link.ID, 10), auditLinkFields(link), nil)
+	}
+	return nil
 }
 
 func generateRandomCode(n int) string {
@@ -0,0 +1,326 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/hll"
+)
+
+const (
+	// maxStatsBuckets caps how many buckets one request may return.
+	maxStatsBuckets = 2000
+
+	defaultBreakdownLimit = 10
+	maxBreakdownLimit     = 100
+	defaultBreakdownDays  = 30
+)
+
+var (
+	ErrInvalidStatsInterval  = errors.New("interval must be one of hour, day, week, month")
+	ErrInvalidTimezone       = errors.New("unknown time zone")
+	ErrInvalidStatsRange     = errors.New("from must be before to")
+	ErrStatsRangeTooLarge    = fmt.Errorf("range spans more than %d buckets, use a larger interval", maxStatsBuckets)
+	ErrInvalidStatsDimension = errors.New("dimension must be one of " + strings.Join(domain.StatsDimensions, ", "))
+	ErrInvalidStatsLimit     = fmt.Errorf("limit must be between 1 and %d", maxBreakdownLimit)
+)
+
+type ClickStatsRepository interface {
+	// ClickSeries returns the non-empty buckets of the query, ordered by
+	// start. Buckets are aligned to the interval in query.Location.
+	ClickSeries(ctx context.Context, query domain.ClickSeriesQuery) ([]domain.TimeBucket, error)
+	// Breakdown returns the top query.Limit values with their clicks and
+	// the total clicks across all values.
+	Breakdown(ctx context.Context, query domain.BreakdownQuery) ([]domain.DimensionCount, int64, error)
+	// VisitorSketches returns the visitor sketches of the UTC days from
+	// fromDay through toDay.
+	VisitorSketches(ctx context.Context, userID int64, linkID *int64, fromDay, toDay time.Time) ([]domain.DailySketch, error)
+}
+
+// StatsService serves click analytics from the pre-aggregated rollups.
+type StatsService struct {
+	linkRepo  LinkRepository
+	statsRepo ClickStatsRepository
+}
+
+func NewStatsService(linkRepo LinkRepository, statsRepo ClickStatsRepository) *StatsService {
+	if linkRepo == nil {
+		panic("LinkRepository cannot be nil")
+	}
+	if statsRepo == nil {
+		panic("ClickStatsRepository cannot be nil")
+	}
+	return &StatsService{linkRepo: linkRepo, statsRepo: statsRepo}
+}
+
+// LinkClickSeries returns the click time series of one of the user's links.
+func (s *StatsService) LinkClickSeries(ctx context.Context, userID int64, shortCode string, from, to *time.Time, interval, tz string, includeBots bool) (*domain.ClickSeries, error) {
+	link, err := s.linkRepo.FindByShortCode(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil || link.UserID != userID {
+		return nil, ErrLinkNotFound
+	}
+	series, err := s.clickSeries(ctx, userID, &link.ID, from, to, interval, tz, includeBots)
+	if err != nil {
+		return nil, err
+	}
+	series.ShortCode = link.ShortCode
+	return series, nil
+}
+
+// AccountClickSeries returns the click time series across all of the
+// user's links.
+func (s *StatsService) AccountClickSeries(ctx context.Context, userID int64, from, to *time.Time, interval, tz string, includeBots bool) (*domain.ClickSeries, error) {
+	return s.clickSeries(ctx, userID, nil, from, to, interval, tz, includeBots)
+}
+
+// clickSeries validates the request, loads the rollups and fills the gaps.
+// The range defaults to a window ending now whose length depends on the
+// interval; from is widened to the start of its bucket.
+func (s *StatsService) clickSeries(ctx context.Context, userID int64, linkID *int64, from, to *time.Time, interval, tz string, includeBots bool) (*domain.ClickSeries, error) {
+	if interval == "" {
+		interval = domain.StatsIntervalDay
+	}
+	if !domain.IsValidStatsInterval(interval) {
+		return nil, ErrInvalidStatsInterval
+	}
+	if tz == "" {
+		tz = "UTC"
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+
+	end := time.Now()
+	if to != nil {
+		end = *to
+	}
+	var start time.Time
+	if from != nil {
+		start = *from
+	} else {
+		start = defaultStatsWindowStart(end, interval)
+	}
+	if !start.Before(end) {
+		return nil, ErrInvalidStatsRange
+	}
+	start = truncateToInterval(start, interval, loc)
+
+	var starts []time.Time
+	for t := start; t.Before(end); t = nextInterval(t, interval) {
+		if len(starts) == maxStatsBuckets {
+			return nil, ErrStatsRangeTooLarge
+		}
+		starts = append(starts, t)
+	}
+
+	rows, err := s.statsRepo.ClickSeries(ctx, domain.ClickSeriesQuery{
+		UserID:      userID,
+		LinkID:      linkID,
+		From:        start,
+		To:          end,
+		Interval:    interval,
+		Location:    loc,
+		IncludeBots: includeBots,
+	})
+	if err != nil {
+		return nil, err
+	}
+	clicks := make(map[int64]int64, len(rows))
+	for _, row := range rows {
+		clicks[row.Start.Unix()] += row.Clicks
+	}
+
+	series := &domain.ClickSeries{
+		IncludeBots: includeBots,
+		Interval:    interval,
+		Timezone:    loc.String(),
+		From:        start,
+		To:          end,
+		Buckets:     make([]domain.TimeBucket, 0, len(starts)),
+	}
+	for _, t := range starts {
+		n := clicks[t.Unix()]
+		series.Total += n
+		series.Buckets = append(series.Buckets, domain.TimeBucket{Start: t, Clicks: n})
+	}
+	if interval != domain.StatsIntervalHour {
+		if err := s.addUniqueVisitors(ctx, series, userID, linkID, loc); err != nil {
+			return nil, err
+		}
+	}
+	return series, nil
+}
+
+// addUniqueVisitors merges the daily visitor sketches into each bucket and
+// into the whole range. Bots never reach the sketches. Sketches cover UTC
+// days; a UTC day is counted in the bucket holding the same calendar date
+// in loc.
+func (s *StatsService) addUniqueVisitors(ctx context.Context, series *domain.ClickSeries, userID int64, linkID *int64, loc *time.Location) error {
+	last := series.To.Add(-time.Nanosecond).In(loc)
+	fromDay := time.Date(series.From.Year(), series.From.Month(), series.From.Day(), 0, 0, 0, 0, time.UTC)
+	toDay := time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, time.UTC)
+	rows, err := s.statsRepo.VisitorSketches(ctx, userID, linkID, fromDay, toDay)
+	if err != nil {
+		return err
+	}
+
+	index := make(map[int64]int, len(series.Buckets))
+	perBucket := make([]*hll.Sketch, len(series.Buckets))
+	for i, b := range series.Buckets {
+		index[b.Start.Unix()] = i
+		perBucket[i] = hll.NewDefault()
+	}
+	total := hll.NewDefault()
+	for _, row := range rows {
+		sketch, err := hll.Decode(row.Sketch)
+		if err != nil {
+			return fmt.Errorf("visitor sketch of link %d on %s: %w", row.LinkID, row.Day.Format(time.DateOnly), err)
+		}
+		localDay := time.Date(row.Day.Year(), row.Day.Month(), row.Day.Day(), 0, 0, 0, 0, loc)
+		i, ok := index[truncateToInterval(localDay, series.Interval, loc).Unix()]
+		if !ok {
+			continue
+		}
+		if err := perBucket[i].Merge(sketch); err != nil {
+			return err
+		}
+		if err := total.Merge(sketch); err != nil {
+			return err
+		}
+	}
+
+	for i := range series.Buckets {
+		n := int64(perBucket[i].Estimate())
+		series.Buckets[i].UniqueVisitors = &n
+	}
+	n := int64(total.Estimate())
+	series.UniqueVisitors = &n
+	return nil
+}
+
+// LinkBreakdown returns the top values of dimension for one of the user's
+// links.
+func (s *StatsService) LinkBreakdown(ctx context.Context, userID int64, shortCode, dimension string, from, to *time.Time, limit int, includeBots bool) (*domain.Breakdown, error) {
+	link, err := s.linkRepo.FindByShortCode(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil || link.UserID != userID {
+		return nil, ErrLinkNotFound
+	}
+	breakdown, err := s.breakdown(ctx, userID, &link.ID, dimension, from, to, limit, includeBots)
+	if err != nil {
+		return nil, err
+	}
+	breakdown.ShortCode = link.ShortCode
+	return breakdown, nil
+}
+
+// AccountBreakdown returns the top values of dimension across all of the
+// user's links.
+func (s *StatsService) AccountBreakdown(ctx context.Context, userID int64, dimension string, from, to *time.Time, limit int, includeBots bool) (*domain.Breakdown, error) {
+	return s.breakdown(ctx, userID, nil, dimension, from, to, limit, includeBots)
+}
+
+// breakdown reads the daily dimension rollups, so from and to are
+// widened to whole UTC days. It defaults to the last 30 days.
+func (s *StatsService) breakdown(ctx context.Context, userID int64, linkID *int64, dimension string, from, to *time.Time, limit int, includeBots bool) (*domain.Breakdown, error) {
+	if !domain.IsValidStatsDimension(dimension) {
+		return nil, ErrInvalidStatsDimension
+	}
+	if limit == 0 {
+		limit = defaultBreakdownLimit
+	}
+	if limit < 0 || limit > maxBreakdownLimit {
+		return nil, ErrInvalidStatsLimit
+	}
+	end := time.Now().UTC()
+	if to != nil {
+		end = to.UTC()
+	}
+	start := end.AddDate(0, 0, -defaultBreakdownDays)
+	if from != nil {
+		start = from.UTC()
+	}
+	if !start.Before(end) {
+		return nil, ErrInvalidStatsRange
+	}
+	start = truncateToInterval(start, domain.StatsIntervalDay, time.UTC)
+
+	items, total, err := s.statsRepo.Breakdown(ctx, domain.BreakdownQuery{
+		UserID:      userID,
+		LinkID:      linkID,
+		Dimension:   dimension,
+		From:        start,
+		To:          end,
+		Limit:       limit,
+		IncludeBots: includeBots,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var top int64
+	for _, item := range items {
+		top += item.Clicks
+	}
+	if other := total - top; other > 0 {
+		items = append(items, domain.DimensionCount{Value: domain.DimensionValueOther, Clicks: other})
+	}
+	return &domain.Breakdown{
+		IncludeBots: includeBots,
+		Dimension:   dimension,
+		From:        start,
+		To:          end,
+		Total:       total,
+		Items:       items,
+	}, nil
+}
+
+func defaultStatsWindowStart(end time.Time, interval string) time.Time {
+	switch interval {
+	case domain.StatsIntervalHour:
+		return end.Add(-48 * time.Hour)
+	case domain.StatsIntervalWeek:
+		return end.AddDate(0, 0, -7*12)
+	case domain.StatsIntervalMonth:
+		return end.AddDate(-1, 0, 0)
+	}
+	return end.AddDate(0, 0, -30)
+}
+
+// truncateToInterval returns the start of the bucket containing t in loc.
+// Weeks start on Monday, matching Postgres date_trunc.
+func truncateToInterval(t time.Time, interval string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	y, m, d := t.Date()
+	switch interval {
+	case domain.StatsIntervalHour:
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, loc)
+	case domain.StatsIntervalWeek:
+		return time.Date(y, m, d-(int(t.Weekday())+6)%7, 0, 0, 0, 0, loc)
+	case domain.StatsIntervalMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, loc)
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// nextInterval steps by calendar units so local days stay aligned across
+// DST changes.
+func nextInterval(t time.Time, interval string) time.Time {
+	switch interval {
+	case domain.StatsIntervalHour:
+		return t.Add(time.Hour)
+	case domain.StatsIntervalWeek:
+		return t.AddDate(0, 0, 7)
+	case domain.StatsIntervalMonth:
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(0, 0, 1)
+}
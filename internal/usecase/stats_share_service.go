@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+	"url-shortener/internal/domain"
+)
+
+const publicBreakdownLimit = 10
+
+var (
+	ErrShareNotFound      = errors.New("share not found")
+	ErrShareGone          = errors.New("share has expired or was revoked")
+	ErrInvalidShareExpiry = errors.New("expires_at must be in the future")
+)
+
+type StatsShareRepository interface {
+	Create(ctx context.Context, share *domain.StatsShare) error
+	ListByLink(ctx context.Context, linkID int64) ([]*domain.StatsShare, error)
+	// FindByToken returns the share with its link's short code, or nil if
+	// there is none or the link was deleted.
+	FindByToken(ctx context.Context, token string) (*domain.StatsShare, error)
+	// Revoke marks the share revoked and returns it, or nil if the link has
+	// no such share.
+	Revoke(ctx context.Context, linkID, shareID int64) (*domain.StatsShare, error)
+}
+
+// StatsShareService lets link owners publish a link's statistics under a
+// token, and serves them to anyone holding it. Shared stats never include
+// bot clicks.
+type StatsShareService struct {
+	linkRepo  LinkRepository
+	shareRepo StatsShareRepository
+	stats     *StatsService
+}
+
+func NewStatsShareService(linkRepo LinkRepository, shareRepo StatsShareRepository, stats *StatsService) *StatsShareService {
+	if linkRepo == nil {
+		panic("LinkRepository cannot be nil")
+	}
+	if shareRepo == nil {
+		panic("StatsShareRepository cannot be nil")
+	}
+	if stats == nil {
+		panic("StatsService cannot be nil")
+	}
+	return &StatsShareService{linkRepo: linkRepo, shareRepo: shareRepo, stats: stats}
+}
+
+// CreateShare shares one of the user's links. Only the listed breakdown
+// dimensions are exposed; none means the time series only.
+func (s *StatsShareService) CreateShare(ctx context.Context, userID int64, shortCode string, dimensions []string, expiresAt *time.Time) (*domain.StatsShare, error) {
+	link, err := s.ownedLink(ctx, userID, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, ErrInvalidShareExpiry
+	}
+	selected := make(map[string]bool, len(dimensions))
+	for _, d := range dimensions {
+		if !domain.IsValidStatsDimension(d) {
+			return nil, ErrInvalidStatsDimension
+		}
+		selected[d] = true
+	}
+	// Keep the canonical order so pages always list breakdowns alike.
+	shared := []string{}
+	for _, d := range domain.StatsDimensions {
+		if selected[d] {
+			shared = append(shared, d)
+		}
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		return nil, err
+	}
+	share := &domain.StatsShare{
+		LinkID:     link.ID,
+		UserID:     userID,
+		ShortCode:  link.ShortCode,
+		Token:      token,
+		Dimensions: shared,
+		ExpiresAt:  expiresAt,
+	}
+	if err := s.shareRepo.Create(ctx, share); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+// ListShares returns every share of one of the user's links, including
+// revoked and expired ones.
+func (s *StatsShareService) ListShares(ctx context.Context, userID int64, shortCode string) ([]*domain.StatsShare, error) {
+	link, err := s.ownedLink(ctx, userID, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	shares, err := s.shareRepo.ListByLink(ctx, link.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, share := range shares {
+		share.ShortCode = link.ShortCode
+	}
+	return shares, nil
+}
+
+// RevokeShare stops a share from being viewed. It cannot be undone.
+func (s *StatsShareService) RevokeShare(ctx context.Context, userID int64, shortCode string, shareID int64) (*domain.StatsShare, error) {
+	link, err := s.ownedLink(ctx, userID, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	share, err := s.shareRepo.Revoke(ctx, link.ID, shareID)
+	if err != nil {
+		return nil, err
+	}
+	if share == nil {
+		return nil, ErrShareNotFound
+	}
+	share.ShortCode = link.ShortCode
+	return share, nil
+}
+
+// PublicStats returns the shared statistics for token. The series takes
+// the same range, interval and time zone options as the owner's stats;
+// breakdowns cover the series' range.
+func (s *StatsShareService) PublicStats(ctx context.Context, token string, from, to *time.Time, interval, tz string) (*domain.PublicStats, error) {
+	share, err := s.shareRepo.FindByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if share == nil {
+		return nil, ErrShareNotFound
+	}
+	if !share.Active(time.Now()) {
+		return nil, ErrShareGone
+	}
+
+	series, err := s.stats.clickSeries(ctx, share.UserID, &share.LinkID, from, to, interval, tz, false)
+	if err != nil {
+		return nil, err
+	}
+	series.ShortCode = share.ShortCode
+	stats := &domain.PublicStats{Share: share, Series: series, Breakdowns: []*domain.Breakdown{}}
+	for _, dimension := range share.Dimensions {
+		breakdown, err := s.stats.breakdown(ctx, share.UserID, &share.LinkID, dimension, &series.From, &series.To, publicBreakdownLimit, false)
+		if err != nil {
+			return nil, err
+		}
+		breakdown.ShortCode = share.ShortCode
+		stats.Breakdowns = append(stats.Breakdowns, breakdown)
+	}
+	return stats, nil
+}
+
+func (s *StatsShareService) ownedLink(ctx context.Context, userID int64, shortCode string) (*domain.Link, error) {
+	link, err := s.linkRepo.FindByShortCode(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil || link.UserID != userID {
+		return nil, ErrLinkNotFound
+	}
+	return link, nil
+}
+
+// newShareToken returns 192 random bits, URL-safe base64 encoded.
+func newShareToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
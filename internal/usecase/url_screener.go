@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"url-shortener/internal/domain"
+)
+
+var (
+	ErrURLFlagged  = errors.New("destination URL is flagged as malicious")
+	ErrLinkFlagged = errors.New("link is disabled because its destination was flagged")
+
+	ErrInvalidBlocklistEntry = errors.New("invalid blocklist entry")
+)
+
+// ScreenVerdict is the result of screening a destination URL.
+type ScreenVerdict struct {
+	Flagged bool
+	Reason  string
+	Source  string
+}
+
+// URLScreener decides whether a destination is safe to redirect to.
+// Implementations live outside the usecase package (see internal/screener).
+type URLScreener interface {
+	Screen(ctx context.Context, rawURL string) (ScreenVerdict, error)
+}
+
+// BlocklistStore is the admin-editable side of the local blocklist provider.
+type BlocklistStore interface {
+	List() domain.Blocklist
+	Add(ctx context.Context, kind, value string) error
+	Remove(ctx context.Context, kind, value string) error
+}
+
+// MultiScreener runs screeners in order and stops at the first flag.
+type MultiScreener []URLScreener
+
+func (m MultiScreener) Screen(ctx context.Context, rawURL string) (ScreenVerdict, error) {
+	for _, s := range m {
+		v, err := s.Screen(ctx, rawURL)
+		if err != nil {
+			return ScreenVerdict{}, err
+		}
+		if v.Flagged {
+			return v, nil
+		}
+	}
+	return ScreenVerdict{}, nil
+}
+
+// FlaggedLinkError is returned by ResolveLink for disabled links so the
+// transport layer can render a warning instead of redirecting.
+type FlaggedLinkError struct {
+	LongURL string
+	Reason  string
+}
+
+func (e *FlaggedLinkError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrLinkFlagged, e.Reason)
+}
+
+func (e *FlaggedLinkError) Is(target error) bool {
+	return target == ErrLinkFlagged
+}
+
+func screenURL(ctx context.Context, screener URLScreener, longURL string) error {
+	if screener == nil {
+		return nil
+	}
+	v, err := screener.Screen(ctx, longURL)
+	if err != nil {
+		return err
+	}
+	if v.Flagged {
+		return fmt.Errorf("%w: %s", ErrURLFlagged, v.Reason)
+	}
+	return nil
+}
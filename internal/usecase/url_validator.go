@@ -0,0 +1,228 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// URLValidationError describes why a destination URL was rejected. Code is
+// a stable machine-readable identifier returned to API clients.
+type URLValidationError struct {
+	Code    string
+	Message string
+}
+
+func (e *URLValidationError) Error() string {
+	return e.Message
+}
+
+var (
+	ErrURLEmpty        = &URLValidationError{Code: "url_empty", Message: "destination URL is required"}
+	ErrURLTooLong      = &URLValidationError{Code: "url_too_long", Message: "destination URL is too long"}
+	ErrURLMalformed    = &URLValidationError{Code: "url_malformed", Message: "destination URL must be an absolute URL with a host"}
+	ErrURLScheme       = &URLValidationError{Code: "url_scheme_not_allowed", Message: "destination URL scheme is not allowed"}
+	ErrURLCredentials  = &URLValidationError{Code: "url_credentials", Message: "destination URL must not contain credentials"}
+	ErrURLPrivateHost  = &URLValidationError{Code: "url_private_host", Message: "destination URL points to a private or local address"}
+	ErrURLUnresolvable = &URLValidationError{Code: "url_unresolvable", Message: "destination host could not be resolved"}
+	ErrURLHostTooLong  = &URLValidationError{Code: "url_host_too_long", Message: "destination host is too long"}
+	ErrURLControlChars = &URLValidationError{Code: "url_control_characters", Message: "destination URL contains control characters"}
+)
+
+var (
+	privateHostSuffixes = []string{"localhost", ".localhost", ".local", ".internal"}
+	// 100.64.0.0/10 (carrier-grade NAT) is not covered by netip.Addr.IsPrivate.
+	sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+)
+
+// Resolver is the subset of net.Resolver used for host checks, so tests can
+// inject a fake.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// URLValidatorConfig comes from URL_* env vars, see LoadURLValidatorConfig.
+type URLValidatorConfig struct {
+	AllowedSchemes    []string
+	MaxLength         int
+	MaxHostLength     int
+	BlockPrivateHosts bool
+	ResolveHosts      bool
+	ResolveTimeout    time.Duration
+}
+
+func LoadURLValidatorConfig() URLValidatorConfig {
+	schemes := envList("URL_ALLOWED_SCHEMES")
+	if len(schemes) == 0 {
+		schemes = []string{"http", "https"}
+	}
+	for i, s := range schemes {
+		schemes[i] = strings.ToLower(s)
+	}
+	return URLValidatorConfig{
+		AllowedSchemes:    schemes,
+		MaxLength:         envInt("URL_MAX_LENGTH", 2048),
+		MaxHostLength:     envInt("URL_MAX_HOST_LENGTH", 253),
+		BlockPrivateHosts: envBool("URL_BLOCK_PRIVATE_HOSTS", true),
+		ResolveHosts:      envBool("URL_RESOLVE_HOSTS", false),
+		ResolveTimeout:    envDuration("URL_RESOLVE_TIMEOUT", 2*time.Second),
+	}
+}
+
+// URLValidator enforces what may be used as a redirect destination.
+type URLValidator struct {
+	cfg      URLValidatorConfig
+	resolver Resolver
+}
+
+func NewURLValidator() *URLValidator {
+	return NewURLValidatorWithResolver(LoadURLValidatorConfig(), net.DefaultResolver)
+}
+
+func NewURLValidatorWithResolver(cfg URLValidatorConfig, resolver Resolver) *URLValidator {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return &URLValidator{cfg: cfg, resolver: resolver}
+}
+
+// Validate returns nil or one of the ErrURL* errors.
+func (v *URLValidator) Validate(ctx context.Context, rawURL string) error {
+	host, err := v.validateStatic(rawURL)
+	if err != nil || host == "" || !v.cfg.ResolveHosts {
+		return err
+	}
+	if v.cfg.ResolveTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.cfg.ResolveTimeout)
+		defer cancel()
+	}
+	addrs, err := v.resolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return ErrURLUnresolvable
+	}
+	// Reject if any record is private: an attacker controlling DNS could
+	// otherwise mix public and private answers.
+	for _, a := range addrs {
+		if addr, ok := netip.AddrFromSlice(a.IP); ok && isPrivateAddr(addr) {
+			return ErrURLPrivateHost
+		}
+	}
+	return nil
+}
+
+// ValidateStatic runs every check that needs no network access. It is cheap
+// enough for the redirect path, where it guards against rows stored before
+// validation existed.
+func (v *URLValidator) ValidateStatic(rawURL string) error {
+	_, err := v.validateStatic(rawURL)
+	return err
+}
+
+// validateStatic returns the host still to be resolved, or "" when no DNS
+// check applies.
+func (v *URLValidator) validateStatic(rawURL string) (string, error) {
+	if strings.TrimSpace(rawURL) == "" {
+		return "", ErrURLEmpty
+	}
+	if v.cfg.MaxLength > 0 && len(rawURL) > v.cfg.MaxLength {
+		return "", ErrURLTooLong
+	}
+	if strings.ContainsFunc(rawURL, func(r rune) bool { return r < 0x20 || r == 0x7f }) {
+		return "", ErrURLControlChars
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || !u.IsAbs() {
+		return "", ErrURLMalformed
+	}
+	if !slices.Contains(v.cfg.AllowedSchemes, strings.ToLower(u.Scheme)) {
+		return "", ErrURLScheme
+	}
+	if u.Opaque != "" || u.Host == "" {
+		return "", ErrURLMalformed
+	}
+	if u.User != nil {
+		return "", ErrURLCredentials
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return "", ErrURLMalformed
+	}
+	if v.cfg.MaxHostLength > 0 && len(host) > v.cfg.MaxHostLength {
+		return "", ErrURLHostTooLong
+	}
+	if !v.cfg.BlockPrivateHosts {
+		return "", nil
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if isPrivateAddr(addr) {
+			return "", ErrURLPrivateHost
+		}
+		return "", nil
+	}
+	// Browsers accept shorthand IPv4 forms such as "2130706433" or "0x7f.1";
+	// no real TLD is numeric, so treat anything like that as malformed.
+	labels := strings.Split(host, ".")
+	if last := labels[len(labels)-1]; strings.HasPrefix(last, "0x") || strings.Trim(last, "0123456789") == "" {
+		return "", ErrURLMalformed
+	}
+	for _, suffix := range privateHostSuffixes {
+		if host == strings.TrimPrefix(suffix, ".") || strings.HasSuffix(host, suffix) {
+			return "", ErrURLPrivateHost
+		}
+	}
+
+	return host, nil
+}
+
+// ErrPrivateAddress is returned when an outbound connection would reach a
+// private or local address.
+var ErrPrivateAddress = errors.New("refusing to connect to a private or local address")
+
+// PublicDialControl is a net.Dialer Control hook that refuses private and
+// local addresses. It sees the address actually dialed, so it also holds
+// after redirects and when DNS answers change between check and use.
+func PublicDialControl(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, address)
+	}
+	if isPrivateAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// NewPublicHTTPClient returns a client for fetching user-supplied URLs: it
+// can only connect to public addresses and ignores proxy settings, which
+// would otherwise be dialed instead of the target.
+func NewPublicHTTPClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: PublicDialControl}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Transport: transport}
+}
+
+func isPrivateAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() ||
+		addr.IsUnspecified() ||
+		sharedAddressSpace.Contains(addr)
+}
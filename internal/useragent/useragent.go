@@ -0,0 +1,210 @@
+// Package useragent is a small, dependency-free User-Agent parser. It only
+// extracts what analytics needs: browser family and major version, OS
+// family and device class.
+package useragent
+
+import (
+	"slices"
+	"strings"
+)
+
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	DeviceUnknown = "unknown"
+
+	Unknown = "Other"
+)
+
+type Info struct {
+	Browser        string
+	BrowserVersion string
+	OS             string
+	Device         string
+}
+
+// browserRules are checked in order; the first token found wins. Order
+// matters because most browsers also claim to be Safari/Chrome/Mozilla.
+// Tokens only match at word boundaries, see indexToken.
+var browserRules = []struct {
+	token string
+	name  string
+}{
+	{"edg/", "Edge"},
+	{"edge/", "Edge"},
+	{"opr/", "Opera"},
+	{"opera", "Opera"},
+	{"samsungbrowser/", "Samsung Internet"},
+	{"yabrowser/", "Yandex"},
+	{"ucbrowser/", "UC Browser"},
+	{"vivaldi/", "Vivaldi"},
+	{"fban", "Facebook"},
+	{"fbav/", "Facebook"},
+	{"instagram", "Instagram"},
+	{"line/", "LINE"},
+	{"zalo", "Zalo"},
+	{"firefox/", "Firefox"},
+	{"fxios/", "Firefox"},
+	{"crios/", "Chrome"},
+	{"chrome/", "Chrome"},
+	{"chromium/", "Chromium"},
+	{"msie ", "Internet Explorer"},
+	{"trident/", "Internet Explorer"},
+	{"version/", "Safari"},
+	{"safari/", "Safari"},
+	{"curl/", "curl"},
+	{"wget/", "Wget"},
+	{"python-requests/", "python-requests"},
+	{"go-http-client/", "Go HTTP client"},
+}
+
+var osRules = []struct {
+	token string
+	name  string
+}{
+	{"windows phone", "Windows Phone"},
+	{"windows", "Windows"},
+	{"iphone", "iOS"},
+	{"ipad", "iOS"},
+	{"ipod", "iOS"},
+	{"android", "Android"},
+	{"cros", "ChromeOS"},
+	{"mac os x", "macOS"},
+	{"macintosh", "macOS"},
+	{"linux", "Linux"},
+	{"freebsd", "FreeBSD"},
+}
+
+// botWords mark a bot when they appear as a whole word; botSuffixes when
+// they end a word that is followed by a version, as in "Googlebot/2.1" or
+// "Slackbot 1.0". Device names like "CUBOT X30" are not followed by one.
+var (
+	botWords    = []string{"bot", "crawler", "spider", "slurp", "facebookexternalhit", "preview", "headless", "headlesschrome"}
+	botSuffixes = []string{"bot", "crawler", "spider"}
+)
+
+// Parse classifies a User-Agent header. Empty input yields "Other"/unknown.
+func Parse(ua string) Info {
+	info := Info{Browser: Unknown, OS: Unknown, Device: DeviceUnknown}
+	lower := strings.ToLower(strings.TrimSpace(ua))
+	if lower == "" {
+		return info
+	}
+
+	for _, r := range browserRules {
+		if idx := indexToken(lower, r.token); idx >= 0 {
+			info.Browser = r.name
+			info.BrowserVersion = majorVersion(lower, idx+len(r.token))
+			break
+		}
+	}
+	// Safari reports its real version after "Version/", not "Safari/".
+	if info.Browser == "Safari" {
+		if idx := indexToken(lower, "version/"); idx >= 0 {
+			info.BrowserVersion = majorVersion(lower, idx+len("version/"))
+		}
+	}
+
+	for _, r := range osRules {
+		if indexToken(lower, r.token) >= 0 {
+			info.OS = r.name
+			break
+		}
+	}
+
+	info.Device = deviceClass(lower, info.OS)
+	return info
+}
+
+func deviceClass(lower, os string) string {
+	if isBot(lower) {
+		return DeviceBot
+	}
+	has := func(token string) bool { return indexToken(lower, token) >= 0 }
+	switch {
+	case has("ipad"), has("tablet"), os == "Android" && !has("mobile"):
+		return DeviceTablet
+	case has("mobile"), has("mobi"), has("iphone"), has("ipod"), os == "Windows Phone":
+		return DeviceMobile
+	case os == "Windows", os == "macOS", os == "Linux", os == "ChromeOS", os == "FreeBSD":
+		return DeviceDesktop
+	}
+	return DeviceUnknown
+}
+
+func isBot(lower string) bool {
+	for start := 0; start < len(lower); {
+		if !isLetter(lower[start]) {
+			start++
+			continue
+		}
+		end := start
+		for end < len(lower) && isLetter(lower[end]) {
+			end++
+		}
+		word := lower[start:end]
+		if slices.Contains(botWords, word) {
+			return true
+		}
+		if followedByVersion(lower, end) {
+			for _, suffix := range botSuffixes {
+				if strings.HasSuffix(word, suffix) {
+					return true
+				}
+			}
+		}
+		start = end
+	}
+	return false
+}
+
+// followedByVersion reports whether s continues at pos with "/", "-" or a
+// space and a digit, the ways product tokens announce a version.
+func followedByVersion(s string, pos int) bool {
+	if pos >= len(s) {
+		return false
+	}
+	switch s[pos] {
+	case '/', '-':
+		return true
+	case ' ':
+		return pos+1 < len(s) && isDigit(s[pos+1])
+	}
+	return false
+}
+
+// indexToken finds token in s where it starts a word and, if it ends in a
+// letter, also ends one, so "cros" does not match inside "microsoft".
+func indexToken(s, token string) int {
+	for offset := 0; ; {
+		idx := strings.Index(s[offset:], token)
+		if idx < 0 {
+			return -1
+		}
+		idx += offset
+		end := idx + len(token)
+		startOK := idx == 0 || !isAlnum(s[idx-1])
+		endOK := !isLetter(token[len(token)-1]) || end == len(s) || !isLetter(s[end])
+		if startOK && endOK {
+			return idx
+		}
+		offset = idx + 1
+	}
+}
+
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' }
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isAlnum(c byte) bool { return isLetter(c) || isDigit(c) }
+
+// majorVersion reads digits starting at pos, e.g. "120" from "chrome/120.0".
+func majorVersion(s string, pos int) string {
+	end := pos
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	return s[pos:end]
+}
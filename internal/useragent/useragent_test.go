@@ -0,0 +1,119 @@
+package useragent
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		ua   string
+		want Info
+	}{
+		{
+			name: "empty",
+			ua:   "",
+			want: Info{Browser: Unknown, OS: Unknown, Device: DeviceUnknown},
+		},
+		{
+			name: "chrome on windows",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			want: Info{Browser: "Chrome", BrowserVersion: "120", OS: "Windows", Device: DeviceDesktop},
+		},
+		{
+			name: "edge on windows",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			want: Info{Browser: "Edge", BrowserVersion: "120", OS: "Windows", Device: DeviceDesktop},
+		},
+		{
+			name: "firefox on linux",
+			ua:   "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			want: Info{Browser: "Firefox", BrowserVersion: "121", OS: "Linux", Device: DeviceDesktop},
+		},
+		{
+			name: "safari on macos",
+			ua:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+			want: Info{Browser: "Safari", BrowserVersion: "17", OS: "macOS", Device: DeviceDesktop},
+		},
+		{
+			name: "safari on iphone",
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			want: Info{Browser: "Safari", BrowserVersion: "17", OS: "iOS", Device: DeviceMobile},
+		},
+		{
+			name: "safari on ipad",
+			ua:   "Mozilla/5.0 (iPad; CPU OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			want: Info{Browser: "Safari", BrowserVersion: "17", OS: "iOS", Device: DeviceTablet},
+		},
+		{
+			name: "chrome on android phone",
+			ua:   "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36",
+			want: Info{Browser: "Chrome", BrowserVersion: "120", OS: "Android", Device: DeviceMobile},
+		},
+		{
+			name: "chrome on android tablet",
+			ua:   "Mozilla/5.0 (Linux; Android 13; SM-X200) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			want: Info{Browser: "Chrome", BrowserVersion: "120", OS: "Android", Device: DeviceTablet},
+		},
+		{
+			name: "cubot phone is not a bot",
+			ua:   "Mozilla/5.0 (Linux; Android 10; CUBOT X30) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.6045.163 Mobile Safari/537.36",
+			want: Info{Browser: "Chrome", BrowserVersion: "119", OS: "Android", Device: DeviceMobile},
+		},
+		{
+			name: "chrome on chromeos",
+			ua:   "Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			want: Info{Browser: "Chrome", BrowserVersion: "120", OS: "ChromeOS", Device: DeviceDesktop},
+		},
+		{
+			name: "microsoft office is not chromeos",
+			ua:   "Microsoft Office/16.0 (Windows NT 10.0; Microsoft Outlook 16.0.17126; Pro)",
+			want: Info{Browser: Unknown, OS: "Windows", Device: DeviceDesktop},
+		},
+		{
+			name: "facebook in-app on ios",
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 [FBAN/FBIOS;FBAV/444.0.0.38.110;FBBV/541088358]",
+			want: Info{Browser: "Facebook", OS: "iOS", Device: DeviceMobile},
+		},
+		{
+			name: "googlebot",
+			ua:   "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			want: Info{Browser: Unknown, OS: Unknown, Device: DeviceBot},
+		},
+		{
+			name: "bingbot",
+			ua:   "Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm) Chrome/116.0.1938.76 Safari/537.36",
+			want: Info{Browser: "Chrome", BrowserVersion: "116", OS: Unknown, Device: DeviceBot},
+		},
+		{
+			name: "slackbot",
+			ua:   "Slackbot 1.0 (+https://api.slack.com/robots)",
+			want: Info{Browser: Unknown, OS: Unknown, Device: DeviceBot},
+		},
+		{
+			name: "baiduspider",
+			ua:   "Mozilla/5.0 (compatible; Baiduspider/2.0; +http://www.baidu.com/search/spider.html)",
+			want: Info{Browser: Unknown, OS: Unknown, Device: DeviceBot},
+		},
+		{
+			name: "facebook crawler",
+			ua:   "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)",
+			want: Info{Browser: Unknown, OS: Unknown, Device: DeviceBot},
+		},
+		{
+			name: "headless chrome",
+			ua:   "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/120.0.6099.109 Safari/537.36",
+			want: Info{Browser: "Safari", BrowserVersion: "537", OS: "Linux", Device: DeviceBot},
+		},
+		{
+			name: "curl",
+			ua:   "curl/8.4.0",
+			want: Info{Browser: "curl", BrowserVersion: "8", OS: Unknown, Device: DeviceUnknown},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.ua); got != tt.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.ua, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_links_user_health;
+ALTER TABLE links
+  DROP COLUMN IF EXISTS health_checked_at,
+  DROP COLUMN IF EXISTS health_redirect_chain,
+  DROP COLUMN IF EXISTS health_latency_ms,
+  DROP COLUMN IF EXISTS health_status_code,
+  DROP COLUMN IF EXISTS health_status;
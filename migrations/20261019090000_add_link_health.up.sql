@@ -0,0 +1,9 @@
+-- +migrate Up
+ALTER TABLE links
+  ADD COLUMN health_status TEXT NOT NULL DEFAULT 'unknown',
+  ADD COLUMN health_status_code INT NOT NULL DEFAULT 0,
+  ADD COLUMN health_latency_ms BIGINT NOT NULL DEFAULT 0,
+  ADD COLUMN health_redirect_chain TEXT[] NULL,
+  ADD COLUMN health_checked_at TIMESTAMPTZ NULL;
+
+CREATE INDEX idx_links_user_health ON links (user_id, health_status) WHERE deleted_at IS NULL;
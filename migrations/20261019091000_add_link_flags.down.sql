@@ -0,0 +1,4 @@
+-- +migrate Down
+ALTER TABLE links
+  DROP COLUMN IF EXISTS flag_reason,
+  DROP COLUMN IF EXISTS flagged_at;
@@ -0,0 +1,4 @@
+-- +migrate Up
+ALTER TABLE links
+  ADD COLUMN flagged_at TIMESTAMPTZ NULL,
+  ADD COLUMN flag_reason TEXT NULL;
@@ -0,0 +1,6 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_links_campaign_id;
+ALTER TABLE links
+  DROP COLUMN IF EXISTS campaign_id;
+DROP INDEX IF EXISTS idx_campaigns_user_id;
+DROP TABLE IF EXISTS campaigns;
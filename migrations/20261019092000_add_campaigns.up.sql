@@ -0,0 +1,18 @@
+-- +migrate Up
+CREATE TABLE campaigns (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    name TEXT NOT NULL,
+    starts_at TIMESTAMPTZ NULL,
+    ends_at TIMESTAMPTZ NULL,
+    deleted_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX idx_campaigns_user_id ON campaigns(user_id);
+
+ALTER TABLE links
+  ADD COLUMN campaign_id BIGINT NULL REFERENCES campaigns(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_links_campaign_id ON links(campaign_id) WHERE campaign_id IS NOT NULL;
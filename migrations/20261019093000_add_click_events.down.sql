@@ -0,0 +1,4 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_click_events_user_clicked_at;
+DROP INDEX IF EXISTS idx_click_events_link_clicked_at;
+DROP TABLE IF EXISTS click_events;
@@ -0,0 +1,21 @@
+-- +migrate Up
+CREATE TABLE click_events (
+    id BIGSERIAL PRIMARY KEY,
+    link_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    clicked_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    referrer TEXT NULL,
+    referrer_domain TEXT NULL,
+    browser TEXT NULL,
+    browser_version TEXT NULL,
+    os TEXT NULL,
+    device TEXT NULL,
+    languages TEXT NULL,
+    primary_language TEXT NULL,
+    ip_hash TEXT NULL,
+    source TEXT NULL,
+    opt_out BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX idx_click_events_link_clicked_at ON click_events (link_id, clicked_at);
+CREATE INDEX idx_click_events_user_clicked_at ON click_events (user_id, clicked_at);
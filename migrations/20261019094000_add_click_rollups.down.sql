@@ -0,0 +1,3 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_click_rollups_hourly_user_bucket;
+DROP TABLE IF EXISTS click_rollups_hourly;
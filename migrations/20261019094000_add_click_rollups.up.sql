@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE click_rollups_hourly (
+    link_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    bucket TIMESTAMPTZ NOT NULL,
+    clicks BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (link_id, bucket)
+);
+
+CREATE INDEX idx_click_rollups_hourly_user_bucket ON click_rollups_hourly (user_id, bucket);
+
+-- Backfill from events recorded before rollups existed.
+INSERT INTO click_rollups_hourly (link_id, user_id, bucket, clicks)
+SELECT link_id, user_id, date_trunc('hour', clicked_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC', COUNT(*)
+FROM click_events
+GROUP BY 1, 2, 3;
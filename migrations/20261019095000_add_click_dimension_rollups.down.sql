@@ -0,0 +1,4 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_click_dimension_rollups_user;
+DROP TABLE IF EXISTS click_dimension_rollups_daily;
+ALTER TABLE click_events DROP COLUMN IF EXISTS country;
@@ -0,0 +1,28 @@
+-- +migrate Up
+ALTER TABLE click_events ADD COLUMN country TEXT NULL;
+
+CREATE TABLE click_dimension_rollups_daily (
+    link_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    day DATE NOT NULL,
+    dimension TEXT NOT NULL,
+    value TEXT NOT NULL,
+    clicks BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (link_id, dimension, day, value)
+);
+
+CREATE INDEX idx_click_dimension_rollups_user ON click_dimension_rollups_daily (user_id, dimension, day);
+
+-- Backfill from existing events (country was not recorded before).
+INSERT INTO click_dimension_rollups_daily (link_id, user_id, day, dimension, value, clicks)
+SELECT e.link_id, e.user_id, (e.clicked_at AT TIME ZONE 'UTC')::date, d.dimension, d.value, COUNT(*)
+FROM click_events e
+CROSS JOIN LATERAL (VALUES
+    ('referrer', COALESCE(NULLIF(e.referrer_domain, ''), CASE WHEN e.opt_out THEN '(unknown)' ELSE '(direct)' END)),
+    ('browser', COALESCE(NULLIF(e.browser, ''), '(unknown)')),
+    ('os', COALESCE(NULLIF(e.os, ''), '(unknown)')),
+    ('device', COALESCE(NULLIF(e.device, ''), '(unknown)')),
+    ('country', '(unknown)'),
+    ('language', COALESCE(NULLIF(e.primary_language, ''), '(unknown)'))
+) AS d (dimension, value)
+GROUP BY 1, 2, 3, 4, 5;
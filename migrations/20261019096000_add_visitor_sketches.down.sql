@@ -0,0 +1,3 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_click_visitor_sketches_user_day;
+DROP TABLE IF EXISTS click_visitor_sketches_daily;
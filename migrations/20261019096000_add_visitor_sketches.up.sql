@@ -0,0 +1,13 @@
+-- +migrate Up
+-- Daily HyperLogLog sketches of visitor fingerprints (see internal/hll).
+-- Clicks recorded before this migration have no fingerprint and are not
+-- counted as unique visitors.
+CREATE TABLE click_visitor_sketches_daily (
+    link_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    day DATE NOT NULL,
+    sketch BYTEA NULL,
+    PRIMARY KEY (link_id, day)
+);
+
+CREATE INDEX idx_click_visitor_sketches_user_day ON click_visitor_sketches_daily (user_id, day);
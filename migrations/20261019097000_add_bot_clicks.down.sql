@@ -0,0 +1,6 @@
+-- +migrate Down
+ALTER TABLE click_dimension_rollups_daily DROP COLUMN IF EXISTS bot_clicks;
+ALTER TABLE click_rollups_hourly DROP COLUMN IF EXISTS bot_clicks;
+ALTER TABLE click_events DROP COLUMN IF EXISTS bot_reason;
+ALTER TABLE click_events DROP COLUMN IF EXISTS is_bot;
+ALTER TABLE links DROP COLUMN IF EXISTS bot_click_count;
@@ -0,0 +1,10 @@
+-- +migrate Up
+-- Bot clicks are counted apart from human ones. Existing counts are not
+-- reclassified.
+ALTER TABLE links ADD COLUMN bot_click_count BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE click_events ADD COLUMN is_bot BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE click_events ADD COLUMN bot_reason TEXT NULL;
+
+ALTER TABLE click_rollups_hourly ADD COLUMN bot_clicks BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE click_dimension_rollups_daily ADD COLUMN bot_clicks BIGINT NOT NULL DEFAULT 0;
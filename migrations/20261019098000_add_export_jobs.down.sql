@@ -0,0 +1,5 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_click_events_clicked_at;
+DROP INDEX IF EXISTS idx_export_jobs_expires_at;
+DROP INDEX IF EXISTS idx_export_jobs_status;
+DROP TABLE IF EXISTS export_jobs;
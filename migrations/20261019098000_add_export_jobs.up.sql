@@ -0,0 +1,28 @@
+-- +migrate Up
+-- Background click exports. Files live in EXPORT_DIR and are removed
+-- together with their row once expires_at passes.
+CREATE TABLE export_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    token TEXT NOT NULL UNIQUE,
+    requested_by BIGINT NOT NULL REFERENCES users(id),
+    user_id BIGINT NULL,
+    link_id BIGINT NULL,
+    dataset TEXT NOT NULL,
+    format TEXT NOT NULL,
+    from_at TIMESTAMPTZ NULL,
+    to_at TIMESTAMPTZ NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    rows BIGINT NOT NULL DEFAULT 0,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    error TEXT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMPTZ NULL,
+    finished_at TIMESTAMPTZ NULL,
+    expires_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX idx_export_jobs_status ON export_jobs (status);
+CREATE INDEX idx_export_jobs_expires_at ON export_jobs (expires_at);
+
+-- Admin-wide exports read every click in time order.
+CREATE INDEX idx_click_events_clicked_at ON click_events (clicked_at);
@@ -0,0 +1,40 @@
+-- +migrate Down
+ALTER TABLE click_events RENAME TO click_events_partitioned;
+ALTER TABLE click_events_partitioned RENAME CONSTRAINT click_events_pkey TO click_events_partitioned_pkey;
+ALTER INDEX idx_click_events_link_clicked_at RENAME TO idx_click_events_partitioned_link_clicked_at;
+ALTER INDEX idx_click_events_user_clicked_at RENAME TO idx_click_events_partitioned_user_clicked_at;
+ALTER INDEX idx_click_events_clicked_at RENAME TO idx_click_events_partitioned_clicked_at;
+
+CREATE TABLE click_events (
+    id BIGINT PRIMARY KEY DEFAULT nextval('click_events_id_seq'),
+    link_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    clicked_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    referrer TEXT NULL,
+    referrer_domain TEXT NULL,
+    browser TEXT NULL,
+    browser_version TEXT NULL,
+    os TEXT NULL,
+    device TEXT NULL,
+    languages TEXT NULL,
+    primary_language TEXT NULL,
+    ip_hash TEXT NULL,
+    source TEXT NULL,
+    opt_out BOOLEAN NOT NULL DEFAULT FALSE,
+    country TEXT NULL,
+    is_bot BOOLEAN NOT NULL DEFAULT FALSE,
+    bot_reason TEXT NULL
+);
+
+ALTER SEQUENCE click_events_id_seq OWNED BY click_events.id;
+
+INSERT INTO click_events SELECT
+    id, link_id, user_id, clicked_at, referrer, referrer_domain, browser, browser_version, os, device,
+    languages, primary_language, ip_hash, source, opt_out, country, is_bot, bot_reason
+FROM click_events_partitioned;
+
+DROP TABLE click_events_partitioned;
+
+CREATE INDEX idx_click_events_link_clicked_at ON click_events (link_id, clicked_at);
+CREATE INDEX idx_click_events_user_clicked_at ON click_events (user_id, clicked_at);
+CREATE INDEX idx_click_events_clicked_at ON click_events (clicked_at);
@@ -0,0 +1,65 @@
+-- +migrate Up
+-- Partition click_events by month of clicked_at (UTC) so old months can be
+-- dropped whole once every plan's retention has passed. The app creates
+-- upcoming partitions ahead of time; the default partition only catches
+-- clicks outside them and should stay empty.
+ALTER TABLE click_events RENAME TO click_events_unpartitioned;
+ALTER TABLE click_events_unpartitioned RENAME CONSTRAINT click_events_pkey TO click_events_unpartitioned_pkey;
+
+CREATE TABLE click_events (
+    id BIGINT NOT NULL DEFAULT nextval('click_events_id_seq'),
+    link_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    clicked_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    referrer TEXT NULL,
+    referrer_domain TEXT NULL,
+    browser TEXT NULL,
+    browser_version TEXT NULL,
+    os TEXT NULL,
+    device TEXT NULL,
+    languages TEXT NULL,
+    primary_language TEXT NULL,
+    ip_hash TEXT NULL,
+    source TEXT NULL,
+    opt_out BOOLEAN NOT NULL DEFAULT FALSE,
+    country TEXT NULL,
+    is_bot BOOLEAN NOT NULL DEFAULT FALSE,
+    bot_reason TEXT NULL,
+    PRIMARY KEY (id, clicked_at)
+) PARTITION BY RANGE (clicked_at);
+
+ALTER SEQUENCE click_events_id_seq OWNED BY click_events.id;
+
+CREATE TABLE click_events_default PARTITION OF click_events DEFAULT;
+
+-- One partition per month from the oldest event through three months ahead.
+DO $$
+DECLARE
+    month_start DATE := date_trunc('month', COALESCE((SELECT MIN(clicked_at) FROM click_events_unpartitioned), NOW()) AT TIME ZONE 'UTC')::date;
+    last_month DATE := (date_trunc('month', NOW() AT TIME ZONE 'UTC') + INTERVAL '3 months')::date;
+BEGIN
+    WHILE month_start <= last_month LOOP
+        EXECUTE format(
+            'CREATE TABLE %I PARTITION OF click_events FOR VALUES FROM (%L) TO (%L)',
+            'click_events_' || to_char(month_start, 'YYYY_MM'),
+            month_start::timestamp AT TIME ZONE 'UTC',
+            (month_start + INTERVAL '1 month')::timestamp AT TIME ZONE 'UTC'
+        );
+        month_start := (month_start + INTERVAL '1 month')::date;
+    END LOOP;
+END $$;
+
+INSERT INTO click_events (
+    id, link_id, user_id, clicked_at, referrer, referrer_domain, browser, browser_version, os, device,
+    languages, primary_language, ip_hash, source, opt_out, country, is_bot, bot_reason
+)
+SELECT
+    id, link_id, user_id, clicked_at, referrer, referrer_domain, browser, browser_version, os, device,
+    languages, primary_language, ip_hash, source, opt_out, country, is_bot, bot_reason
+FROM click_events_unpartitioned;
+
+DROP TABLE click_events_unpartitioned;
+
+CREATE INDEX idx_click_events_link_clicked_at ON click_events (link_id, clicked_at);
+CREATE INDEX idx_click_events_user_clicked_at ON click_events (user_id, clicked_at);
+CREATE INDEX idx_click_events_clicked_at ON click_events (clicked_at);
@@ -0,0 +1,3 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_links_user_click_count;
+ALTER TABLE links DROP COLUMN IF EXISTS health_changed_at;
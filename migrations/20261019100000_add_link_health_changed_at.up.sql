@@ -0,0 +1,7 @@
+-- +migrate Up
+-- When a link's health status last changed, so newly broken links can be
+-- told apart from links that have been broken for a long time.
+ALTER TABLE links ADD COLUMN health_changed_at TIMESTAMPTZ NULL;
+UPDATE links SET health_changed_at = health_checked_at WHERE health_checked_at IS NOT NULL;
+
+CREATE INDEX idx_links_user_click_count ON links (user_id, click_count DESC) WHERE deleted_at IS NULL;
@@ -0,0 +1,13 @@
+-- +migrate Up
+CREATE TABLE stats_shares (
+    id BIGSERIAL PRIMARY KEY,
+    link_id BIGINT NOT NULL REFERENCES links(id),
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    token TEXT NOT NULL UNIQUE,
+    dimensions TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMPTZ NULL,
+    revoked_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_stats_shares_link_id ON stats_shares(link_id);
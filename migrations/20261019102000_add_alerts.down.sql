@@ -0,0 +1,3 @@
+-- +migrate Down
+DROP TABLE IF EXISTS alert_events;
+DROP TABLE IF EXISTS alert_rules;
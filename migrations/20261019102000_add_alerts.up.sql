@@ -0,0 +1,45 @@
+-- +migrate Up
+CREATE TABLE alert_rules (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    link_id BIGINT NULL REFERENCES links(id),
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    threshold BIGINT NOT NULL DEFAULT 0,
+    factor DOUBLE PRECISION NOT NULL DEFAULT 0,
+    window_hours INT NOT NULL DEFAULT 0,
+    min_clicks BIGINT NOT NULL DEFAULT 0,
+    include_bots BOOLEAN NOT NULL DEFAULT FALSE,
+    channel TEXT NOT NULL,
+    target TEXT NOT NULL,
+    cooldown_seconds BIGINT NOT NULL DEFAULT 0,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    last_triggered_at TIMESTAMPTZ NULL,
+    deleted_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX idx_alert_rules_user_id ON alert_rules(user_id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_alert_rules_enabled ON alert_rules(id) WHERE enabled AND deleted_at IS NULL;
+
+CREATE TABLE alert_events (
+    id BIGSERIAL PRIMARY KEY,
+    rule_id BIGINT NOT NULL REFERENCES alert_rules(id),
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    link_id BIGINT NULL REFERENCES links(id),
+    kind TEXT NOT NULL,
+    dedup_key TEXT NOT NULL,
+    value BIGINT NOT NULL,
+    baseline DOUBLE PRECISION NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    error TEXT NULL,
+    triggered_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    next_attempt_at TIMESTAMPTZ NULL,
+    delivered_at TIMESTAMPTZ NULL,
+    UNIQUE (rule_id, dedup_key)
+);
+
+CREATE INDEX idx_alert_events_user_triggered ON alert_events(user_id, triggered_at DESC);
+CREATE INDEX idx_alert_events_retry ON alert_events(next_attempt_at) WHERE status <> 'sent';
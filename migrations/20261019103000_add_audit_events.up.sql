@@ -0,0 +1,38 @@
+-- +migrate Up
+CREATE TABLE audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    actor_user_id BIGINT NULL,
+    actor_api_key_id BIGINT NULL,
+    action TEXT NOT NULL,
+    target_type TEXT NOT NULL,
+    target_id TEXT NOT NULL,
+    before JSONB NULL,
+    after JSONB NULL,
+    ip TEXT NOT NULL DEFAULT '',
+    request_id TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    prev_hash TEXT NOT NULL,
+    hash TEXT NOT NULL UNIQUE
+);
+
+CREATE INDEX idx_audit_events_created_at ON audit_events(created_at);
+CREATE INDEX idx_audit_events_actor ON audit_events(actor_user_id, id);
+CREATE INDEX idx_audit_events_target ON audit_events(target_type, target_id, id);
+
+-- Actors and targets are not foreign keys: the trail must outlive the rows
+-- it describes. Rows can only be inserted.
+-- +migrate StatementBegin
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+
+CREATE TRIGGER audit_events_no_update_delete
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+CREATE TRIGGER audit_events_no_truncate
+    BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
@@ -0,0 +1,9 @@
+-- +migrate Down
+-- Plaintext keys cannot be recovered. The digest fills the old column so
+-- its constraints hold; every key has to be issued again.
+ALTER TABLE apikeys ADD COLUMN key TEXT NULL;
+UPDATE apikeys SET key = key_hash;
+ALTER TABLE apikeys ALTER COLUMN key SET NOT NULL;
+ALTER TABLE apikeys ADD CONSTRAINT apikeys_key_key UNIQUE (key);
+ALTER TABLE apikeys DROP COLUMN key_prefix;
+ALTER TABLE apikeys DROP COLUMN key_hash;
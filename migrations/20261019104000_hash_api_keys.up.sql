@@ -0,0 +1,14 @@
+-- +migrate Up
+-- Keys are kept as the hex SHA-256 of the key plus a short prefix for
+-- telling them apart. The prefix is at most 8 characters and never more
+-- than a quarter of the key.
+ALTER TABLE apikeys ADD COLUMN key_hash TEXT NULL;
+ALTER TABLE apikeys ADD COLUMN key_prefix TEXT NOT NULL DEFAULT '';
+
+UPDATE apikeys SET
+    key_hash = encode(sha256(convert_to(key, 'UTF8')), 'hex'),
+    key_prefix = left(key, LEAST(8, length(key) / 4));
+
+ALTER TABLE apikeys ALTER COLUMN key_hash SET NOT NULL;
+ALTER TABLE apikeys ADD CONSTRAINT apikeys_key_hash_key UNIQUE (key_hash);
+ALTER TABLE apikeys DROP COLUMN key;
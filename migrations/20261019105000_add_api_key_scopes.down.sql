@@ -0,0 +1,2 @@
+-- +migrate Down
+ALTER TABLE apikeys DROP COLUMN IF EXISTS scopes;
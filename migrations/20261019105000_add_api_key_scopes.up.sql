@@ -0,0 +1,4 @@
+-- +migrate Up
+-- Keys issued before scopes existed keep the full power of their user.
+ALTER TABLE apikeys ADD COLUMN scopes TEXT[] NOT NULL DEFAULT '{}';
+UPDATE apikeys SET scopes = '{*}';
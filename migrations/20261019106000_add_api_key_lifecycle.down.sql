@@ -0,0 +1,4 @@
+-- +migrate Down
+ALTER TABLE apikeys DROP COLUMN IF EXISTS last_used_ip;
+ALTER TABLE apikeys DROP COLUMN IF EXISTS last_used_at;
+ALTER TABLE apikeys DROP COLUMN IF EXISTS expires_at;
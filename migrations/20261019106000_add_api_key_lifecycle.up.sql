@@ -0,0 +1,4 @@
+-- +migrate Up
+ALTER TABLE apikeys ADD COLUMN expires_at TIMESTAMPTZ NULL;
+ALTER TABLE apikeys ADD COLUMN last_used_at TIMESTAMPTZ NULL;
+ALTER TABLE apikeys ADD COLUMN last_used_ip TEXT NULL;
@@ -0,0 +1,2 @@
+-- +migrate Down
+ALTER TABLE links DROP COLUMN IF EXISTS health_error;
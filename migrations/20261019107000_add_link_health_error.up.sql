@@ -0,0 +1,3 @@
+-- +migrate Up
+-- Why the last health check failed, e.g. a DNS or connection error.
+ALTER TABLE links ADD COLUMN health_error TEXT NULL;
@@ -0,0 +1,3 @@
+-- +migrate Up
+-- The key that replaced this one on rotation; a key is rotated only once.
+ALTER TABLE apikeys ADD COLUMN replaced_by BIGINT NULL REFERENCES apikeys(id);